@@ -0,0 +1,162 @@
+package annotationcmd
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+var Command = &cobra.Command{
+	Use:     "annotation",
+	GroupID: "core",
+	Short:   "List and declare the annotation schemas of the workspace",
+	Long: `Annotation schemas declare the type of the values of custom Todo annotations so that every client of the
+workspace validates them when they are added or modified. A schema for a key also applies to every #fragment of the
+key. Annotations without a schema are not validated.`,
+}
+
+type marshallableSchema struct {
+	Key         string   `yaml:"key"`
+	Type        string   `yaml:"type"`
+	Values      []string `yaml:"values,omitempty"`
+	Description string   `yaml:"description,omitempty"`
+}
+
+func preMarshalSchema(s au.AnnotationSchema) marshallableSchema {
+	return marshallableSchema{Key: s.Key, Type: s.Type, Values: s.Values, Description: s.Description}
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List the annotation schemas",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		schemas, err := ws.ListAnnotationSchemas(cmd.Context())
+		if err != nil {
+			return err
+		}
+		output := make([]marshallableSchema, len(schemas))
+		for i, s := range schemas {
+			output[i] = preMarshalSchema(s)
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(output)
+	},
+}
+
+var setCommand = &cobra.Command{
+	Use:   "set <key>",
+	Short: "Declare or replace the schema of an annotation key",
+	Long: `Declare or replace the schema of an annotation key. The type is one of string, integer, number, bool, date,
+enum, or url. Dates are YYYY-MM-DD dates or RFC3339 timestamps, and enums require the allowed values. Existing annotation
+values are not checked.`,
+	Example:    `  au annotation set https://example.com/priority --type enum --value low --value high`,
+	Args:       cobra.ExactArgs(1),
+	ArgAliases: []string{"key"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		params := au.SetAnnotationSchemaParams{Key: args[0]}
+		if v, err := cmd.Flags().GetString("type"); err != nil {
+			return errors.Wrap(err, "failed to get type flag")
+		} else {
+			params.Type = v
+		}
+		if v, err := cmd.Flags().GetStringArray("value"); err != nil {
+			return errors.Wrap(err, "failed to get value flag")
+		} else {
+			params.Values = v
+		}
+		if v, err := cmd.Flags().GetString("description"); err != nil {
+			return errors.Wrap(err, "failed to get description flag")
+		} else {
+			params.Description = v
+		}
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.UpdatedBy = v
+		}
+
+		schema, err := ws.SetAnnotationSchema(cmd.Context(), params)
+		if err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(preMarshalSchema(*schema))
+	},
+}
+
+var deleteCommand = &cobra.Command{
+	Use:        "delete <key>",
+	Short:      "Delete the schema of an annotation key, annotations with the key are kept",
+	Args:       cobra.ExactArgs(1),
+	ArgAliases: []string{"key"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		var params au.DeleteAnnotationSchemaParams
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.DeletedBy = v
+		}
+
+		if err := ws.DeleteAnnotationSchema(cmd.Context(), args[0], params); err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		}
+		return nil
+	},
+}
+
+func init() {
+	setCommand.Flags().String("type", au.AnnotationTypeString, "The type of the annotation values")
+	setCommand.Flags().StringArray("value", []string{}, "An allowed value of an enum annotation, may be repeated")
+	setCommand.Flags().String("description", "", "A single-line description of the annotation")
+
+	Command.AddCommand(
+		listCommand,
+		setCommand,
+		deleteCommand,
+	)
+}
@@ -0,0 +1,83 @@
+package annotationcmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/internal"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+func executeAndResetCommand(ctx context.Context, cmd *cobra.Command, args []string) error {
+	cmd.SetArgs(args)
+	subCmd, err := cmd.ExecuteContextC(ctx)
+	subCmd.SetContext(nil)
+	// flag values otherwise leak into the next execution of the same sub command
+	subCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace([]string{})
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+	return err
+}
+
+func TestCli_annotation(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	assert.Equal(t, "[]\n", buff.String())
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"set", "https://example.com/priority", "--type", "enum", "--value", "low", "--value", "high", "--description", "How urgent it is"}))
+	assert.Equal(t, "key: https://example.com/priority\ntype: enum\nvalues:\n  - high\n  - low\ndescription: How urgent it is\n", buff.String())
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"set", "https://example.com/points", "--type", "integer"}))
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"set", "https://example.com/size", "--type", "enum"}), "enum annotations require at least one value")
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"set", "https://aurelian.one/annotations/rank", "--type", "number"}), "'aurelian.one' annotations cannot have a schema")
+
+	ws, err := s.OpenWorkspace(context.Background(), wsMeta.Id, true)
+	assert.NoError(t, err)
+	_, err = ws.CreateTodo(context.Background(), au.CreateTodoParams{Title: "Todo", CreatedBy: "Example <email@me.com>", Annotations: map[string]string{"https://example.com/priority": "urgent"}})
+	assert.EqualError(t, err, "invalid annotation value for 'https://example.com/priority': 'urgent' is not one of high, low")
+	todo, err := ws.CreateTodo(context.Background(), au.CreateTodoParams{Title: "Todo", CreatedBy: "Example <email@me.com>", Annotations: map[string]string{"https://example.com/priority": "high"}})
+	assert.NoError(t, err)
+	_, err = ws.EditTodo(context.Background(), todo.Id, au.EditTodoParams{Annotations: map[string]string{"https://example.com/points": "three"}, UpdatedBy: "Example <email@me.com>"})
+	assert.EqualError(t, err, "invalid annotation value for 'https://example.com/points': 'three' is not an integer")
+	_, err = ws.EditTodo(context.Background(), todo.Id, au.EditTodoParams{Title: internal.Ref("Renamed"), UpdatedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+	assert.NoError(t, ws.Flush())
+	assert.NoError(t, ws.Close())
+
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"delete", "https://example.com/priority"}))
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"delete", "https://example.com/priority"}), "annotation schema 'https://example.com/priority' does not exist")
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	assert.Equal(t, []map[string]interface{}{{"key": "https://example.com/points", "type": "integer"}}, outSlice)
+}
@@ -0,0 +1,292 @@
+package attachmentcmd
+
+import (
+	"bytes"
+	"fmt"
+	"mime"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/cmd/au/workspacecmd"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+var Command = &cobra.Command{
+	Use:     "attachment",
+	GroupID: "core",
+	Short:   "Attach files to Todos and retrieve them",
+	Long: strings.TrimSpace(`
+Attachments are files stored outside the Workspace document by the hash of their content. The Workspace only contains a
+Comment referencing the attachment by hash, filename, size, and media type, so large files do not bloat the document
+history. Attachments are transferred to and from a server on demand with 'attachment push' and 'attachment get --remote'.
+`),
+}
+
+type marshallableAttachment struct {
+	TodoId    string `yaml:"todo_id,omitempty"`
+	CommentId string `yaml:"comment_id,omitempty"`
+	Hash      string `yaml:"hash"`
+	Filename  string `yaml:"filename"`
+	MediaType string `yaml:"media_type"`
+	Size      int64  `yaml:"size"`
+	Local     bool   `yaml:"local"`
+}
+
+var addCommand = &cobra.Command{
+	Use:        "add <todo-id> <file>",
+	Short:      "Attach a file to a Todo as a new Comment",
+	Args:       cobra.ExactArgs(2),
+	ArgAliases: []string{"todo-id", "file"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		if stat, err := os.Stat(args[1]); err != nil {
+			return errors.Wrap(err, "failed to stat file")
+		} else if stat.Size() > au.MaximumAttachmentSize {
+			return errors.Errorf("file is too large to attach, it should be at most %d bytes", au.MaximumAttachmentSize)
+		}
+		data, err := os.ReadFile(args[1])
+		if err != nil {
+			return errors.Wrap(err, "failed to read file")
+		}
+
+		attachment := au.Attachment{Filename: filepath.Base(args[1]), Size: int64(len(data))}
+		if v, err := cmd.Flags().GetString("media-type"); err != nil {
+			return errors.Wrap(err, "failed to get media-type flag")
+		} else if v != "" {
+			attachment.MediaType = v
+		} else if mt := mime.TypeByExtension(filepath.Ext(args[1])); mt != "" {
+			attachment.MediaType = mt
+		} else {
+			attachment.MediaType = "application/octet-stream"
+		}
+
+		params := au.CreateCommentParams{MediaType: au.DefaultCommentMediaType, Attachment: &attachment}
+		if v, err := cmd.Flags().GetString("caption"); err != nil {
+			return errors.Wrap(err, "failed to get caption flag")
+		} else {
+			params.Content = []byte(v)
+		}
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.CreatedBy = v
+		}
+
+		// the blob is stored first so that the comment never references content which is not available locally
+		if attachment.Hash, err = s.PutBlob(cmd.Context(), data); err != nil {
+			return err
+		}
+		todoId, err := common.ResolveTodoId(cmd.Context(), ws, args[0])
+		if err != nil {
+			return err
+		}
+		comment, err := ws.CreateComment(cmd.Context(), todoId, params)
+		if err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		}
+
+		a := comment.Attachment
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(marshallableAttachment{
+			TodoId: todoId, CommentId: comment.Id, Hash: a.Hash, Filename: a.Filename, MediaType: a.MediaType, Size: a.Size, Local: true,
+		})
+	},
+}
+
+var getCommand = &cobra.Command{
+	Use:        "get <todo-id> <comment-id>",
+	Short:      "Write the content of the attachment on a Comment to standard output or a file",
+	Args:       cobra.ExactArgs(2),
+	ArgAliases: []string{"todo-id", "comment-id"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		todoId, commentId, err := common.ResolveTodoAndCommentIds(cmd.Context(), ws, args[0], args[1])
+		if err != nil {
+			return err
+		}
+		comment, err := ws.GetComment(cmd.Context(), todoId, commentId)
+		if err != nil {
+			return err
+		} else if comment.Attachment == nil {
+			return errors.Errorf("comment '%s' does not have an attachment", commentId)
+		}
+
+		data, err := s.GetBlob(cmd.Context(), comment.Attachment.Hash)
+		if errors.Is(err, os.ErrNotExist) {
+			if remote, _ := cmd.Flags().GetString("remote"); remote != "" {
+				data, err = downloadAttachment(cmd, s, remote, w, comment.Attachment.Hash)
+			} else {
+				return errors.Errorf("attachment '%s' is not available locally, use --remote to download it from a server", comment.Attachment.Hash)
+			}
+		}
+		if err != nil {
+			return err
+		}
+
+		if v, err := cmd.Flags().GetString("output"); err != nil {
+			return errors.Wrap(err, "failed to get output flag")
+		} else if v != "" && v != "-" {
+			return errors.Wrap(os.WriteFile(v, data, os.FileMode(0644)), "failed to write output file")
+		}
+		_, err = cmd.OutOrStdout().Write(data)
+		return err
+	},
+}
+
+// downloadAttachment fetches the attachment from the server and stores it locally once the hash has been verified.
+func downloadAttachment(cmd *cobra.Command, s au.StorageProvider, remote, workspaceId, hash string) ([]byte, error) {
+	c, err := workspacecmd.NewClientWithResponses(remote)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to create client")
+	}
+	resp, err := c.DownloadWorkspaceAttachmentWithResponse(cmd.Context(), workspaceId, hash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to request: %w", err)
+	} else if resp.StatusCode() != http.StatusOK {
+		return nil, errors.Errorf("non-200 response code from attachment api: %d %s", resp.StatusCode(), string(resp.Body))
+	} else if h := au.HashBlob(resp.Body); h != hash {
+		return nil, errors.Errorf("downloaded attachment has hash '%s', expected '%s'", h, hash)
+	}
+	if _, err := s.PutBlob(cmd.Context(), resp.Body); err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List the attachments referenced by the current Workspace and whether they are available locally",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		attachments, err := ws.ListAttachments(cmd.Context())
+		if err != nil {
+			return err
+		}
+		output := make([]marshallableAttachment, len(attachments))
+		for i, a := range attachments {
+			_, err := s.StatBlob(cmd.Context(), a.Hash)
+			output[i] = marshallableAttachment{Hash: a.Hash, Filename: a.Filename, MediaType: a.MediaType, Size: a.Size, Local: err == nil}
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(output)
+	},
+}
+
+var pushCommand = &cobra.Command{
+	Use:        "push <http://localhost:80>",
+	Short:      "Upload the local attachments of the current Workspace that the server does not have yet",
+	Long:       "The server only accepts attachments that are referenced by its copy of the Workspace, so sync the Workspace first.",
+	Args:       cobra.ExactArgs(1),
+	ArgAliases: []string{"address"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		} else if at, _ := cmd.Context().Value(common.AtContextKey).(string); at != "" {
+			return errors.New("--at cannot be used with push since the server only accepts attachments referenced by its latest workspace")
+		}
+		ws, err := s.OpenWorkspace(cmd.Context(), w, false)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		attachments, err := ws.ListAttachments(cmd.Context())
+		if err != nil {
+			return err
+		}
+
+		c, err := workspacecmd.NewClientWithResponses(args[0])
+		if err != nil {
+			return errors.Wrap(err, "failed to create client")
+		}
+		resp, err := c.ListWorkspaceAttachmentsWithResponse(cmd.Context(), w)
+		if err != nil {
+			return fmt.Errorf("failed to request: %w", err)
+		} else if resp.StatusCode() != http.StatusOK {
+			return errors.Errorf("non-200 response code from attachment api: %d %s", resp.StatusCode(), string(resp.Body))
+		}
+
+		pushed := 0
+		for _, a := range attachments {
+			if slices.ContainsFunc(*resp.JSON200, func(r workspacecmd.Attachment) bool { return r.Hash == a.Hash }) {
+				continue
+			}
+			data, err := s.GetBlob(cmd.Context(), a.Hash)
+			if errors.Is(err, os.ErrNotExist) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			if resp, err := c.UploadWorkspaceAttachmentWithBodyWithResponse(cmd.Context(), w, a.Hash, "application/octet-stream", bytes.NewReader(data)); err != nil {
+				return fmt.Errorf("failed to request: %w", err)
+			} else if resp.StatusCode() != http.StatusNoContent {
+				return errors.Errorf("non-204 response code from attachment api for '%s': %d %s", a.Hash, resp.StatusCode(), string(resp.Body))
+			}
+			pushed++
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(map[string]interface{}{"pushed": pushed})
+	},
+}
+
+func init() {
+	addCommand.Flags().String("media-type", "", "Set the media type of the attachment, defaults to a type based on the file extension")
+	addCommand.Flags().String("caption", "", "Set markdown content to show along with the attachment")
+	getCommand.Flags().StringP("output", "o", "-", "Write the attachment to this file instead of standard output")
+	getCommand.Flags().String("remote", "", "Download the attachment from this server if it is not available locally")
+
+	Command.AddCommand(
+		addCommand,
+		getCommand,
+		listCommand,
+		pushCommand,
+	)
+}
@@ -0,0 +1,93 @@
+package attachmentcmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+func executeAndResetCommand(ctx context.Context, cmd *cobra.Command, args []string) error {
+	cmd.SetArgs(args)
+	subCmd, err := cmd.ExecuteContextC(ctx)
+	subCmd.SetContext(nil)
+	// flag values otherwise leak into the next execution of the same sub command
+	subCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		_ = f.Value.Set(f.DefValue)
+		f.Changed = false
+	})
+	return err
+}
+
+func TestCli_attachment(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+	defer os.RemoveAll(td)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+	ws, err := s.OpenWorkspace(context.Background(), wsMeta.Id, true)
+	assert.NoError(t, err)
+	todo, err := ws.CreateTodo(context.Background(), au.CreateTodoParams{Title: "Todo", CreatedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+	assert.NoError(t, ws.Flush())
+	assert.NoError(t, ws.Close())
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	data := []byte{0x89, 'P', 'N', 'G', 0x00, 0x01, 0x02}
+	inputPath := filepath.Join(td, "image.png")
+	assert.NoError(t, os.WriteFile(inputPath, data, os.FileMode(0644)))
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"add", todo.Id, inputPath, "--caption", "A screenshot"}))
+	var out marshallableAttachment
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &out))
+	assert.Equal(t, marshallableAttachment{
+		TodoId: todo.Id, CommentId: out.CommentId, Hash: au.HashBlob(data), Filename: "image.png", MediaType: "image/png", Size: 7, Local: true,
+	}, out)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"get", todo.Id, out.CommentId}))
+	assert.Equal(t, data, buff.Bytes())
+
+	outputPath := filepath.Join(td, "output.png")
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"get", todo.Id, out.CommentId, "--output", outputPath}))
+	written, err := os.ReadFile(outputPath)
+	assert.NoError(t, err)
+	assert.Equal(t, data, written)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	var outSlice []marshallableAttachment
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	assert.Equal(t, []marshallableAttachment{{Hash: au.HashBlob(data), Filename: "image.png", MediaType: "image/png", Size: 7, Local: true}}, outSlice)
+
+	// simulate a workspace synced from another device where the blob has not been downloaded yet
+	assert.NoError(t, os.RemoveAll(filepath.Join(td, "blobs")))
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"get", todo.Id, out.CommentId}), "attachment '"+au.HashBlob(data)+"' is not available locally, use --remote to download it from a server")
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	assert.False(t, outSlice[0].Local)
+
+	atCtx := context.WithValue(ctx, common.AtContextKey, "2000-01-01")
+	assert.EqualError(t, executeAndResetCommand(atCtx, Command, []string{"push", "http://127.0.0.1:1"}), "--at cannot be used with push since the server only accepts attachments referenced by its latest workspace")
+}
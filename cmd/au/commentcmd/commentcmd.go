@@ -8,6 +8,7 @@ import (
 	"os"
 	"path/filepath"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/pkg/errors"
@@ -27,12 +28,24 @@ var Command = &cobra.Command{
 
 type marshallableComment struct {
 	Id        string     `yaml:"id"`
+	ShortId   string     `yaml:"short_id,omitempty"`
 	CreatedAt time.Time  `yaml:"created_at"`
 	CreatedBy string     `yaml:"created_by"`
 	UpdatedAt *time.Time `yaml:"updated_at,omitempty"`
 	UpdatedBy *string    `yaml:"updated_by,omitempty"`
 	MediaType string     `yaml:"media_type"`
 	Content   string     `yaml:"content"`
+
+	Attachment *marshallableAttachment `yaml:"attachment,omitempty"`
+	ReplyTo    *string                 `yaml:"reply_to,omitempty"`
+	Replies    []interface{}           `yaml:"replies,omitempty"`
+}
+
+type marshallableAttachment struct {
+	Hash      string `yaml:"hash"`
+	Filename  string `yaml:"filename"`
+	MediaType string `yaml:"media_type"`
+	Size      int64  `yaml:"size"`
 }
 
 func preMarshalComment(comment *au.Comment, snipRaw bool) interface{} {
@@ -43,6 +56,7 @@ func preMarshalComment(comment *au.Comment, snipRaw bool) interface{} {
 		UpdatedAt: comment.UpdatedAt,
 		UpdatedBy: comment.UpdatedBy,
 		MediaType: comment.MediaType,
+		ReplyTo:   comment.ReplyTo,
 	}
 	if comment.MediaType == au.DefaultCommentMediaType {
 		out.Content = string(comment.Content)
@@ -51,6 +65,9 @@ func preMarshalComment(comment *au.Comment, snipRaw bool) interface{} {
 	} else {
 		out.Content = base64.RawStdEncoding.EncodeToString(comment.Content)
 	}
+	if a := comment.Attachment; a != nil {
+		out.Attachment = &marshallableAttachment{Hash: a.Hash, Filename: a.Filename, MediaType: a.MediaType, Size: a.Size}
+	}
 	return out
 }
 
@@ -65,13 +82,17 @@ var getCommand = &cobra.Command{
 		if w == "" {
 			return errors.New("current workspace not set")
 		}
-		ws, err := s.OpenWorkspace(cmd.Context(), w, false)
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
 		if err != nil {
 			return err
 		}
 		defer ws.Close()
 
-		comment, err := ws.GetComment(cmd.Context(), cmd.Flags().Arg(0), cmd.Flags().Arg(1))
+		todoId, commentId, err := common.ResolveTodoAndCommentIds(cmd.Context(), ws, cmd.Flags().Arg(0), cmd.Flags().Arg(1))
+		if err != nil {
+			return err
+		}
+		comment, err := ws.GetComment(cmd.Context(), todoId, commentId)
 		if err != nil {
 			return err
 		}
@@ -98,32 +119,75 @@ var listCommand = &cobra.Command{
 		if w == "" {
 			return errors.New("current workspace not set")
 		}
-		ws, err := s.OpenWorkspace(cmd.Context(), w, false)
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
 		if err != nil {
 			return err
 		}
 		defer ws.Close()
 
-		comments, err := ws.ListComments(cmd.Context(), cmd.Flags().Arg(0))
+		todoId, err := common.ResolveTodoId(cmd.Context(), ws, cmd.Flags().Arg(0))
+		if err != nil {
+			return err
+		}
+		comments, err := ws.ListComments(cmd.Context(), todoId)
 		if err != nil {
 			return err
 		}
 
 		slices.SortFunc(comments, func(a, b au.Comment) int {
-			return a.CreatedAt.Compare(b.CreatedAt)
+			if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+				return c
+			}
+			return strings.Compare(a.Id, b.Id)
 		})
 
-		preMarshalledComment := make([]interface{}, len(comments))
+		flat, err := cmd.Flags().GetBool("flat")
+		if err != nil {
+			return errors.Wrap(err, "failed to get flat flag")
+		}
+
+		ids := make([]string, len(comments))
 		for i, c := range comments {
-			preMarshalledComment[i] = preMarshalComment(&c, true)
+			ids[i] = c.Id
 		}
+		shortIds := au.ShortIds(ids)
 
 		encoder := yaml.NewEncoder(cmd.OutOrStdout())
 		encoder.SetIndent(2)
-		return encoder.Encode(preMarshalledComment)
+		if flat {
+			preMarshalledComment := make([]interface{}, len(comments))
+			for i, c := range comments {
+				mc := preMarshalComment(&c, true).(*marshallableComment)
+				mc.ShortId = shortIds[c.Id]
+				preMarshalledComment[i] = mc
+			}
+			return encoder.Encode(preMarshalledComment)
+		}
+		return encoder.Encode(preMarshalThreads(comments, shortIds))
 	},
 }
 
+// preMarshalThreads nests each comment under the comment it replies to. Comments replying to a comment that no longer
+// exists are shown at the top level. The order of the input is preserved within each level.
+func preMarshalThreads(comments []au.Comment, shortIds map[string]string) []interface{} {
+	byId := make(map[string]*marshallableComment, len(comments))
+	for _, c := range comments {
+		byId[c.Id] = preMarshalComment(&c, true).(*marshallableComment)
+		byId[c.Id].ShortId = shortIds[c.Id]
+	}
+	output := make([]interface{}, 0)
+	for _, c := range comments {
+		if c.ReplyTo != nil {
+			if parent, ok := byId[*c.ReplyTo]; ok {
+				parent.Replies = append(parent.Replies, byId[c.Id])
+				continue
+			}
+		}
+		output = append(output, byId[c.Id])
+	}
+	return output
+}
+
 func readMarkdown(flagValue string, stdin io.Reader) (content []byte, mediaType string, err error) {
 	if flagValue == "-" {
 		content, err := io.ReadAll(stdin)
@@ -172,7 +236,7 @@ var createCommand = &cobra.Command{
 		if w == "" {
 			return errors.New("current workspace not set")
 		}
-		ws, err := s.OpenWorkspace(cmd.Context(), w, true)
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
 		if err != nil {
 			return err
 		}
@@ -212,15 +276,25 @@ var createCommand = &cobra.Command{
 			params.Content = []byte(after)
 		}
 
-		if v, ok := cmd.Context().Value(common.CurrentAuthorContextKey).(string); ok && v != "" {
-			params.CreatedBy = v
-		} else if v := ws.Metadata().CurrentAuthor; v != nil {
-			params.CreatedBy = *v
+		todoId, err := common.ResolveTodoId(cmd.Context(), ws, cmd.Flags().Arg(0))
+		if err != nil {
+			return err
+		}
+		if v, err := cmd.Flags().GetString("reply-to"); err != nil {
+			return errors.Wrap(err, "failed to get reply-to flag")
+		} else if v != "" {
+			if params.ReplyTo, err = common.ResolveCommentId(cmd.Context(), ws, todoId, v); err != nil {
+				return err
+			}
+		}
+
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
 		} else {
-			return errors.New("no author set, please set one for the current workspace")
+			params.CreatedBy = v
 		}
 
-		if comment, err := ws.CreateComment(cmd.Context(), cmd.Flags().Arg(0), params); err != nil {
+		if comment, err := ws.CreateComment(cmd.Context(), todoId, params); err != nil {
 			return err
 		} else if err := ws.Flush(); err != nil {
 			return errors.Wrap(err, "failed to flush to file")
@@ -243,13 +317,17 @@ var editCommand = &cobra.Command{
 		if w == "" {
 			return errors.New("current workspace not set")
 		}
-		ws, err := s.OpenWorkspace(cmd.Context(), w, true)
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
 		if err != nil {
 			return err
 		}
 		defer ws.Close()
 
-		comment, err := ws.GetComment(cmd.Context(), cmd.Flags().Arg(0), cmd.Flags().Arg(1))
+		todoId, commentId, err := common.ResolveTodoAndCommentIds(cmd.Context(), ws, cmd.Flags().Arg(0), cmd.Flags().Arg(1))
+		if err != nil {
+			return err
+		}
+		comment, err := ws.GetComment(cmd.Context(), todoId, commentId)
 		if err != nil {
 			return err
 		}
@@ -269,12 +347,10 @@ var editCommand = &cobra.Command{
 			}
 		}
 
-		if v, ok := cmd.Context().Value(common.CurrentAuthorContextKey).(string); ok && v != "" {
-			params.UpdatedBy = v
-		} else if v := ws.Metadata().CurrentAuthor; v != nil {
-			params.UpdatedBy = *v
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
 		} else {
-			return errors.New("no author set, please set one for the current workspace")
+			params.UpdatedBy = v
 		}
 
 		if v, err := cmd.Flags().GetBool("edit"); err != nil {
@@ -291,7 +367,7 @@ var editCommand = &cobra.Command{
 			params.Content = []byte(after)
 		}
 
-		if comment, err := ws.EditComment(cmd.Context(), cmd.Flags().Arg(0), cmd.Flags().Arg(1), params); err != nil {
+		if comment, err := ws.EditComment(cmd.Context(), todoId, commentId, params); err != nil {
 			return err
 		} else if err := ws.Flush(); err != nil {
 			return errors.Wrap(err, "failed to flush to file")
@@ -306,6 +382,7 @@ var editCommand = &cobra.Command{
 var deleteCommand = &cobra.Command{
 	Use:        "delete <todo-id> <comment-id>",
 	Short:      "Delete a Comment from a Todo",
+	Long:       "Replies to the deleted Comment are kept and become replies to its parent, or top level Comments if it had no parent.",
 	Args:       cobra.ExactArgs(2),
 	ArgAliases: []string{"todo-id", "comment-id"},
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -314,22 +391,24 @@ var deleteCommand = &cobra.Command{
 		if w == "" {
 			return errors.New("current workspace not set")
 		}
-		ws, err := s.OpenWorkspace(cmd.Context(), w, true)
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
 		if err != nil {
 			return err
 		}
 		defer ws.Close()
 
 		var params au.DeleteCommentParams
-		if v, ok := cmd.Context().Value(common.CurrentAuthorContextKey).(string); ok && v != "" {
-			params.DeletedBy = v
-		} else if v := ws.Metadata().CurrentAuthor; v != nil {
-			params.DeletedBy = *v
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
 		} else {
-			return errors.New("no author set, please set one for the current workspace")
+			params.DeletedBy = v
 		}
 
-		if err := ws.DeleteComment(cmd.Context(), cmd.Flags().Arg(0), cmd.Flags().Arg(1), params); err != nil {
+		todoId, commentId, err := common.ResolveTodoAndCommentIds(cmd.Context(), ws, cmd.Flags().Arg(0), cmd.Flags().Arg(1))
+		if err != nil {
+			return err
+		}
+		if err := ws.DeleteComment(cmd.Context(), todoId, commentId, params); err != nil {
 			return err
 		} else if err := ws.Flush(); err != nil {
 			return errors.Wrap(err, "failed to flush to file")
@@ -338,15 +417,49 @@ var deleteCommand = &cobra.Command{
 	},
 }
 
+var historyCommand = &cobra.Command{
+	Use:        "history <todo-id> <comment-id>",
+	Short:      "Show the audit trail of changes made to a Comment",
+	Args:       cobra.ExactArgs(2),
+	ArgAliases: []string{"todo-id", "comment-id"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		todoId, commentId, err := common.ResolveTodoAndCommentIds(cmd.Context(), ws, cmd.Flags().Arg(0), cmd.Flags().Arg(1))
+		if err != nil {
+			return err
+		}
+		history, err := ws.GetCommentHistory(cmd.Context(), todoId, commentId)
+		if err != nil {
+			return err
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(common.PreMarshalHistory(history))
+	},
+}
+
 func init() {
 	createCommand.Flags().StringP("markdown", "m", "", "Set the markdown content of the comment, or - to read from stdin")
 	createCommand.Flags().String("content", "", "Set the content of the comment as raw bytes from a file, or - to indicate standard input")
 	createCommand.Flags().Bool("edit", false, "Edit the content using AU_EDITOR")
+	createCommand.Flags().String("reply-to", "", "Create the comment as a reply to this comment on the same Todo")
 
 	editCommand.Flags().StringP("markdown", "m", "", "Set the markdown content of the comment, or - to read from stdin")
 	editCommand.Flags().Bool("edit", false, "Edit the content using AU_EDITOR")
 
 	getCommand.Flags().Bool("raw", false, "Show the base64 encoded content for non markdown comments")
+	listCommand.Flags().Bool("flat", false, "List the comments in creation order without nesting replies under their parent")
 
 	Command.AddCommand(
 		getCommand,
@@ -354,6 +467,7 @@ func init() {
 		createCommand,
 		editCommand,
 		deleteCommand,
+		historyCommand,
 	)
 
 	// add some supplimental media types
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
 	"github.com/spf13/cobra"
@@ -101,6 +102,7 @@ func TestComment(t *testing.T) {
 			assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &out))
 			assert.Len(t, out, 1)
 			assert.Equal(t, "Something\nElse", out[0]["content"])
+			assert.Equal(t, commentId[len(commentId)-4:], out[0]["short_id"])
 		})
 
 		t.Run("get", func(t *testing.T) {
@@ -113,6 +115,16 @@ func TestComment(t *testing.T) {
 			assert.Nil(t, out["updated_by"])
 		})
 
+		t.Run("get by short ids", func(t *testing.T) {
+			buff.Reset()
+			assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"get", todoId[len(todoId)-6:], strings.ToLower(commentId[len(commentId)-6:])}))
+			assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &out))
+			assert.Equal(t, "Something\nElse", out["content"])
+			assert.Equal(t, commentId, out["id"].(string))
+			assert.Nil(t, out["updated_at"])
+			assert.Nil(t, out["updated_by"])
+		})
+
 		t.Run("edit", func(t *testing.T) {
 			buff.Reset()
 			assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"edit", todoId, commentId, "--markdown", "New Content"}))
@@ -205,3 +217,76 @@ func TestCli_todo_authors(t *testing.T) {
 	assert.NotNil(t, outStruct["updated_at"])
 	assert.Equal(t, "Example2 <email@me.com>", outStruct["updated_by"])
 }
+
+func TestCli_comment_threads(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	var todoId string
+	{
+		openWs, err := s.OpenWorkspace(context.Background(), wsMeta.Id, true)
+		assert.NoError(t, err)
+		todo, err := openWs.CreateTodo(context.Background(), au.CreateTodoParams{Title: "something", CreatedBy: "Example <email@me.com>"})
+		assert.NoError(t, err)
+		todoId = todo.Id
+		assert.NoError(t, openWs.Flush())
+		assert.NoError(t, openWs.Close())
+	}
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	create := func(args ...string) string {
+		buff.Reset()
+		assert.NoError(t, executeAndResetCommand(ctx, Command, append([]string{"create", todoId}, args...)))
+		var out map[string]interface{}
+		assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &out))
+		return out["id"].(string)
+	}
+	question := create("--markdown", "Question")
+	answer := create("--markdown", "Answer", "--reply-to", question)
+	create("--markdown", "Thanks", "--reply-to", answer)
+	create("--markdown", "Unrelated")
+
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"create", todoId, "--markdown", "x", "--reply-to", "unknown"}), "invalid reply to: comment with id 'unknown' does not exist")
+
+	type threaded struct {
+		Content string     `yaml:"content"`
+		ReplyTo string     `yaml:"reply_to"`
+		Replies []threaded `yaml:"replies"`
+	}
+	var out []threaded
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list", todoId}))
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &out))
+	assert.Equal(t, []threaded{
+		{Content: "Question", Replies: []threaded{{Content: "Answer", ReplyTo: question, Replies: []threaded{{Content: "Thanks", ReplyTo: answer}}}}},
+		{Content: "Unrelated"},
+	}, out)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list", todoId, "--flat"}))
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &out))
+	assert.Len(t, out, 4)
+
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"delete", todoId, answer}))
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list", todoId}))
+	out = nil
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &out))
+	assert.Equal(t, []threaded{
+		{Content: "Question", Replies: []threaded{{Content: "Thanks", ReplyTo: question}}},
+		{Content: "Unrelated"},
+	}, out)
+}
@@ -0,0 +1,50 @@
+package common
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+// CurrentAuthor returns the author from --current-author or the workspace author file. When the author is one of the
+// members of the workspace, their canonical 'Name <email>' is returned instead.
+func CurrentAuthor(ctx context.Context, ws au.WorkspaceProvider) (string, error) {
+	author, _, err := resolveCurrentAuthor(ctx, ws)
+	return author, err
+}
+
+// CurrentWritingAuthor returns the CurrentAuthor for a command that changes the workspace and warns when the workspace
+// has members but the author is not one of them.
+func CurrentWritingAuthor(cmd *cobra.Command, ws au.WorkspaceProvider) (string, error) {
+	author, known, err := resolveCurrentAuthor(cmd.Context(), ws)
+	if err != nil {
+		return "", err
+	} else if !known {
+		_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "warning: author '%s' is not a member of this workspace, see 'au member add'\n", author)
+	}
+	return author, nil
+}
+
+func resolveCurrentAuthor(ctx context.Context, ws au.WorkspaceProvider) (string, bool, error) {
+	var author string
+	if v, ok := ctx.Value(CurrentAuthorContextKey).(string); ok && v != "" {
+		author = v
+	} else if v := ws.Metadata().CurrentAuthor; v != nil {
+		author = *v
+	} else {
+		return "", false, errors.New("no author set, please set one for the current workspace")
+	}
+	members, err := ws.ListMembers(ctx)
+	if err != nil {
+		return "", false, err
+	} else if len(members) == 0 {
+		return author, true, nil
+	} else if m := au.ResolveMember(members, author); m != nil {
+		return m.Author(), true, nil
+	}
+	return author, false, nil
+}
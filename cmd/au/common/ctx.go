@@ -6,3 +6,4 @@ const StorageContextKey = contextKey(0)
 const CurrentWorkspaceIdContextKey = contextKey(1)
 const CurrentAuthorContextKey = contextKey(2)
 const ListenerRefContextKey = contextKey(3)
+const AtContextKey = contextKey(4)
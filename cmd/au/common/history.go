@@ -0,0 +1,38 @@
+package common
+
+import (
+	"time"
+
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+type marshallableFieldChange struct {
+	Field  string  `yaml:"field"`
+	Before *string `yaml:"before,omitempty"`
+	After  *string `yaml:"after,omitempty"`
+}
+
+type marshallableHistoryEntry struct {
+	Hash    string                    `yaml:"hash"`
+	At      time.Time                 `yaml:"at"`
+	Author  string                    `yaml:"author,omitempty"`
+	Message string                    `yaml:"message,omitempty"`
+	Changes []marshallableFieldChange `yaml:"changes"`
+}
+
+func PreMarshalHistory(entries []au.HistoryEntry) interface{} {
+	output := make([]marshallableHistoryEntry, len(entries))
+	for i, entry := range entries {
+		output[i] = marshallableHistoryEntry{
+			Hash:    entry.Hash,
+			At:      entry.At,
+			Author:  entry.Author,
+			Message: entry.Message,
+			Changes: make([]marshallableFieldChange, len(entry.Changes)),
+		}
+		for j, change := range entry.Changes {
+			output[i].Changes[j] = marshallableFieldChange{Field: change.Field, Before: change.Before, After: change.After}
+		}
+	}
+	return output
+}
@@ -0,0 +1,83 @@
+package common
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+// ResolveTodoId returns the id of the todo referenced by its id or a unique prefix or suffix of it.
+func ResolveTodoId(ctx context.Context, ws au.WorkspaceProvider, input string) (string, error) {
+	todos, err := ws.ListTodos(ctx)
+	if err != nil {
+		return "", err
+	}
+	ids := make([]string, len(todos))
+	for i, t := range todos {
+		ids[i] = t.Id
+	}
+	return au.ResolveShortId("todo", input, ids)
+}
+
+// ResolveCommentId returns the id of the comment on the todo referenced by its id or a unique prefix or suffix of it.
+func ResolveCommentId(ctx context.Context, ws au.WorkspaceProvider, todoId string, input string) (string, error) {
+	comments, err := ws.ListComments(ctx, todoId)
+	if err != nil {
+		return "", err
+	}
+	ids := make([]string, len(comments))
+	for i, c := range comments {
+		ids[i] = c.Id
+	}
+	return au.ResolveShortId("comment", input, ids)
+}
+
+// ResolveTodoAndCommentIds resolves the todo id and then the comment id within that todo.
+func ResolveTodoAndCommentIds(ctx context.Context, ws au.WorkspaceProvider, todoInput, commentInput string) (string, string, error) {
+	todoId, err := ResolveTodoId(ctx, ws, todoInput)
+	if err != nil {
+		return "", "", err
+	}
+	commentId, err := ResolveCommentId(ctx, ws, todoId, commentInput)
+	if err != nil {
+		return "", "", err
+	}
+	return todoId, commentId, nil
+}
+
+// ResolveWorkspaceId returns the id of the workspace referenced by its id, its alias, or a unique prefix or suffix of
+// its id. An alias shared by several workspaces is ambiguous. Unlike todos and comments, an unknown workspace is reported
+// here since opening it would otherwise fail with a file error.
+func ResolveWorkspaceId(ctx context.Context, s au.StorageProvider, input string) (string, error) {
+	workspaces, err := s.ListWorkspaces(ctx)
+	if err != nil {
+		return "", err
+	}
+	ids := make([]string, len(workspaces))
+	aliased := make([]string, 0)
+	for i, w := range workspaces {
+		ids[i] = w.Id
+		if w.Alias == input {
+			aliased = append(aliased, w.Id)
+		}
+	}
+	if slices.Contains(ids, input) {
+		return input, nil
+	} else if len(aliased) == 1 {
+		return aliased[0], nil
+	} else if len(aliased) > 1 {
+		slices.Sort(aliased)
+		return "", errors.Errorf("workspace alias '%s' is ambiguous, it matches: %s", input, strings.Join(aliased, ", "))
+	}
+	id, err := au.ResolveShortId("workspace", input, ids)
+	if err != nil {
+		return "", err
+	} else if !slices.Contains(ids, id) {
+		return "", errors.Errorf("workspace '%s' does not exist", input)
+	}
+	return id, nil
+}
@@ -0,0 +1,41 @@
+package common
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+// OpenReadableWorkspace opens the workspace for reading. If a point in history was provided through --at, this returns
+// a read-only view of the workspace as it was at that point.
+func OpenReadableWorkspace(ctx context.Context, s au.StorageProvider, id string) (au.WorkspaceProvider, error) {
+	ws, err := s.OpenWorkspace(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	at, _ := ctx.Value(AtContextKey).(string)
+	if at == "" {
+		return ws, nil
+	}
+	defer ws.Close()
+	dws, ok := ws.(au.DocProvider)
+	if !ok {
+		return nil, errors.New("no access to doc")
+	}
+	heads, err := au.ResolveHeads(dws.GetDoc(), at)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to resolve --at")
+	}
+	return s.OpenWorkspaceAt(ctx, id, heads)
+}
+
+// OpenWritableWorkspace opens the workspace for writing. Changes are always made on top of the latest state of the
+// workspace, so this fails if a point in history was provided through --at rather than silently ignoring it.
+func OpenWritableWorkspace(ctx context.Context, s au.StorageProvider, id string) (au.WorkspaceProvider, error) {
+	if at, _ := ctx.Value(AtContextKey).(string); at != "" {
+		return nil, errors.New("--at cannot be used with commands that change the workspace")
+	}
+	return s.OpenWorkspace(ctx, id, true)
+}
@@ -35,7 +35,7 @@ var dumpCommand = &cobra.Command{
 		if w == "" {
 			return errors.New("current workspace not set")
 		}
-		ws, err := s.OpenWorkspace(cmd.Context(), w, false)
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
 		if err != nil {
 			return err
 		}
@@ -62,7 +62,7 @@ var generateYamlHistory = &cobra.Command{
 		if w == "" {
 			return errors.New("current workspace not set")
 		}
-		ws, err := s.OpenWorkspace(cmd.Context(), w, false)
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
 		if err != nil {
 			return err
 		}
@@ -106,7 +106,7 @@ var generateDotHistory = &cobra.Command{
 		if w == "" {
 			return errors.New("current workspace not set")
 		}
-		ws, err := s.OpenWorkspace(cmd.Context(), w, false)
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
 		if err != nil {
 			return err
 		}
@@ -205,6 +205,8 @@ This command generates a linear history. To generate a branching history, the wo
 		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
 		if w == "" {
 			return errors.New("current workspace not set")
+		} else if at, _ := cmd.Context().Value(common.AtContextKey).(string); at != "" {
+			return errors.New("--at cannot be used with fake-data, use --backtrack to start from an earlier change")
 		}
 
 		numOperations, err := cmd.Flags().GetInt("num")
@@ -0,0 +1,116 @@
+package keycmd
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+var Command = &cobra.Command{
+	Use:     "key",
+	GroupID: "core",
+	Short:   "Generate and trust the ed25519 keys that sign changes",
+	Long: `Keys are stored in the 'keys' directory of the storage directory. When the author of a change has a private key,
+every change they make is signed with it, and the signature is stored at the end of the commit message of the change.
+Public keys of other authors must be trusted before their changes verify, see 'au workspace verify'.`,
+}
+
+type marshallableAuthorKeys struct {
+	Email      string   `yaml:"email"`
+	PublicKeys []string `yaml:"public_keys"`
+}
+
+var generateCommand = &cobra.Command{
+	Use:   "generate [<'Name <email>'>]",
+	Short: "Generate a new key pair for an author, by default the current author",
+	Long: `Generate a new key pair for an author, by default the current author. The new key signs all future changes by the
+author, while their previous public keys stay trusted so that older changes still verify. Share the printed public key
+with the other members and servers of the workspace so that they can trust it.`,
+	Args:       cobra.MaximumNArgs(1),
+	ArgAliases: []string{"author"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		var author string
+		if len(args) > 0 {
+			author = args[0]
+		} else {
+			w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+			if w == "" {
+				return errors.New("current workspace not set")
+			}
+			ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
+			if err != nil {
+				return err
+			}
+			defer ws.Close()
+			if author, err = common.CurrentAuthor(cmd.Context(), ws); err != nil {
+				return err
+			}
+		}
+
+		key, err := s.GenerateSigningKey(cmd.Context(), author)
+		if err != nil {
+			return err
+		}
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(map[string]string{"author": author, "public_key": au.EncodePublicKey(key)})
+	},
+}
+
+var trustCommand = &cobra.Command{
+	Use:        "trust <'Name <email>'> <public-key>",
+	Short:      "Trust a public key for the changes of an author",
+	Args:       cobra.ExactArgs(2),
+	ArgAliases: []string{"author", "public-key"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		key, err := au.ParsePublicKey(args[1])
+		if err != nil {
+			return err
+		}
+		return s.TrustPublicKey(cmd.Context(), args[0], key)
+	},
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List the trusted public keys of each author",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		keys, err := s.ListPublicKeys(cmd.Context())
+		if err != nil {
+			return err
+		}
+		output := make([]marshallableAuthorKeys, 0, len(keys))
+		for email, authorKeys := range keys {
+			entry := marshallableAuthorKeys{Email: email, PublicKeys: make([]string, len(authorKeys))}
+			for i, k := range authorKeys {
+				entry.PublicKeys[i] = au.EncodePublicKey(k)
+			}
+			output = append(output, entry)
+		}
+		slices.SortFunc(output, func(a, b marshallableAuthorKeys) int {
+			return strings.Compare(a.Email, b.Email)
+		})
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(output)
+	},
+}
+
+func init() {
+	Command.AddCommand(
+		generateCommand,
+		trustCommand,
+		listCommand,
+	)
+}
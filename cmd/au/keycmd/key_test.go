@@ -0,0 +1,73 @@
+package keycmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+func executeAndResetCommand(ctx context.Context, cmd *cobra.Command, args []string) error {
+	cmd.SetArgs(args)
+	subCmd, err := cmd.ExecuteContextC(ctx)
+	subCmd.SetContext(nil)
+	// flag values otherwise leak into the next execution of the same sub command
+	subCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace([]string{})
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+	return err
+}
+
+func TestCli_key(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+	defer os.RemoveAll(td)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	outBuff := new(bytes.Buffer)
+	Command.SetOut(outBuff)
+	Command.SetErr(new(bytes.Buffer))
+
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	assert.Equal(t, "[]\n", outBuff.String())
+
+	outBuff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"generate"}))
+	var generated map[string]string
+	assert.NoError(t, yaml.Unmarshal(outBuff.Bytes(), &generated))
+	assert.Equal(t, "Example <email@me.com>", generated["author"])
+
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"trust", "Other <other@me.com>", generated["public_key"]}))
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"trust", "Other <other@me.com>", "bm9wZQ=="}), "invalid public key, expected a base64 ed25519 public key")
+
+	outBuff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	assert.Equal(t, `- email: email@me.com
+  public_keys:
+    - `+generated["public_key"]+`
+- email: other@me.com
+  public_keys:
+    - `+generated["public_key"]+`
+`, outBuff.String())
+}
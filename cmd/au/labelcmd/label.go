@@ -0,0 +1,103 @@
+package labelcmd
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+var Command = &cobra.Command{
+	Use:     "label",
+	GroupID: "core",
+	Short:   "List and rename the labels used on Todos",
+	Long:    "Labels are stored as https://aurelian.one/annotations/label#<label> annotations on each Todo. Use 'todo label' to add or remove them.",
+}
+
+type marshallableLabel struct {
+	Label string `yaml:"label"`
+	Count int    `yaml:"count"`
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List the labels in use along with the number of Todos that have each",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		labels, err := ws.ListLabels(cmd.Context())
+		if err != nil {
+			return err
+		}
+		output := make([]marshallableLabel, 0, len(labels))
+		for l, c := range labels {
+			output = append(output, marshallableLabel{Label: l, Count: c})
+		}
+		slices.SortFunc(output, func(a, b marshallableLabel) int {
+			return strings.Compare(a.Label, b.Label)
+		})
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(output)
+	},
+}
+
+var renameCommand = &cobra.Command{
+	Use:        "rename <from> <to>",
+	Short:      "Rename a label on every Todo that has it",
+	Args:       cobra.ExactArgs(2),
+	ArgAliases: []string{"from", "to"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		params := au.RenameLabelParams{From: args[0], To: args[1]}
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.RenamedBy = v
+		}
+
+		count, err := ws.RenameLabel(cmd.Context(), params)
+		if err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(map[string]interface{}{"renamed": count})
+	},
+}
+
+func init() {
+	Command.AddCommand(
+		listCommand,
+		renameCommand,
+	)
+}
@@ -0,0 +1,67 @@
+package labelcmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+func executeAndResetCommand(ctx context.Context, cmd *cobra.Command, args []string) error {
+	cmd.SetArgs(args)
+	subCmd, err := cmd.ExecuteContextC(ctx)
+	subCmd.SetContext(nil)
+	return err
+}
+
+func TestCli_labels(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+	ws, err := s.OpenWorkspace(context.Background(), wsMeta.Id, true)
+	assert.NoError(t, err)
+	for _, labels := range [][]string{{"bug"}, {"bug", "ui"}, {}} {
+		annotations := make(map[string]string)
+		for _, l := range labels {
+			annotations[au.LabelAnnotationKey(l)] = "true"
+		}
+		_, err := ws.CreateTodo(context.Background(), au.CreateTodoParams{Title: "Todo", Annotations: annotations, CreatedBy: "Example <email@me.com>"})
+		assert.NoError(t, err)
+	}
+	assert.NoError(t, ws.Flush())
+	assert.NoError(t, ws.Close())
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	assert.Equal(t, []map[string]interface{}{{"label": "bug", "count": 2}, {"label": "ui", "count": 1}}, outSlice)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"rename", "bug", "defect"}))
+	assert.Equal(t, "renamed: 2\n", buff.String())
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	assert.Equal(t, []map[string]interface{}{{"label": "defect", "count": 2}, {"label": "ui", "count": 1}}, outSlice)
+}
@@ -13,9 +13,16 @@ import (
 	"github.com/pkg/errors"
 	"github.com/spf13/cobra"
 
+	"github.com/aurelian-one/au/cmd/au/annotationcmd"
+	"github.com/aurelian-one/au/cmd/au/attachmentcmd"
 	"github.com/aurelian-one/au/cmd/au/commentcmd"
 	"github.com/aurelian-one/au/cmd/au/common"
 	"github.com/aurelian-one/au/cmd/au/devcmd"
+	"github.com/aurelian-one/au/cmd/au/keycmd"
+	"github.com/aurelian-one/au/cmd/au/labelcmd"
+	"github.com/aurelian-one/au/cmd/au/membercmd"
+	"github.com/aurelian-one/au/cmd/au/statuscmd"
+	"github.com/aurelian-one/au/cmd/au/templatecmd"
 	"github.com/aurelian-one/au/cmd/au/todocmd"
 	"github.com/aurelian-one/au/cmd/au/workspacecmd"
 	"github.com/aurelian-one/au/pkg/au"
@@ -34,7 +41,7 @@ Find more information at: https://github.com/aurelian-one/au`),
 		if err := setupLogger(cmd); err != nil {
 			return err
 		}
-		if err := resolveConfigDirectoryAndWorkspace(cmd, "directory", "current-workspace", "author"); err != nil {
+		if err := resolveConfigDirectoryAndWorkspace(cmd, "directory", "current-workspace", "current-author", "at"); err != nil {
 			return err
 		}
 		return nil
@@ -74,7 +81,7 @@ func setupLogger(cmd *cobra.Command) error {
 	return nil
 }
 
-func resolveConfigDirectoryAndWorkspace(cmd *cobra.Command, directoryFlag string, workspaceFlag string, authorFlag string) error {
+func resolveConfigDirectoryAndWorkspace(cmd *cobra.Command, directoryFlag string, workspaceFlag string, authorFlag string, atFlag string) error {
 	directoryValue, err := cmd.Flags().GetString(directoryFlag)
 	if err != nil {
 		return err
@@ -101,6 +108,8 @@ func resolveConfigDirectoryAndWorkspace(cmd *cobra.Command, directoryFlag string
 		} else {
 			workspaceValue = r
 		}
+	} else if workspaceValue, err = common.ResolveWorkspaceId(cmd.Context(), directoryStorage, workspaceValue); err != nil {
+		return err
 	}
 
 	authorValue, _ := cmd.Flags().GetString(authorFlag)
@@ -112,6 +121,13 @@ func resolveConfigDirectoryAndWorkspace(cmd *cobra.Command, directoryFlag string
 	cmd.SetContext(context.WithValue(cmd.Context(), common.StorageContextKey, directoryStorage))
 	cmd.SetContext(context.WithValue(cmd.Context(), common.CurrentWorkspaceIdContextKey, workspaceValue))
 	cmd.SetContext(context.WithValue(cmd.Context(), common.CurrentAuthorContextKey, currentAuthor))
+
+	// commands such as 'todo time log' define their own --at flag, which shadows the global one
+	var atValue string
+	if f := cmd.Flags().Lookup(atFlag); f != nil && f == cmd.Root().PersistentFlags().Lookup(atFlag) {
+		atValue = f.Value.String()
+	}
+	cmd.SetContext(context.WithValue(cmd.Context(), common.AtContextKey, atValue))
 	return nil
 }
 
@@ -131,24 +147,39 @@ The path of the config directory to operate in. If no value is provided, this wi
 	rootCmd.PersistentFlags().String(
 		"current-workspace", "",
 		strings.TrimSpace(fmt.Sprintf(`
-The id of the target workspace to operate in. If no value is provided, this will fallback to $%s before falling back to 'current' file".`,
+The id, alias, or unique id prefix or suffix of the target workspace to operate in. If no value is provided, this will fallback to $%s before falling back to 'current' file".`,
 			au.WorkspaceUidEnvironmentVariable,
 		)),
 	)
 	rootCmd.PersistentFlags().String(
 		"current-author", "",
 		strings.TrimSpace(fmt.Sprintf(`
-The 'Name <email>' of the author for any Todo or Comment changes. If no value is provided, this will fallback to $%s before falling back to 'author' file".`,
+The 'Name <email>', email, or alias of the author for any Todo or Comment changes. Members of the workspace are resolved to their canonical 'Name <email>'. If no value is provided, this will fallback to $%s before falling back to 'author' file".`,
 			au.AuthorEnvironmentVariable,
 		)),
 	)
+	rootCmd.PersistentFlags().String(
+		"at", "",
+		strings.TrimSpace(`
+Show the workspace as it was at a point in history. This can be a change hash or unique prefix, an RFC3339 timestamp, or a YYYY-MM-DD date. Only applies to commands that read the workspace, commands that change it fail when this is set.`,
+		),
+	)
 
 	rootCmd.AddGroup(&cobra.Group{Title: "Core", ID: "core"})
 	rootCmd.AddCommand(
 		workspacecmd.Command,
 		todocmd.Command,
 		commentcmd.Command,
+		attachmentcmd.Command,
+		labelcmd.Command,
+		statuscmd.Command,
+		templatecmd.Command,
+		annotationcmd.Command,
+		membercmd.Command,
+		keycmd.Command,
 		devcmd.Command,
+		todocmd.InboxCommand,
+		workspacecmd.UndoCommand,
 		versionCmd,
 	)
 }
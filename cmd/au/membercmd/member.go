@@ -0,0 +1,164 @@
+package membercmd
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+var Command = &cobra.Command{
+	Use:     "member",
+	GroupID: "core",
+	Short:   "List and manage the members of the workspace",
+	Long: `Members are the known authors of the workspace. Each member has a canonical 'Name <email>', a role, and aliases
+such as other emails or 'Name <email>' variants they have used. The --current-author is resolved through the members so
+that every change is made with the canonical author, and a warning is shown when an author who is not a member changes a
+workspace that has members. Servers reject synced changes that the role of their author does not allow, see
+'au workspace serve --help'.`,
+}
+
+type marshallableMember struct {
+	Author  string   `yaml:"author"`
+	Role    string   `yaml:"role"`
+	Aliases []string `yaml:"aliases,omitempty"`
+}
+
+func preMarshalMember(m au.Member) marshallableMember {
+	return marshallableMember{Author: m.Author(), Role: m.Role, Aliases: m.Aliases}
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List the members",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		members, err := ws.ListMembers(cmd.Context())
+		if err != nil {
+			return err
+		}
+		output := make([]marshallableMember, len(members))
+		for i, m := range members {
+			output[i] = preMarshalMember(m)
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(output)
+	},
+}
+
+var addCommand = &cobra.Command{
+	Use:   "add <'Name <email>'>",
+	Short: "Add a member to the workspace",
+	Long: `Add a member to the workspace. When the workspace has no members yet, the author who created it is added as an
+admin along with the first member.`,
+	Example:    `  au member add "Alice Smith <alice@example.com>" --role editor --alias alice@old.example.com`,
+	Args:       cobra.ExactArgs(1),
+	ArgAliases: []string{"author"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		params := au.AddMemberParams{Author: args[0]}
+		if v, err := cmd.Flags().GetString("role"); err != nil {
+			return errors.Wrap(err, "failed to get role flag")
+		} else {
+			params.Role = v
+		}
+		if v, err := cmd.Flags().GetStringArray("alias"); err != nil {
+			return errors.Wrap(err, "failed to get alias flag")
+		} else {
+			params.Aliases = v
+		}
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.AddedBy = v
+		}
+
+		member, err := ws.AddMember(cmd.Context(), params)
+		if err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(preMarshalMember(*member))
+	},
+}
+
+var mergeCommand = &cobra.Command{
+	Use:   "merge <member> <source...>",
+	Short: "Merge other members, emails, or authors into a member",
+	Long: `Merge other members, emails, or authors into a member. Source members are removed and their emails, authors, and
+aliases become aliases of the target member, along with any other sources. Existing changes keep the author they were
+made with.`,
+	Example:    `  au member merge alice@example.com "alice <alice@laptop.local>" asmith@example.com`,
+	Args:       cobra.MinimumNArgs(2),
+	ArgAliases: []string{"member", "source"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		params := au.MergeMembersParams{Into: args[0], Sources: args[1:]}
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.MergedBy = v
+		}
+
+		member, err := ws.MergeMembers(cmd.Context(), params)
+		if err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(preMarshalMember(*member))
+	},
+}
+
+func init() {
+	addCommand.Flags().String("role", au.MemberRoleEditor, "The role of the member, one of admin, editor, contributor, commenter, or reader")
+	addCommand.Flags().StringArray("alias", []string{}, "Another email, 'Name <email>', or handle of the member, may be repeated")
+
+	Command.AddCommand(
+		listCommand,
+		addCommand,
+		mergeCommand,
+	)
+}
@@ -0,0 +1,92 @@
+package membercmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+func executeAndResetCommand(ctx context.Context, cmd *cobra.Command, args []string) error {
+	cmd.SetArgs(args)
+	subCmd, err := cmd.ExecuteContextC(ctx)
+	subCmd.SetContext(nil)
+	// flag values otherwise leak into the next execution of the same sub command
+	subCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace([]string{})
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+	return err
+}
+
+func TestCli_member(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	outBuff, errBuff := new(bytes.Buffer), new(bytes.Buffer)
+	Command.SetOut(outBuff)
+	Command.SetErr(errBuff)
+
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	assert.Equal(t, "[]\n", outBuff.String())
+
+	outBuff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"add", "Alice Smith <Alice@Example.com>", "--role", "admin", "--alias", "alice@old.example.com"}))
+	assert.Equal(t, "author: Alice Smith <alice@example.com>\nrole: admin\naliases:\n  - alice@old.example.com\n", outBuff.String())
+	assert.Empty(t, errBuff.String())
+
+	// the current author is not a member of the workspace now that it has members
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"add", "asmith <asmith@laptop.local>"}))
+	assert.Equal(t, "warning: author 'Example <email@me.com>' is not a member of this workspace, see 'au member add'\n", errBuff.String())
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"add", "Other <other@example.com>", "--alias", "ALICE@old.example.com"}), "'ALICE@old.example.com' already belongs to member 'Alice Smith <alice@example.com>'")
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"add", "Other <other@example.com>", "--role", "owner"}), "role must be one of admin, editor, contributor, commenter, reader")
+
+	// the current author is resolved through the aliases of the member
+	errBuff.Reset()
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Old Alice <alice@old.example.com>")
+	outBuff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"merge", "alice@example.com", "asmith@laptop.local", "Example <email@me.com>"}))
+	assert.Equal(t, `author: Alice Smith <alice@example.com>
+role: admin
+aliases:
+  - Example <email@me.com>
+  - alice@old.example.com
+  - asmith <asmith@laptop.local>
+  - asmith@laptop.local
+`, outBuff.String())
+	assert.Empty(t, errBuff.String())
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"merge", "nobody@example.com", "x"}), "member 'nobody@example.com' does not exist")
+
+	ws, err := s.OpenWorkspace(context.Background(), wsMeta.Id, false)
+	assert.NoError(t, err)
+	changes, _ := ws.(au.DocProvider).GetDoc().Changes()
+	assert.Equal(t, "Alice Smith <alice@example.com> merged members into alice@example.com", changes[len(changes)-1].Message())
+	assert.NoError(t, ws.Close())
+
+	outBuff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(outBuff.Bytes(), &outSlice))
+	assert.Len(t, outSlice, 1)
+}
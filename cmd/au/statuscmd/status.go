@@ -0,0 +1,160 @@
+package statuscmd
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+var Command = &cobra.Command{
+	Use:     "status",
+	GroupID: "core",
+	Short:   "List and define the workflow statuses of the workspace",
+	Long: `Workflow statuses extend the open and closed status of Todos. Each status belongs to either the open or closed
+category, which is stored as the status of the Todo so that other clients can still understand it, and may restrict
+which statuses a Todo can move to next. Without any statuses, Todos are either open or closed.`,
+}
+
+type marshallableStatus struct {
+	Name        string   `yaml:"name"`
+	Category    string   `yaml:"category"`
+	Transitions []string `yaml:"transitions,omitempty"`
+	Default     bool     `yaml:"default,omitempty"`
+}
+
+func preMarshalStatus(s au.WorkflowStatus) marshallableStatus {
+	return marshallableStatus{Name: s.Name, Category: s.Category, Transitions: s.Transitions, Default: s.Default}
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List the workflow statuses",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		statuses, err := ws.ListWorkflowStatuses(cmd.Context())
+		if err != nil {
+			return err
+		}
+		output := make([]marshallableStatus, len(statuses))
+		for i, s := range statuses {
+			output[i] = preMarshalStatus(s)
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(output)
+	},
+}
+
+var setCommand = &cobra.Command{
+	Use:        "set <name>",
+	Short:      "Create or replace a workflow status",
+	Args:       cobra.ExactArgs(1),
+	ArgAliases: []string{"name"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		params := au.SetWorkflowStatusParams{Name: args[0]}
+		if v, err := cmd.Flags().GetString("category"); err != nil {
+			return errors.Wrap(err, "failed to get category flag")
+		} else {
+			params.Category = v
+		}
+		if v, err := cmd.Flags().GetStringArray("transition"); err != nil {
+			return errors.Wrap(err, "failed to get transition flag")
+		} else {
+			params.Transitions = v
+		}
+		if v, err := cmd.Flags().GetBool("default"); err != nil {
+			return errors.Wrap(err, "failed to get default flag")
+		} else {
+			params.Default = v
+		}
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.UpdatedBy = v
+		}
+
+		status, err := ws.SetWorkflowStatus(cmd.Context(), params)
+		if err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(preMarshalStatus(*status))
+	},
+}
+
+var deleteCommand = &cobra.Command{
+	Use:   "delete <name>",
+	Short: "Delete a workflow status and any transitions to it",
+	Long: `Delete a workflow status and any transitions to it. A status cannot be deleted while it is the only transition of
+another status, since a status without transitions allows any transition.`,
+	Args:       cobra.ExactArgs(1),
+	ArgAliases: []string{"name"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		var params au.DeleteWorkflowStatusParams
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.DeletedBy = v
+		}
+
+		if err := ws.DeleteWorkflowStatus(cmd.Context(), args[0], params); err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		}
+		return nil
+	},
+}
+
+func init() {
+	setCommand.Flags().String("category", "open", "Set whether Todos in this status are open or closed")
+	setCommand.Flags().StringArray("transition", []string{}, "Allow Todos in this status to move to this status, may be repeated, all transitions are allowed if none are given")
+	setCommand.Flags().Bool("default", false, "Use this status for new Todos")
+
+	Command.AddCommand(
+		listCommand,
+		setCommand,
+		deleteCommand,
+	)
+}
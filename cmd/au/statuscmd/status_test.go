@@ -0,0 +1,81 @@
+package statuscmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/internal"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+func executeAndResetCommand(ctx context.Context, cmd *cobra.Command, args []string) error {
+	cmd.SetArgs(args)
+	subCmd, err := cmd.ExecuteContextC(ctx)
+	subCmd.SetContext(nil)
+	// flag values otherwise leak into the next execution of the same sub command
+	subCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace([]string{})
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+	return err
+}
+
+func TestCli_status(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	assert.Equal(t, "[]\n", buff.String())
+
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"set", "done", "--category", "closed"}))
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"set", "todo", "--transition", "done", "--default"}))
+	assert.Equal(t, "name: todo\ncategory: open\ntransitions:\n  - done\ndefault: true\n", buff.String())
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"set", "doing", "--category", "nope"}), "invalid category: status must be open or closed")
+
+	ws, err := s.OpenWorkspace(context.Background(), wsMeta.Id, true)
+	assert.NoError(t, err)
+	todo, err := ws.CreateTodo(context.Background(), au.CreateTodoParams{Title: "Todo", CreatedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+	statuses, err := ws.ListWorkflowStatuses(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, "todo", au.TodoWorkflowStatus(todo, statuses))
+	_, err = ws.EditTodo(context.Background(), todo.Id, au.EditTodoParams{Status: internal.Ref("closed"), UpdatedBy: "Example <email@me.com>"})
+	assert.EqualError(t, err, "status must be one of done, todo")
+	assert.NoError(t, ws.Close())
+
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"delete", "done"}), "cannot delete status 'done' since it is the only transition of status 'todo'")
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"set", "todo", "--default"}))
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"delete", "done"}))
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	assert.Equal(t, []map[string]interface{}{{"name": "todo", "category": "open", "default": true}}, outSlice)
+}
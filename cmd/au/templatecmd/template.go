@@ -0,0 +1,260 @@
+package templatecmd
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+var Command = &cobra.Command{
+	Use:     "template",
+	GroupID: "core",
+	Short:   "List and define templates for creating Todos",
+	Long: `Templates describe repeated kinds of work. The title, description, and annotation values of a template may
+contain {{variable}} placeholders which are filled in with 'au todo create --template <name> --var variable=value'.
+Templates are stored in the workspace so that they are shared with everyone who syncs it.`,
+}
+
+type marshallableTemplate struct {
+	Name        string            `yaml:"name"`
+	Title       string            `yaml:"title"`
+	Description string            `yaml:"description,omitempty"`
+	Status      string            `yaml:"status,omitempty"`
+	Annotations map[string]string `yaml:"annotations,omitempty"`
+	Variables   []string          `yaml:"variables,omitempty"`
+}
+
+func preMarshalTemplate(t *au.TodoTemplate) marshallableTemplate {
+	return marshallableTemplate{
+		Name:        t.Name,
+		Title:       t.Title,
+		Description: t.Description,
+		Status:      t.Status,
+		Annotations: t.Annotations,
+		Variables:   au.TemplateVariables(t),
+	}
+}
+
+func parseAnnotationFlag(cmd *cobra.Command, allowEmpty bool) (map[string]string, error) {
+	v, err := cmd.Flags().GetStringArray("annotation")
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get annotations flag")
+	}
+	output := make(map[string]string, len(v))
+	for _, entry := range v {
+		parts := strings.SplitN(entry, "=", 2)
+		if len(parts) == 1 {
+			return nil, errors.Errorf("invalid annotation argument '%s', must end in = or =value", entry)
+		} else if parts[1] == "" && !allowEmpty {
+			return nil, errors.New("cannot set an annotation to an empty string")
+		}
+		output[parts[0]] = parts[1]
+	}
+	return output, nil
+}
+
+var listCommand = &cobra.Command{
+	Use:   "list",
+	Short: "List the templates",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		templates, err := ws.ListTodoTemplates(cmd.Context())
+		if err != nil {
+			return err
+		}
+		output := make([]marshallableTemplate, len(templates))
+		for i := range templates {
+			output[i] = preMarshalTemplate(&templates[i])
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(output)
+	},
+}
+
+var addCommand = &cobra.Command{
+	Use:        "add <name>",
+	Short:      "Add a new template",
+	Args:       cobra.ExactArgs(1),
+	ArgAliases: []string{"name"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		params := au.AddTodoTemplateParams{Name: args[0]}
+		if v, err := cmd.Flags().GetString("title"); err != nil {
+			return errors.Wrap(err, "failed to get title flag")
+		} else {
+			params.Title = v
+		}
+		if v, err := cmd.Flags().GetString("description"); err != nil {
+			return errors.Wrap(err, "failed to get description flag")
+		} else {
+			params.Description = v
+		}
+		if v, err := cmd.Flags().GetString("status"); err != nil {
+			return errors.Wrap(err, "failed to get status flag")
+		} else {
+			params.Status = v
+		}
+		if params.Annotations, err = parseAnnotationFlag(cmd, false); err != nil {
+			return err
+		}
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.CreatedBy = v
+		}
+
+		template, err := ws.AddTodoTemplate(cmd.Context(), params)
+		if err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(preMarshalTemplate(template))
+	},
+}
+
+var editCommand = &cobra.Command{
+	Use:        "edit <name>",
+	Short:      "Edit an existing template",
+	Long:       "Edit an existing template. Only the given flags are changed, and an annotation set to an empty value with key= is removed.",
+	Args:       cobra.ExactArgs(1),
+	ArgAliases: []string{"name"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		params := au.EditTodoTemplateParams{}
+		if cmd.Flags().Changed("title") {
+			if v, err := cmd.Flags().GetString("title"); err != nil {
+				return errors.Wrap(err, "failed to get title flag")
+			} else {
+				params.Title = &v
+			}
+		}
+		if cmd.Flags().Changed("description") {
+			if v, err := cmd.Flags().GetString("description"); err != nil {
+				return errors.Wrap(err, "failed to get description flag")
+			} else {
+				params.Description = &v
+			}
+		}
+		if cmd.Flags().Changed("status") {
+			if v, err := cmd.Flags().GetString("status"); err != nil {
+				return errors.Wrap(err, "failed to get status flag")
+			} else {
+				params.Status = &v
+			}
+		}
+		if params.Annotations, err = parseAnnotationFlag(cmd, true); err != nil {
+			return err
+		}
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.UpdatedBy = v
+		}
+
+		template, err := ws.EditTodoTemplate(cmd.Context(), args[0], params)
+		if err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(preMarshalTemplate(template))
+	},
+}
+
+var deleteCommand = &cobra.Command{
+	Use:        "delete <name>",
+	Short:      "Delete a template",
+	Args:       cobra.ExactArgs(1),
+	ArgAliases: []string{"name"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		var params au.DeleteTodoTemplateParams
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.DeletedBy = v
+		}
+
+		if err := ws.DeleteTodoTemplate(cmd.Context(), args[0], params); err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		}
+		return nil
+	},
+}
+
+func init() {
+	addCommand.Flags().StringP("title", "t", "", "Set the title pattern of the template")
+	addCommand.Flags().String("description", "", "Set the description pattern of the template")
+	addCommand.Flags().String("status", "", "Set the status of Todos created from the template, defaults to the default status of the workspace")
+	addCommand.Flags().StringArray("annotation", []string{}, "Set an annotation using key=value syntax")
+	_ = addCommand.MarkFlagRequired("title")
+
+	editCommand.Flags().StringP("title", "t", "", "Set the title pattern of the template")
+	editCommand.Flags().String("description", "", "Set the description pattern of the template")
+	editCommand.Flags().String("status", "", "Set the status of Todos created from the template, empty for the default status of the workspace")
+	editCommand.Flags().StringArray("annotation", []string{}, "Set an annotation using key=value syntax, or remove it with key=")
+
+	Command.AddCommand(
+		listCommand,
+		addCommand,
+		editCommand,
+		deleteCommand,
+	)
+}
@@ -0,0 +1,80 @@
+package templatecmd
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+func executeAndResetCommand(ctx context.Context, cmd *cobra.Command, args []string) error {
+	cmd.SetArgs(args)
+	subCmd, err := cmd.ExecuteContextC(ctx)
+	subCmd.SetContext(nil)
+	// flag values otherwise leak into the next execution of the same sub command
+	subCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace([]string{})
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
+	return err
+}
+
+func TestCli_template(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	assert.Equal(t, "[]\n", buff.String())
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{
+		"add", "bug", "--title", "Bug: {{summary}}", "--description", "Seen in {{version}}", "--annotation", "about:blank#kind=bug",
+	}))
+	assert.Equal(t, `name: bug
+title: 'Bug: {{summary}}'
+description: Seen in {{version}}
+annotations:
+  about:blank#kind: bug
+variables:
+  - summary
+  - version
+`, buff.String())
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"add", "bug", "--title", "Other"}), "template 'bug' already exists")
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"add", "other", "--title", "Other", "--status", "nope"}), "invalid template status: status must be open or closed")
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"edit", "bug", "--description", "", "--annotation", "about:blank#kind="}))
+	assert.Equal(t, "name: bug\ntitle: 'Bug: {{summary}}'\nvariables:\n  - summary\n", buff.String())
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	assert.Equal(t, "- name: bug\n  title: 'Bug: {{summary}}'\n  variables:\n    - summary\n", buff.String())
+
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"delete", "bug"}))
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"delete", "bug"}), "template 'bug' does not exist")
+}
@@ -0,0 +1,85 @@
+package todocmd
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+var assignCommand = &cobra.Command{
+	Use:        "assign <id> [author...]",
+	Short:      "Assign a Todo to one or more 'Name <email>' authors, or 'me' for the current author",
+	Long:       "Assign a Todo to one or more 'Name <email>' authors, or 'me' for the current author. With no authors, the Todo is assigned to the current author.",
+	Args:       cobra.MinimumNArgs(1),
+	ArgAliases: []string{"id", "author"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editAssignees(cmd, args[0], args[1:], true)
+	},
+}
+
+var unassignCommand = &cobra.Command{
+	Use:        "unassign <id> [author...]",
+	Short:      "Remove one or more 'Name <email>' authors, or 'me' for the current author, from the assignees of a Todo",
+	Long:       "Remove one or more 'Name <email>' authors, or 'me' for the current author, from the assignees of a Todo. With no authors, the current author is removed.",
+	Args:       cobra.MinimumNArgs(1),
+	ArgAliases: []string{"id", "author"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editAssignees(cmd, args[0], args[1:], false)
+	},
+}
+
+// resolveAssignee converts 'me' into the current author.
+func resolveAssignee(cmd *cobra.Command, ws au.WorkspaceProvider, input string) (string, error) {
+	if input != "me" {
+		return input, nil
+	}
+	return common.CurrentAuthor(cmd.Context(), ws)
+}
+
+func editAssignees(cmd *cobra.Command, id string, assignees []string, assign bool) error {
+	s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+	w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+	if w == "" {
+		return errors.New("current workspace not set")
+	}
+	ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	author, err := resolveAssignee(cmd, ws, "me")
+	if err != nil {
+		return err
+	}
+	if len(assignees) == 0 {
+		assignees = []string{"me"}
+	}
+	resolved := make([]string, len(assignees))
+	for i, a := range assignees {
+		if resolved[i], err = resolveAssignee(cmd, ws, a); err != nil {
+			return err
+		}
+	}
+
+	if id, err = common.ResolveTodoId(cmd.Context(), ws, id); err != nil {
+		return err
+	}
+	var todo *au.Todo
+	if assign {
+		todo, err = ws.AssignTodo(cmd.Context(), id, resolved, author)
+	} else {
+		todo, err = ws.UnassignTodo(cmd.Context(), id, resolved, author)
+	}
+	if err != nil {
+		return err
+	} else if err := ws.Flush(); err != nil {
+		return errors.Wrap(err, "failed to flush to file")
+	}
+	encoder := yaml.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent(2)
+	return encoder.Encode(preMarshalTodo(todo))
+}
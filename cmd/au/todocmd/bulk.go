@@ -0,0 +1,185 @@
+package todocmd
+
+import (
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+var bulkEditCommand = &cobra.Command{
+	Use:   "bulk-edit [id...]",
+	Short: "Apply the same edit to every Todo matching a filter in a single change",
+	Long: `Apply the same edit to every Todo matching a filter in a single change.
+
+The Todos are selected by their ids, by filters, or both. Each filter is a key=value expression where the key is one of
+label, assignee, status, parent, or title, and a Todo must match all of them. The label filter may be repeated and the
+assignee may be 'me' for the current author. The edit is checked against every Todo first, so if any of them cannot be
+edited then none are. With --dry-run, the same checks are run and the ids of the Todos are listed without editing them, and --at may be used to
+preview the edit against an earlier point in history.`,
+	Example: `  au todo bulk-edit --filter label=sprint-1 --filter status=open --set-status closed
+  au todo bulk-edit --filter assignee=me --add-label urgent --dry-run`,
+	ArgAliases: []string{"id"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		dryRun, err := cmd.Flags().GetBool("dry-run")
+		if err != nil {
+			return errors.Wrap(err, "failed to get dry-run flag")
+		}
+		// a dry run only reads the workspace, so it may preview the edit against an earlier point in history
+		var ws au.WorkspaceProvider
+		if dryRun {
+			ws, err = common.OpenReadableWorkspace(cmd.Context(), s, w)
+		} else {
+			ws, err = common.OpenWritableWorkspace(cmd.Context(), s, w)
+		}
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		filter, err := cmd.Flags().GetStringArray("filter")
+		if err != nil {
+			return errors.Wrap(err, "failed to get filter flag")
+		} else if len(filter) == 0 && len(args) == 0 {
+			return errors.New("at least one id or filter is required")
+		}
+		todoFilter, err := au.ParseTodoFilter(filter)
+		if err != nil {
+			return err
+		}
+		if todoFilter.Assignee != "" {
+			if todoFilter.Assignee, err = resolveAssignee(cmd, ws, todoFilter.Assignee); err != nil {
+				return err
+			}
+		}
+		if todoFilter.Parent != "" {
+			if todoFilter.Parent, err = common.ResolveTodoId(cmd.Context(), ws, todoFilter.Parent); err != nil {
+				return err
+			}
+		}
+
+		params := au.EditTodoParams{Annotations: make(map[string]string)}
+		if v, err := cmd.Flags().GetString("set-status"); err != nil {
+			return errors.Wrap(err, "failed to get set-status flag")
+		} else if v != "" {
+			params.Status = &v
+		}
+		if v, err := cmd.Flags().GetString("reason"); err != nil {
+			return errors.Wrap(err, "failed to get reason flag")
+		} else if v != "" {
+			params.Annotations[au.AurelianStatusReasonAnnotation] = v
+		}
+		if v, err := cmd.Flags().GetStringArray("annotation"); err != nil {
+			return errors.Wrap(err, "failed to get annotations flag")
+		} else {
+			for _, entry := range v {
+				parts := strings.SplitN(entry, "=", 2)
+				if len(parts) == 1 {
+					return errors.Errorf("invalid annotation argument '%s', must end in = or =value", entry)
+				}
+				params.Annotations[parts[0]] = parts[1]
+			}
+		}
+		for _, flag := range []string{"add-label", "remove-label"} {
+			value := ""
+			if flag == "add-label" {
+				value = "true"
+			}
+			if v, err := cmd.Flags().GetStringArray(flag); err != nil {
+				return errors.Wrapf(err, "failed to get %s flag", flag)
+			} else {
+				for _, l := range v {
+					cleaned, err := au.ValidateLabel(l)
+					if err != nil {
+						return err
+					}
+					params.Annotations[au.LabelAnnotationKey(cleaned)] = value
+				}
+			}
+		}
+		if params.Status == nil && len(params.Annotations) == 0 {
+			return errors.New("nothing to edit, set a status, annotation, or label")
+		}
+
+		todos, err := ws.ListTodos(cmd.Context())
+		if err != nil {
+			return err
+		}
+		if todoFilter.Statuses, err = ws.ListWorkflowStatuses(cmd.Context()); err != nil {
+			return err
+		}
+		if len(args) > 0 {
+			selected := make([]au.Todo, 0, len(args))
+			for _, arg := range args {
+				id, err := common.ResolveTodoId(cmd.Context(), ws, arg)
+				if err != nil {
+					return err
+				}
+				todo, err := ws.GetTodo(cmd.Context(), id)
+				if err != nil {
+					return err
+				}
+				selected = append(selected, *todo)
+			}
+			todos = selected
+		}
+		todos = au.FilterTodos(todos, todoFilter)
+		au.SortTodosByRank(todos)
+		ids := make([]string, len(todos))
+		for i, t := range todos {
+			ids[i] = t.Id
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		if len(ids) == 0 {
+			if dryRun {
+				return encoder.Encode(ids)
+			}
+			return errors.New("no todos match the filter")
+		}
+
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.UpdatedBy = v
+		}
+		if dryRun {
+			if err := ws.CheckBulkEditTodos(cmd.Context(), ids, params); err != nil {
+				return err
+			}
+			return encoder.Encode(ids)
+		}
+
+		edited, err := ws.BulkEditTodos(cmd.Context(), ids, params)
+		if err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		}
+		output := make([]interface{}, len(edited))
+		for i, t := range edited {
+			output[i] = preMarshalTodo(&t)
+		}
+		return encoder.Encode(output)
+	},
+}
+
+func init() {
+	bulkEditCommand.Flags().StringArray("filter", []string{}, "Only edit Todos matching this key=value filter, may be repeated")
+	bulkEditCommand.Flags().Bool("dry-run", false, "List the ids of the Todos that would be edited without editing them")
+	bulkEditCommand.Flags().String("set-status", "", "Set the status of the Todos, one of the workflow statuses of the workspace, or open or closed if it has none")
+	bulkEditCommand.Flags().String("reason", "", "Set the reason for the status of the Todos")
+	bulkEditCommand.Flags().StringArray("annotation", []string{}, "Set an annotation using key=value or clear an annotation using key=")
+	bulkEditCommand.Flags().StringArray("add-label", []string{}, "Add this label to the Todos")
+	bulkEditCommand.Flags().StringArray("remove-label", []string{}, "Remove this label from the Todos")
+}
@@ -0,0 +1,183 @@
+package todocmd
+
+import (
+	"strconv"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/internal"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+var checkCommand = &cobra.Command{
+	Use:   "check",
+	Short: "Add, toggle, move, or remove items in the checklist of a Todo",
+	Long:  "Checklist items are referenced by their id or by their 1-based position as shown in the checklist_items of 'todo get'.",
+}
+
+var checkAddCommand = &cobra.Command{
+	Use:        "add <id> <text>",
+	Short:      "Add an item to the end of the checklist",
+	Args:       cobra.ExactArgs(2),
+	ArgAliases: []string{"id", "text"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editChecklist(cmd, args[0], func(ws au.WorkspaceProvider, todo *au.Todo, author string) error {
+			params := au.AddChecklistItemParams{Text: args[1], CreatedBy: author}
+			if v, err := cmd.Flags().GetString("before"); err != nil {
+				return errors.Wrap(err, "failed to get before flag")
+			} else if v != "" {
+				if params.Before, err = resolveChecklistItem(todo, v); err != nil {
+					return err
+				}
+			}
+			_, err := ws.AddChecklistItem(cmd.Context(), todo.Id, params)
+			return err
+		})
+	},
+}
+
+var checkToggleCommand = &cobra.Command{
+	Use:        "toggle <id> <item>",
+	Short:      "Mark an item as done, or as not done if it is already done",
+	Args:       cobra.ExactArgs(2),
+	ArgAliases: []string{"id", "item"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editChecklist(cmd, args[0], func(ws au.WorkspaceProvider, todo *au.Todo, author string) error {
+			itemId, err := resolveChecklistItem(todo, args[1])
+			if err != nil {
+				return err
+			}
+			for _, item := range todo.Checklist {
+				if item.Id == itemId {
+					_, err = ws.EditChecklistItem(cmd.Context(), todo.Id, itemId, au.EditChecklistItemParams{Done: internal.Ref(!item.Done), UpdatedBy: author})
+				}
+			}
+			return err
+		})
+	},
+}
+
+var checkMoveCommand = &cobra.Command{
+	Use:        "move <id> <item>",
+	Short:      "Move an item before or after another item",
+	Args:       cobra.ExactArgs(2),
+	ArgAliases: []string{"id", "item"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editChecklist(cmd, args[0], func(ws au.WorkspaceProvider, todo *au.Todo, author string) error {
+			itemId, err := resolveChecklistItem(todo, args[1])
+			if err != nil {
+				return err
+			}
+			params := au.EditChecklistItemParams{UpdatedBy: author}
+			if v, err := cmd.Flags().GetString("before"); err != nil {
+				return errors.Wrap(err, "failed to get before flag")
+			} else if v != "" {
+				if params.Before, err = resolveChecklistItem(todo, v); err != nil {
+					return err
+				}
+			}
+			if v, err := cmd.Flags().GetString("after"); err != nil {
+				return errors.Wrap(err, "failed to get after flag")
+			} else if v != "" {
+				if params.After, err = resolveChecklistItem(todo, v); err != nil {
+					return err
+				}
+			}
+			_, err = ws.EditChecklistItem(cmd.Context(), todo.Id, itemId, params)
+			return err
+		})
+	},
+}
+
+var checkRemoveCommand = &cobra.Command{
+	Use:        "rm <id> <item>",
+	Short:      "Remove an item from the checklist",
+	Args:       cobra.ExactArgs(2),
+	ArgAliases: []string{"id", "item"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editChecklist(cmd, args[0], func(ws au.WorkspaceProvider, todo *au.Todo, author string) error {
+			itemId, err := resolveChecklistItem(todo, args[1])
+			if err != nil {
+				return err
+			}
+			return ws.DeleteChecklistItem(cmd.Context(), todo.Id, itemId, au.DeleteChecklistItemParams{DeletedBy: author})
+		})
+	},
+}
+
+// resolveChecklistItem returns the id of the checklist item referenced by either its id or 1-based position.
+func resolveChecklistItem(todo *au.Todo, input string) (string, error) {
+	if n, err := strconv.Atoi(input); err == nil {
+		if n < 1 || n > len(todo.Checklist) {
+			return "", errors.Errorf("checklist item %d does not exist, todo '%s' has %d items", n, todo.Id, len(todo.Checklist))
+		}
+		return todo.Checklist[n-1].Id, nil
+	}
+	for _, item := range todo.Checklist {
+		if item.Id == input {
+			return item.Id, nil
+		}
+	}
+	return "", errors.Errorf("checklist item with id '%s' does not exist", input)
+}
+
+// editChecklist opens the workspace and applies the edit to the todo, then prints the todo along with its checklist.
+func editChecklist(cmd *cobra.Command, id string, edit func(ws au.WorkspaceProvider, todo *au.Todo, author string) error) error {
+	s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+	w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+	if w == "" {
+		return errors.New("current workspace not set")
+	}
+	ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	var author string
+	if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+		return err
+	} else {
+		author = v
+	}
+
+	if id, err = common.ResolveTodoId(cmd.Context(), ws, id); err != nil {
+		return err
+	}
+	todo, err := ws.GetTodo(cmd.Context(), id)
+	if err != nil {
+		return err
+	} else if err := edit(ws, todo, author); err != nil {
+		return err
+	} else if err := ws.Flush(); err != nil {
+		return errors.Wrap(err, "failed to flush to file")
+	}
+	if todo, err = ws.GetTodo(cmd.Context(), id); err != nil {
+		return err
+	}
+	output := preMarshalTodo(todo).(*marshallableTodo)
+	for _, item := range todo.Checklist {
+		output.ChecklistItems = append(output.ChecklistItems, marshallableChecklistItem{Id: item.Id, Text: item.Text, Done: item.Done})
+	}
+	encoder := yaml.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent(2)
+	return encoder.Encode(output)
+}
+
+func init() {
+	checkAddCommand.Flags().String("before", "", "Add the item before this item instead of at the end")
+	checkMoveCommand.Flags().String("before", "", "Move the item directly before this item")
+	checkMoveCommand.Flags().String("after", "", "Move the item directly after this item")
+	checkMoveCommand.MarkFlagsMutuallyExclusive("before", "after")
+	checkMoveCommand.MarkFlagsOneRequired("before", "after")
+
+	checkCommand.AddCommand(
+		checkAddCommand,
+		checkToggleCommand,
+		checkMoveCommand,
+		checkRemoveCommand,
+	)
+}
@@ -0,0 +1,81 @@
+package todocmd
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+type marshallableMention struct {
+	TodoId      string    `yaml:"todo_id"`
+	Title       string    `yaml:"title"`
+	CommentId   string    `yaml:"comment_id,omitempty"`
+	MentionedBy string    `yaml:"mentioned_by"`
+	At          time.Time `yaml:"at"`
+}
+
+var InboxCommand = &cobra.Command{
+	Use:     "inbox",
+	GroupID: "core",
+	Short:   "List the Todos and Comments that mention the current author",
+	Long: `List the Todos and Comments that mention the current author, newest first.
+
+Authors are mentioned in titles, descriptions, and markdown comments as @handle, where the handle is the local part of
+their email, their full email, or their username without spaces. Authors are only recognised once they have created or
+edited something in the Workspace. Each mention is attributed to the change that introduced it, and mentions written by
+the current author are not listed.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+
+		var since time.Time
+		if v, err := cmd.Flags().GetString("since"); err != nil {
+			return errors.Wrap(err, "failed to get since flag")
+		} else if d, err := au.ParseDurationInput(v); err == nil {
+			since = time.Now().Add(-d)
+		} else if t, err2 := au.ParseTimeInput(v); err2 == nil {
+			since = t
+		} else {
+			return errors.Errorf("invalid since '%s', expected a duration like 4h or 3d, or a time", v)
+		}
+
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		var author string
+		if v, err := common.CurrentAuthor(cmd.Context(), ws); err != nil {
+			return err
+		} else {
+			author = v
+		}
+
+		mentions, err := ws.ListMentions(cmd.Context(), author, since)
+		if err != nil {
+			return err
+		}
+		output := make([]marshallableMention, len(mentions))
+		for i, m := range mentions {
+			output[i] = marshallableMention{TodoId: m.TodoId, Title: m.TodoTitle, CommentId: m.CommentId, MentionedBy: m.MentionedBy, At: m.At}
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(output)
+	},
+}
+
+func init() {
+	InboxCommand.Flags().String("since", "7d", "Only list mentions introduced since this duration ago or time")
+}
@@ -0,0 +1,80 @@
+package todocmd
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+var labelCommand = &cobra.Command{
+	Use:   "label",
+	Short: "Add or remove labels on a Todo",
+}
+
+var labelAddCommand = &cobra.Command{
+	Use:        "add <id> <label>...",
+	Short:      "Add one or more labels to a Todo",
+	Args:       cobra.MinimumNArgs(2),
+	ArgAliases: []string{"id", "label"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editLabels(cmd, args[0], args[1:], true)
+	},
+}
+
+var labelRemoveCommand = &cobra.Command{
+	Use:        "rm <id> <label>...",
+	Short:      "Remove one or more labels from a Todo",
+	Args:       cobra.MinimumNArgs(2),
+	ArgAliases: []string{"id", "label"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return editLabels(cmd, args[0], args[1:], false)
+	},
+}
+
+func editLabels(cmd *cobra.Command, id string, labels []string, add bool) error {
+	s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+	w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+	if w == "" {
+		return errors.New("current workspace not set")
+	}
+	ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	var author string
+	if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+		return err
+	} else {
+		author = v
+	}
+
+	if id, err = common.ResolveTodoId(cmd.Context(), ws, id); err != nil {
+		return err
+	}
+	var todo *au.Todo
+	if add {
+		todo, err = ws.AddTodoLabels(cmd.Context(), id, labels, author)
+	} else {
+		todo, err = ws.RemoveTodoLabels(cmd.Context(), id, labels, author)
+	}
+	if err != nil {
+		return err
+	} else if err := ws.Flush(); err != nil {
+		return errors.Wrap(err, "failed to flush to file")
+	}
+	encoder := yaml.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent(2)
+	return encoder.Encode(preMarshalTodo(todo))
+}
+
+func init() {
+	labelCommand.AddCommand(
+		labelAddCommand,
+		labelRemoveCommand,
+	)
+}
@@ -0,0 +1,134 @@
+package todocmd
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+var moveCommand = &cobra.Command{
+	Use:   "move <id>",
+	Short: "Move a Todo to a new position in the ranked list or to another Workspace",
+	Long: `Move a Todo to a new position in the ranked list or to another Workspace.
+
+The Todo is given a rank between its new neighbours. If there is no room between them, the ranks of all Todos are
+rebalanced as part of the same change.
+
+With --to, the Todo is copied to the other Workspace as described by 'todo copy' and then deleted from the current
+Workspace. The copy is written first, so if the delete fails the move can be run again to finish it. A Todo with
+children cannot be moved to another Workspace, move or reparent the children first.`,
+	Args:       cobra.ExactArgs(1),
+	ArgAliases: []string{"id"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		if v, err := cmd.Flags().GetString("to"); err != nil {
+			return errors.Wrap(err, "failed to get to flag")
+		} else if v != "" {
+			return transferTodo(cmd, cmd.Flags().Arg(0), v, true)
+		}
+
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		params := au.MoveTodoParams{}
+		if v, err := cmd.Flags().GetString("before"); err != nil {
+			return errors.Wrap(err, "failed to get before flag")
+		} else if v != "" {
+			if params.Before, err = common.ResolveTodoId(cmd.Context(), ws, v); err != nil {
+				return err
+			}
+		}
+		if v, err := cmd.Flags().GetString("after"); err != nil {
+			return errors.Wrap(err, "failed to get after flag")
+		} else if v != "" {
+			if params.After, err = common.ResolveTodoId(cmd.Context(), ws, v); err != nil {
+				return err
+			}
+		}
+		if v, err := cmd.Flags().GetBool("top"); err != nil {
+			return errors.Wrap(err, "failed to get top flag")
+		} else {
+			params.Top = v
+		}
+		if v, err := cmd.Flags().GetBool("bottom"); err != nil {
+			return errors.Wrap(err, "failed to get bottom flag")
+		} else {
+			params.Bottom = v
+		}
+
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.MovedBy = v
+		}
+
+		id, err := common.ResolveTodoId(cmd.Context(), ws, cmd.Flags().Arg(0))
+		if err != nil {
+			return err
+		}
+		if todo, err := ws.MoveTodo(cmd.Context(), id, params); err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		} else {
+			encoder := yaml.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent(2)
+			return encoder.Encode(preMarshalTodo(todo))
+		}
+	},
+}
+
+var rebalanceCommand = &cobra.Command{
+	Use:   "rebalance",
+	Short: "Rewrite the ranks of all Todos as evenly spaced integers without changing their order",
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		params := au.RebalanceRanksParams{}
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.RebalancedBy = v
+		}
+
+		count, err := ws.RebalanceRanks(cmd.Context(), params)
+		if err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		}
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(map[string]interface{}{"rebalanced": count})
+	},
+}
+
+func init() {
+	moveCommand.Flags().String("before", "", "Place the Todo directly above the Todo with this id")
+	moveCommand.Flags().String("after", "", "Place the Todo directly below the Todo with this id")
+	moveCommand.Flags().Bool("top", false, "Place the Todo at the top of the list")
+	moveCommand.Flags().Bool("bottom", false, "Place the Todo at the bottom of the list")
+	moveCommand.Flags().String("to", "", "Move the Todo to the Workspace with this id or alias")
+	moveCommand.MarkFlagsMutuallyExclusive("before", "after", "top", "bottom", "to")
+	moveCommand.MarkFlagsOneRequired("before", "after", "top", "bottom", "to")
+}
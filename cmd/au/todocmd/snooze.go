@@ -0,0 +1,66 @@
+package todocmd
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+var snoozeCommand = &cobra.Command{
+	Use:   "snooze <id> <duration|time>",
+	Short: "Hide a Todo from the default list until a later time",
+	Long: `Hide a Todo from the default list until a later time.
+
+The argument may be a duration from now such as 90m, 4h, 3d, or 2w, or an RFC3339 timestamp or YYYY-MM-DD date. Use
+'todo list --show-hidden' to see snoozed Todos.`,
+	Args:       cobra.ExactArgs(2),
+	ArgAliases: []string{"id", "duration"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+
+		var until time.Time
+		if d, err := au.ParseDurationInput(args[1]); err == nil {
+			until = time.Now().Add(d)
+		} else if t, err2 := au.ParseTimeInput(args[1]); err2 == nil {
+			until = t
+		} else {
+			return errors.Errorf("invalid snooze '%s', expected a duration like 4h or 3d, or a time", args[1])
+		}
+
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		params := au.EditTodoParams{Annotations: map[string]string{au.AurelianHideUntilAnnotation: au.FormatTodoTime(until)}}
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.UpdatedBy = v
+		}
+
+		id, err := common.ResolveTodoId(cmd.Context(), ws, args[0])
+		if err != nil {
+			return err
+		}
+		if todo, err := ws.EditTodo(cmd.Context(), id, params); err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		} else {
+			encoder := yaml.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent(2)
+			return encoder.Encode(preMarshalTodo(todo))
+		}
+	},
+}
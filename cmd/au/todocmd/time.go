@@ -0,0 +1,221 @@
+package todocmd
+
+import (
+	"slices"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+var timeCommand = &cobra.Command{
+	Use:   "time",
+	Short: "Track the time spent on Todos",
+	Long: `Time is tracked as entries per author, either by starting and stopping a timer or by logging a duration directly.
+The total time spent is shown by 'todo get' along with the total for each author.`,
+}
+
+var timeStartCommand = &cobra.Command{
+	Use:        "start <id>",
+	Short:      "Start a timer on a Todo",
+	Args:       cobra.ExactArgs(1),
+	ArgAliases: []string{"id"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return trackTime(cmd, args[0], func(ws au.WorkspaceProvider, id string, author string) error {
+			return ws.StartTimer(cmd.Context(), id, au.StartTimerParams{StartedBy: author})
+		})
+	},
+}
+
+var timeStopCommand = &cobra.Command{
+	Use:        "stop <id>",
+	Short:      "Stop the running timer on a Todo and log the elapsed time",
+	Args:       cobra.ExactArgs(1),
+	ArgAliases: []string{"id"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return trackTime(cmd, args[0], func(ws au.WorkspaceProvider, id string, author string) error {
+			_, err := ws.StopTimer(cmd.Context(), id, au.StopTimerParams{StoppedBy: author})
+			return err
+		})
+	},
+}
+
+var timeLogCommand = &cobra.Command{
+	Use:        "log <id> <duration>",
+	Short:      "Log time spent on a Todo, like 30m or 1h30m",
+	Args:       cobra.ExactArgs(2),
+	ArgAliases: []string{"id", "duration"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		d, err := au.ParseDurationInput(args[1])
+		if err != nil {
+			return err
+		}
+		params := au.LogTimeParams{Duration: d}
+		if v, err := cmd.Flags().GetString("at"); err != nil {
+			return errors.Wrap(err, "failed to get at flag")
+		} else if v != "" {
+			if params.StartedAt, err = au.ParseTimeInput(v); err != nil {
+				return err
+			}
+		}
+		return trackTime(cmd, args[0], func(ws au.WorkspaceProvider, id string, author string) error {
+			params.LoggedBy = author
+			_, err := ws.LogTime(cmd.Context(), id, params)
+			return err
+		})
+	},
+}
+
+// trackTime opens the workspace and applies the change to the time of the todo, then prints the todo along with its
+// time totals.
+func trackTime(cmd *cobra.Command, id string, track func(ws au.WorkspaceProvider, id string, author string) error) error {
+	s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+	w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+	if w == "" {
+		return errors.New("current workspace not set")
+	}
+	ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+
+	var author string
+	if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+		return err
+	} else {
+		author = v
+	}
+
+	if id, err = common.ResolveTodoId(cmd.Context(), ws, id); err != nil {
+		return err
+	}
+	if err := track(ws, id, author); err != nil {
+		return err
+	} else if err := ws.Flush(); err != nil {
+		return errors.Wrap(err, "failed to flush to file")
+	}
+	todo, err := ws.GetTodo(cmd.Context(), id)
+	if err != nil {
+		return err
+	}
+	output := preMarshalTodo(todo).(*marshallableTodo)
+	if entries, err := ws.ListTimeEntries(cmd.Context(), id); err != nil {
+		return err
+	} else {
+		output.TimeByAuthor = preMarshalDurations(au.TimeTotals(entries))
+	}
+	encoder := yaml.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent(2)
+	return encoder.Encode(output)
+}
+
+func preMarshalDurations(input map[string]time.Duration) map[string]string {
+	output := make(map[string]string, len(input))
+	for k, v := range input {
+		output[k] = v.String()
+	}
+	return output
+}
+
+type marshallableTimeReport struct {
+	Since    time.Time         `yaml:"since"`
+	Until    time.Time         `yaml:"until"`
+	Total    string            `yaml:"total"`
+	ByAuthor map[string]string `yaml:"by_author"`
+	ByLabel  map[string]string `yaml:"by_label"`
+}
+
+var timeReportCommand = &cobra.Command{
+	Use:   "report",
+	Short: "Report the time tracked in the Workspace by author and label",
+	Long: `Report the time tracked in the Workspace by author and by label, counting the entries which started within the
+date range. Time on a Todo with several labels counts towards each of them, and time on a Todo without labels only
+counts towards the total.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+
+		report := marshallableTimeReport{Until: time.Now().UTC().Truncate(time.Second)}
+		if v, err := cmd.Flags().GetString("since"); err != nil {
+			return errors.Wrap(err, "failed to get since flag")
+		} else if d, err := au.ParseDurationInput(v); err == nil {
+			report.Since = report.Until.Add(-d)
+		} else if t, err2 := au.ParseTimeInput(v); err2 == nil {
+			report.Since = t
+		} else {
+			return errors.Errorf("invalid since '%s', expected a duration like 4h or 3d, or a time", v)
+		}
+		if v, err := cmd.Flags().GetString("until"); err != nil {
+			return errors.Wrap(err, "failed to get until flag")
+		} else if v != "" {
+			if report.Until, err = au.ParseTimeInput(v); err != nil {
+				return err
+			}
+		}
+		if !report.Since.Before(report.Until) {
+			return errors.New("since must be before until")
+		}
+
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		todos, err := ws.ListTodos(cmd.Context())
+		if err != nil {
+			return err
+		}
+		var total time.Duration
+		byAuthor, byLabel := make(map[string]time.Duration), make(map[string]time.Duration)
+		for _, todo := range todos {
+			if todo.TimeSpent == 0 {
+				continue
+			}
+			entries, err := ws.ListTimeEntries(cmd.Context(), todo.Id)
+			if err != nil {
+				return err
+			}
+			entries = slices.DeleteFunc(entries, func(e au.TimeEntry) bool {
+				return e.StartedAt.Before(report.Since) || !e.StartedAt.Before(report.Until)
+			})
+			for author, d := range au.TimeTotals(entries) {
+				total += d
+				byAuthor[author] += d
+				for _, label := range au.TodoLabels(&todo) {
+					byLabel[label] += d
+				}
+			}
+		}
+		report.Since, report.Until = report.Since.UTC(), report.Until.UTC()
+		report.Total = total.String()
+		report.ByAuthor = preMarshalDurations(byAuthor)
+		report.ByLabel = preMarshalDurations(byLabel)
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(report)
+	},
+}
+
+func init() {
+	timeLogCommand.Flags().String("at", "", "Set when the work started as an RFC3339 timestamp or YYYY-MM-DD date, defaults to the duration before now")
+	timeReportCommand.Flags().String("since", "7d", "Only count time entries which started since this duration ago or time")
+	timeReportCommand.Flags().String("until", "", "Only count time entries which started before this time, defaults to now")
+
+	timeCommand.AddCommand(
+		timeStartCommand,
+		timeStopCommand,
+		timeLogCommand,
+		timeReportCommand,
+	)
+}
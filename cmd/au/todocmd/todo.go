@@ -1,6 +1,7 @@
 package todocmd
 
 import (
+	"fmt"
 	"slices"
 	"strconv"
 	"strings"
@@ -22,6 +23,7 @@ var Command = &cobra.Command{
 
 type marshallableTodo struct {
 	Id           string     `yaml:"id"`
+	ShortId      string     `yaml:"short_id,omitempty"`
 	CreatedAt    time.Time  `yaml:"created_at"`
 	CreatedBy    string     `yaml:"created_by,omitempty"`
 	UpdatedAt    *time.Time `yaml:"updated_at,omitempty"`
@@ -31,10 +33,49 @@ type marshallableTodo struct {
 	Title       string            `yaml:"title"`
 	Description string            `yaml:"description,omitempty"`
 	Status      string            `yaml:"status"`
+	Workflow    string            `yaml:"workflow_status,omitempty"`
 	Annotations map[string]string `yaml:"annotations,omitempty"`
+
+	Checklist      string                      `yaml:"checklist,omitempty"`
+	ChecklistItems []marshallableChecklistItem `yaml:"checklist_items,omitempty"`
+
+	TimeSpent    string               `yaml:"time_spent,omitempty"`
+	TimeByAuthor map[string]string    `yaml:"time_by_author,omitempty"`
+	Timers       map[string]time.Time `yaml:"timers,omitempty"`
+
+	Blocked   bool                   `yaml:"blocked,omitempty"`
+	Conflicts []marshallableConflict `yaml:"conflicts,omitempty"`
+	Children  []interface{}          `yaml:"children,omitempty"`
+}
+
+type marshallableChecklistItem struct {
+	Id   string `yaml:"id"`
+	Text string `yaml:"text"`
+	Done bool   `yaml:"done"`
+}
+
+type marshallableConflictValue struct {
+	Value   string    `yaml:"value"`
+	Author  string    `yaml:"author,omitempty"`
+	ActorId string    `yaml:"actor_id"`
+	Hash    string    `yaml:"hash"`
+	At      time.Time `yaml:"at"`
+}
+
+type marshallableConflict struct {
+	Field  string                      `yaml:"field"`
+	Values []marshallableConflictValue `yaml:"values"`
 }
 
 func preMarshalTodo(todo *au.Todo) interface{} {
+	var checklist string
+	if done, total := au.ChecklistProgress(todo.Checklist); total > 0 {
+		checklist = fmt.Sprintf("%d/%d", done, total)
+	}
+	var timeSpent string
+	if todo.TimeSpent > 0 {
+		timeSpent = todo.TimeSpent.String()
+	}
 	return &marshallableTodo{
 		Id:           todo.Id,
 		CreatedAt:    todo.CreatedAt,
@@ -45,7 +86,11 @@ func preMarshalTodo(todo *au.Todo) interface{} {
 		Title:        todo.Title,
 		Description:  todo.Description,
 		Status:       todo.Status,
+		Workflow:     todo.Annotations[au.AurelianWorkflowStatusAnnotation],
 		Annotations:  todo.Annotations,
+		Checklist:    checklist,
+		TimeSpent:    timeSpent,
+		Timers:       todo.Timers,
 	}
 }
 
@@ -59,23 +104,101 @@ var getCommand = &cobra.Command{
 		if w == "" {
 			return errors.New("current workspace not set")
 		}
-		ws, err := s.OpenWorkspace(cmd.Context(), w, false)
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
 		if err != nil {
 			return err
 		}
 		defer ws.Close()
 
-		todo, err := ws.GetTodo(cmd.Context(), cmd.Flags().Arg(0))
+		id, err := common.ResolveTodoId(cmd.Context(), ws, cmd.Flags().Arg(0))
+		if err != nil {
+			return err
+		}
+		todo, err := ws.GetTodo(cmd.Context(), id)
 		if err != nil {
 			return err
 		}
+		conflicts, err := ws.ListTodoConflicts(cmd.Context(), todo.Id)
+		if err != nil {
+			return err
+		}
+
+		output := preMarshalTodo(todo).(*marshallableTodo)
+		for _, item := range todo.Checklist {
+			output.ChecklistItems = append(output.ChecklistItems, marshallableChecklistItem{Id: item.Id, Text: item.Text, Done: item.Done})
+		}
+		if entries, err := ws.ListTimeEntries(cmd.Context(), todo.Id); err != nil {
+			return err
+		} else {
+			output.TimeByAuthor = preMarshalDurations(au.TimeTotals(entries))
+		}
+		for _, c := range conflicts {
+			mc := marshallableConflict{Field: c.Field, Values: make([]marshallableConflictValue, len(c.Values))}
+			for i, v := range c.Values {
+				mc.Values[i] = marshallableConflictValue{Value: v.Value, Author: v.Author, ActorId: v.ActorId, Hash: v.Hash, At: v.At}
+			}
+			output.Conflicts = append(output.Conflicts, mc)
+		}
 
 		encoder := yaml.NewEncoder(cmd.OutOrStdout())
 		encoder.SetIndent(2)
-		return encoder.Encode(preMarshalTodo(todo))
+		return encoder.Encode(output)
 	},
 }
 
+// preMarshalTodoTree nests each todo under its parent while preserving the order of the given todos at each level. Todos
+// whose parent is not in the list are shown at the top level.
+func preMarshalTodoTree(todos []au.Todo, openTodoIds map[string]bool, shortIds map[string]string) []interface{} {
+	exists := make(map[string]bool, len(todos))
+	for _, t := range todos {
+		exists[t.Id] = true
+	}
+	var build func(parentId string, seen map[string]bool) []interface{}
+	build = func(parentId string, seen map[string]bool) []interface{} {
+		output := make([]interface{}, 0)
+		for _, t := range todos {
+			p := t.Annotations[au.AurelianParentAnnotation]
+			if !exists[p] {
+				p = ""
+			}
+			if p != parentId || seen[t.Id] {
+				continue
+			}
+			seen[t.Id] = true
+			mt := preMarshalTodo(&t).(*marshallableTodo)
+			mt.ShortId = shortIds[t.Id]
+			mt.Blocked = isBlocked(&t, openTodoIds)
+			mt.Children = build(t.Id, seen)
+			output = append(output, mt)
+		}
+		return output
+	}
+	seen := make(map[string]bool)
+	output := build("", seen)
+	// concurrent edits may leave a cycle of parents which has no root, show these at the top level too
+	for _, t := range todos {
+		if !seen[t.Id] {
+			seen[t.Id] = true
+			mt := preMarshalTodo(&t).(*marshallableTodo)
+			mt.ShortId = shortIds[t.Id]
+			mt.Blocked = isBlocked(&t, openTodoIds)
+			mt.Children = build(t.Id, seen)
+			output = append(output, mt)
+		}
+	}
+	return output
+}
+
+// isBlocked returns whether any of the todos blocking the given todo are still open.
+func isBlocked(todo *au.Todo, openTodoIds map[string]bool) bool {
+	for _, id := range au.TodoBlockerIds(todo) {
+		if openTodoIds[id] {
+			return true
+		}
+	}
+	return false
+}
+
 var listCommand = &cobra.Command{
 	Use:   "list",
 	Short: "List all Todos",
@@ -86,7 +209,7 @@ var listCommand = &cobra.Command{
 		if w == "" {
 			return errors.New("current workspace not set")
 		}
-		ws, err := s.OpenWorkspace(cmd.Context(), w, false)
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
 		if err != nil {
 			return err
 		}
@@ -96,27 +219,97 @@ var listCommand = &cobra.Command{
 		if err != nil {
 			return err
 		}
+		// short ids must be unique among all todos, and blockers may be open even if they are not listed
+		allIds := make([]string, len(todos))
+		openTodoIds := make(map[string]bool, len(todos))
+		for i, t := range todos {
+			allIds[i] = t.Id
+			openTodoIds[t.Id] = t.Status == "open"
+		}
+		shortIds := au.ShortIds(allIds)
 
-		slices.SortFunc(todos, func(a, b au.Todo) int {
-			return a.CreatedAt.Compare(b.CreatedAt)
-		})
-		slices.SortStableFunc(todos, func(a, b au.Todo) int {
-			rankA, _ := strconv.Atoi(a.Annotations[au.AurelianRankAnnotation])
-			rankB, _ := strconv.Atoi(b.Annotations[au.AurelianRankAnnotation])
-			return rankB - rankA
-		})
+		if v, err := cmd.Flags().GetStringArray("label"); err != nil {
+			return errors.Wrap(err, "failed to get label flag")
+		} else if len(v) > 0 {
+			todos = au.FilterTodosByLabels(todos, v...)
+		}
 
-		preMashalledTodos := make([]interface{}, len(todos))
-		for i, t := range todos {
-			preMashalledTodos[i] = preMarshalTodo(&t)
+		if v, err := cmd.Flags().GetString("assignee"); err != nil {
+			return errors.Wrap(err, "failed to get assignee flag")
+		} else if v != "" {
+			assignee, err := resolveAssignee(cmd, ws, v)
+			if err != nil {
+				return err
+			}
+			todos = au.FilterTodosByAssignee(todos, assignee)
 		}
 
+		now := time.Now()
+		if v, err := cmd.Flags().GetBool("show-hidden"); err != nil {
+			return errors.Wrap(err, "failed to get show-hidden flag")
+		} else if !v {
+			todos = slices.DeleteFunc(todos, func(t au.Todo) bool {
+				return au.IsTodoHidden(&t, now)
+			})
+		}
+		if v, err := cmd.Flags().GetBool("overdue"); err != nil {
+			return errors.Wrap(err, "failed to get overdue flag")
+		} else if v {
+			todos = slices.DeleteFunc(todos, func(t au.Todo) bool {
+				return !au.IsTodoOverdue(&t, now)
+			})
+		}
+		if v, err := cmd.Flags().GetString("due-before"); err != nil {
+			return errors.Wrap(err, "failed to get due-before flag")
+		} else if v != "" {
+			dueBefore, err := au.ParseTimeInput(v)
+			if err != nil {
+				return err
+			}
+			todos = slices.DeleteFunc(todos, func(t au.Todo) bool {
+				due := au.TodoDue(&t)
+				return due == nil || !due.Before(dueBefore)
+			})
+		}
+
+		au.SortTodosByRank(todos)
+
 		encoder := yaml.NewEncoder(cmd.OutOrStdout())
 		encoder.SetIndent(2)
+
+		if v, err := cmd.Flags().GetBool("tree"); err != nil {
+			return errors.Wrap(err, "failed to get tree flag")
+		} else if v {
+			return encoder.Encode(preMarshalTodoTree(todos, openTodoIds, shortIds))
+		}
+
+		preMashalledTodos := make([]interface{}, len(todos))
+		for i, t := range todos {
+			mt := preMarshalTodo(&t).(*marshallableTodo)
+			mt.ShortId = shortIds[t.Id]
+			mt.Blocked = isBlocked(&t, openTodoIds)
+			preMashalledTodos[i] = mt
+		}
 		return encoder.Encode(preMashalledTodos)
 	},
 }
 
+// timeFlagsToAnnotations converts the --due and --start flags into their annotations.
+func timeFlagsToAnnotations(cmd *cobra.Command, annotations map[string]string) error {
+	for flag, key := range map[string]string{"due": au.AurelianDueAnnotation, "start": au.AurelianStartAnnotation} {
+		if v, err := cmd.Flags().GetString(flag); err != nil {
+			return errors.Wrapf(err, "failed to get %s flag", flag)
+		} else if v != "" {
+			t, err := au.ParseTimeInput(v)
+			if err != nil {
+				return err
+			}
+			annotations[key] = au.FormatTodoTime(t)
+		}
+	}
+	return nil
+}
+
 var createCommand = &cobra.Command{
 	Use:   "create",
 	Short: "Create a new Todo",
@@ -127,23 +320,46 @@ var createCommand = &cobra.Command{
 		if w == "" {
 			return errors.New("current workspace not set")
 		}
-		ws, err := s.OpenWorkspace(cmd.Context(), w, true)
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
 		if err != nil {
 			return err
 		}
 		defer ws.Close()
 
-		params := au.CreateTodoParams{}
+		params := au.CreateTodoParams{Annotations: make(map[string]string)}
+
+		if v, err := cmd.Flags().GetString("template"); err != nil {
+			return errors.Wrap(err, "failed to get template flag")
+		} else if vars, err := cmd.Flags().GetStringArray("var"); err != nil {
+			return errors.Wrap(err, "failed to get var flag")
+		} else if v == "" && len(vars) > 0 {
+			return errors.New("the var flag can only be used with a template")
+		} else if v != "" {
+			values := make(map[string]string, len(vars))
+			for _, entry := range vars {
+				parts := strings.SplitN(entry, "=", 2)
+				if len(parts) == 1 {
+					return errors.Errorf("invalid var argument '%s', must be key=value", entry)
+				}
+				values[parts[0]] = parts[1]
+			}
+			template, err := ws.GetTodoTemplate(cmd.Context(), v)
+			if err != nil {
+				return err
+			} else if params, err = au.RenderTodoTemplate(template, values); err != nil {
+				return err
+			}
+		}
 
 		if v, err := cmd.Flags().GetString("title"); err != nil {
 			return errors.Wrap(err, "failed to get title flag")
-		} else {
+		} else if v != "" {
 			params.Title = v
 		}
 
 		if v, err := cmd.Flags().GetString("description"); err != nil {
 			return errors.Wrap(err, "failed to get description flag")
-		} else {
+		} else if v != "" {
 			params.Description = v
 		}
 
@@ -161,7 +377,6 @@ var createCommand = &cobra.Command{
 		if v, err := cmd.Flags().GetStringArray("annotation"); err != nil {
 			return errors.Wrap(err, "failed to get annotations flag")
 		} else {
-			params.Annotations = make(map[string]string)
 			for _, entry := range v {
 				parts := strings.SplitN(entry, "=", 2)
 				if len(parts) == 1 {
@@ -174,12 +389,49 @@ var createCommand = &cobra.Command{
 			}
 		}
 
-		if v, ok := cmd.Context().Value(common.CurrentAuthorContextKey).(string); ok && v != "" {
-			params.CreatedBy = v
-		} else if v := ws.Metadata().CurrentAuthor; v != nil {
-			params.CreatedBy = *v
+		if v, err := cmd.Flags().GetString("parent"); err != nil {
+			return errors.Wrap(err, "failed to get parent flag")
+		} else if v != "" {
+			if params.Annotations[au.AurelianParentAnnotation], err = common.ResolveTodoId(cmd.Context(), ws, v); err != nil {
+				return err
+			}
+		}
+
+		if v, err := cmd.Flags().GetStringArray("blocked-by"); err != nil {
+			return errors.Wrap(err, "failed to get blocked-by flag")
+		} else {
+			for _, blockerId := range v {
+				if blockerId, err = common.ResolveTodoId(cmd.Context(), ws, blockerId); err != nil {
+					return err
+				}
+				params.Annotations[au.BlockedByAnnotationKey(blockerId)] = "true"
+			}
+		}
+
+		if err := timeFlagsToAnnotations(cmd, params.Annotations); err != nil {
+			return err
+		}
+
+		if v, err := cmd.Flags().GetString("repeat"); err != nil {
+			return errors.Wrap(err, "failed to get repeat flag")
+		} else if v != "" {
+			r, err := au.ParseRecurrence(v)
+			if err != nil {
+				return err
+			}
+			params.Annotations[au.AurelianRecurrenceAnnotation] = r.String()
+		}
+
+		if v, err := cmd.Flags().GetString("status"); err != nil {
+			return errors.Wrap(err, "failed to get status flag")
+		} else if v != "" {
+			params.Status = &v
+		}
+
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
 		} else {
-			return errors.New("no author set, please set one for the current workspace")
+			params.CreatedBy = v
 		}
 
 		if todo, err := ws.CreateTodo(cmd.Context(), params); err != nil {
@@ -194,6 +446,16 @@ var createCommand = &cobra.Command{
 	},
 }
 
+func openTodoIds(todos []au.Todo) []string {
+	output := make([]string, 0)
+	for _, t := range todos {
+		if t.Status == "open" {
+			output = append(output, t.Id)
+		}
+	}
+	return output
+}
+
 var editCommand = &cobra.Command{
 	Use:        "edit <id>",
 	Short:      "Edit a Todo by id",
@@ -205,13 +467,17 @@ var editCommand = &cobra.Command{
 		if w == "" {
 			return errors.New("current workspace not set")
 		}
-		ws, err := s.OpenWorkspace(cmd.Context(), w, true)
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
 		if err != nil {
 			return err
 		}
 		defer ws.Close()
 
-		todo, err := ws.GetTodo(cmd.Context(), cmd.Flags().Arg(0))
+		id, err := common.ResolveTodoId(cmd.Context(), ws, cmd.Flags().Arg(0))
+		if err != nil {
+			return err
+		}
+		todo, err := ws.GetTodo(cmd.Context(), id)
 		if err != nil {
 			return err
 		}
@@ -265,22 +531,75 @@ var editCommand = &cobra.Command{
 			}
 		}
 
-		if v, ok := cmd.Context().Value(common.CurrentAuthorContextKey).(string); ok && v != "" {
-			params.UpdatedBy = v
-		} else if v := ws.Metadata().CurrentAuthor; v != nil {
-			params.UpdatedBy = *v
+		if v, err := cmd.Flags().GetStringArray("blocked-by"); err != nil {
+			return errors.Wrap(err, "failed to get blocked-by flag")
+		} else {
+			for _, blockerId := range v {
+				if blockerId, err = common.ResolveTodoId(cmd.Context(), ws, blockerId); err != nil {
+					return err
+				}
+				params.Annotations[au.BlockedByAnnotationKey(blockerId)] = "true"
+			}
+		}
+		if v, err := cmd.Flags().GetStringArray("unblocked-by"); err != nil {
+			return errors.Wrap(err, "failed to get unblocked-by flag")
 		} else {
-			return errors.New("no author set, please set one for the current workspace")
+			for _, blockerId := range v {
+				if blockerId, err = common.ResolveTodoId(cmd.Context(), ws, blockerId); err != nil {
+					return err
+				}
+				params.Annotations[au.BlockedByAnnotationKey(blockerId)] = ""
+			}
 		}
 
-		if todo, err := ws.EditTodo(cmd.Context(), cmd.Flags().Arg(0), params); err != nil {
+		if err := timeFlagsToAnnotations(cmd, params.Annotations); err != nil {
+			return err
+		}
+
+		if v, err := cmd.Flags().GetString("repeat"); err != nil {
+			return errors.Wrap(err, "failed to get repeat flag")
+		} else if v == "never" {
+			params.Annotations[au.AurelianRecurrenceAnnotation] = ""
+		} else if v != "" {
+			r, err := au.ParseRecurrence(v)
+			if err != nil {
+				return err
+			}
+			params.Annotations[au.AurelianRecurrenceAnnotation] = r.String()
+		}
+
+		if v, err := cmd.Flags().GetString("reason"); err != nil {
+			return errors.Wrap(err, "failed to get reason flag")
+		} else if v != "" {
+			params.Annotations[au.AurelianStatusReasonAnnotation] = v
+		}
+
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.UpdatedBy = v
+		}
+
+		if edited, err := ws.EditTodo(cmd.Context(), id, params); err != nil {
 			return err
 		} else if err := ws.Flush(); err != nil {
 			return errors.Wrap(err, "failed to flush to file")
 		} else {
+			if todo.Status != "closed" && edited.Status == "closed" {
+				if blockers, err := ws.ListTodoBlockers(cmd.Context(), edited.Id); err != nil {
+					return err
+				} else if openIds := openTodoIds(blockers); len(openIds) > 0 {
+					_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "warning: todo '%s' was closed while still blocked by open todos: %s\n", edited.Id, strings.Join(openIds, ", "))
+				}
+				if au.TodoRecurrence(edited) != nil {
+					if nextId, err := au.NextOccurrenceId(edited.Id); err == nil {
+						_, _ = fmt.Fprintf(cmd.ErrOrStderr(), "todo '%s' repeats, the next occurrence is '%s'\n", edited.Id, nextId)
+					}
+				}
+			}
 			encoder := yaml.NewEncoder(cmd.OutOrStdout())
 			encoder.SetIndent(2)
-			return encoder.Encode(preMarshalTodo(todo))
+			return encoder.Encode(preMarshalTodo(edited))
 		}
 	},
 }
@@ -296,26 +615,160 @@ var deleteCommand = &cobra.Command{
 		if w == "" {
 			return errors.New("current workspace not set")
 		}
-		ws, err := s.OpenWorkspace(cmd.Context(), w, true)
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
 		if err != nil {
 			return err
 		}
 		defer ws.Close()
 
 		var params au.DeleteTodoParams
-		if v, ok := cmd.Context().Value(common.CurrentAuthorContextKey).(string); ok && v != "" {
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
 			params.DeletedBy = v
-		} else if v := ws.Metadata().CurrentAuthor; v != nil {
-			params.DeletedBy = *v
+		}
+
+		id, err := common.ResolveTodoId(cmd.Context(), ws, cmd.Flags().Arg(0))
+		if err != nil {
+			return err
+		}
+		if err := ws.DeleteTodo(cmd.Context(), id, params); err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		}
+		return nil
+	},
+}
+
+var historyCommand = &cobra.Command{
+	Use:        "history <id>",
+	Short:      "Show the audit trail of changes made to a Todo",
+	Args:       cobra.ExactArgs(1),
+	ArgAliases: []string{"id"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		id, err := common.ResolveTodoId(cmd.Context(), ws, cmd.Flags().Arg(0))
+		if err != nil {
+			return err
+		}
+		history, err := ws.GetTodoHistory(cmd.Context(), id)
+		if err != nil {
+			return err
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		return encoder.Encode(common.PreMarshalHistory(history))
+	},
+}
+
+var resolveCommand = &cobra.Command{
+	Use:   "resolve <id> <field> <value>",
+	Short: "Resolve a conflict on a Todo field by choosing its value",
+	Long: `Resolve a conflict on a Todo field by choosing its value.
+
+The field is one of title, status, or annotations/<key> as shown in the conflicts section of 'todo get'. A status may
+be any workflow status that 'todo edit --status' accepts. An empty value for an annotation removes it.`,
+	Args:       cobra.ExactArgs(3),
+	ArgAliases: []string{"id", "field", "value"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		params := au.ResolveTodoConflictParams{Field: cmd.Flags().Arg(1), Value: cmd.Flags().Arg(2)}
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
 		} else {
-			return errors.New("no author set, please set one for the current workspace")
+			params.ResolvedBy = v
 		}
 
-		if err := ws.DeleteTodo(cmd.Context(), cmd.Flags().Arg(0), params); err != nil {
+		id, err := common.ResolveTodoId(cmd.Context(), ws, cmd.Flags().Arg(0))
+		if err != nil {
+			return err
+		}
+		if todo, err := ws.ResolveTodoConflict(cmd.Context(), id, params); err != nil {
 			return err
 		} else if err := ws.Flush(); err != nil {
 			return errors.Wrap(err, "failed to flush to file")
+		} else {
+			encoder := yaml.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent(2)
+			return encoder.Encode(preMarshalTodo(todo))
+		}
+	},
+}
+
+var graphCommand = &cobra.Command{
+	Use:   "graph",
+	Short: "Emit the blocking and parent relationships between Todos as Graphviz DOT",
+	Long: `Emit the blocking and parent relationships between Todos as Graphviz DOT.
+
+Solid edges point from a blocking Todo to the Todo it blocks, dashed edges point from a parent Todo to its child. Closed
+Todos are shown in grey. Render the output with 'dot -Tsvg'.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		todos, err := ws.ListTodos(cmd.Context())
+		if err != nil {
+			return err
+		}
+		slices.SortFunc(todos, func(a, b au.Todo) int {
+			return a.CreatedAt.Compare(b.CreatedAt)
+		})
+		exists := make(map[string]bool, len(todos))
+		for _, t := range todos {
+			exists[t.Id] = true
+		}
+
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "strict digraph {")
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "node [shape=box]")
+		for _, t := range todos {
+			style := ""
+			if t.Status == "closed" {
+				style = ", style=\"filled\" fillcolor=lightgrey"
+			}
+			_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\"%s\" [label=%s%s]\n", t.Id, strconv.Quote(t.Id[len(t.Id)-6:]+": "+t.Title), style)
+		}
+		for _, t := range todos {
+			for _, blockerId := range au.TodoBlockerIds(&t) {
+				if exists[blockerId] {
+					_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\"%s\" -> \"%s\"\n", blockerId, t.Id)
+				}
+			}
+			if parentId := t.Annotations[au.AurelianParentAnnotation]; exists[parentId] {
+				_, _ = fmt.Fprintf(cmd.OutOrStdout(), "\"%s\" -> \"%s\" [style=dashed]\n", parentId, t.Id)
+			}
 		}
+		_, _ = fmt.Fprintln(cmd.OutOrStdout(), "}")
 		return nil
 	},
 }
@@ -323,22 +776,56 @@ var deleteCommand = &cobra.Command{
 func init() {
 	createCommand.Flags().StringP("title", "t", "", "Set the title of the Todo")
 	createCommand.Flags().String("description", "", "Set the description of the Todo")
+	createCommand.Flags().String("status", "", "Set the status of the Todo, defaults to the default status of the workspace")
 	createCommand.Flags().Bool("edit", false, "Edit the title and description using AU_EDITOR")
 	createCommand.Flags().StringArray("annotation", []string{}, "Set an annotation using key=value syntax")
 	createCommand.Flags().String("author", "", "Set the author of the Todo as 'Name <email>'")
+	createCommand.Flags().String("parent", "", "Set the id of the parent Todo")
+	createCommand.Flags().StringArray("blocked-by", []string{}, "Mark the Todo as blocked by the Todo with this id")
+	createCommand.Flags().String("due", "", "Set the due time as an RFC3339 timestamp or YYYY-MM-DD date")
+	createCommand.Flags().String("start", "", "Set the start time as an RFC3339 timestamp or YYYY-MM-DD date, the Todo is hidden until then")
+	createCommand.Flags().String("repeat", "", "Repeat the Todo when it is closed, either daily, weekly, monthly, yearly, or an RRULE such as FREQ=WEEKLY;INTERVAL=2")
+	createCommand.Flags().String("template", "", "Create the Todo from this template, other flags override the template")
+	createCommand.Flags().StringArray("var", []string{}, "Set a template variable using key=value syntax")
+
+	listCommand.Flags().Bool("tree", false, "Nest Todos under their parent Todo")
+	listCommand.Flags().StringArray("label", []string{}, "Only list Todos with this label, may be repeated to require multiple labels")
+	listCommand.Flags().String("assignee", "", "Only list Todos assigned to this 'Name <email>' author, or 'me' for the current author")
+	listCommand.Flags().Bool("show-hidden", false, "Include Todos which have not started yet or are snoozed")
+	listCommand.Flags().Bool("overdue", false, "Only list open Todos which are past their due time")
+	listCommand.Flags().String("due-before", "", "Only list Todos due before this RFC3339 timestamp or YYYY-MM-DD date")
 
 	editCommand.Flags().StringP("title", "t", "", "Set the title of the Todo")
 	editCommand.Flags().String("description", "", "Set the description of the Todo")
-	editCommand.Flags().String("status", "", "Set the status of the Todo")
+	editCommand.Flags().String("status", "", "Set the status of the Todo, one of the workflow statuses of the workspace, or open or closed if it has none")
+	editCommand.Flags().String("reason", "", "Set the reason for the status of the Todo, cleared when the status next changes")
 	editCommand.Flags().Bool("edit", false, "Edit the title and description using AU_EDITOR")
-	editCommand.Flags().StringArray("annotation", []string{}, "Set an annotation using key=value or clear an annotation using key=")
+	editCommand.Flags().StringArray("annotation", []string{}, "Set an annotation using key=value or clear an annotation using key=, existing annotations without a schema are read-only")
 	editCommand.Flags().String("author", "", "Set the author of the Todo update as 'Name <email>'")
+	editCommand.Flags().StringArray("blocked-by", []string{}, "Mark the Todo as blocked by the Todo with this id")
+	editCommand.Flags().StringArray("unblocked-by", []string{}, "Remove the Todo with this id from the blockers of the Todo")
+	editCommand.Flags().String("due", "", "Set the due time as an RFC3339 timestamp or YYYY-MM-DD date")
+	editCommand.Flags().String("start", "", "Set the start time as an RFC3339 timestamp or YYYY-MM-DD date, the Todo is hidden until then")
+	editCommand.Flags().String("repeat", "", "Repeat the Todo when it is closed, either daily, weekly, monthly, yearly, an RRULE such as FREQ=WEEKLY;INTERVAL=2, or never")
 
 	Command.AddCommand(
 		getCommand,
 		listCommand,
 		createCommand,
 		editCommand,
+		bulkEditCommand,
 		deleteCommand,
+		historyCommand,
+		resolveCommand,
+		graphCommand,
+		labelCommand,
+		checkCommand,
+		moveCommand,
+		rebalanceCommand,
+		copyCommand,
+		snoozeCommand,
+		assignCommand,
+		unassignCommand,
+		timeCommand,
 	)
 }
@@ -5,11 +5,14 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"strings"
 	"testing"
 	"time"
 
+	"github.com/automerge/automerge-go"
 	"github.com/oklog/ulid/v2"
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
 
@@ -21,6 +24,15 @@ func executeAndResetCommand(ctx context.Context, cmd *cobra.Command, args []stri
 	cmd.SetArgs(args)
 	subCmd, err := cmd.ExecuteContextC(ctx)
 	subCmd.SetContext(nil)
+	// flag values otherwise leak into the next execution of the same sub command
+	subCmd.Flags().VisitAll(func(f *pflag.Flag) {
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			_ = sv.Replace([]string{})
+		} else {
+			_ = f.Value.Set(f.DefValue)
+		}
+		f.Changed = false
+	})
 	return err
 }
 
@@ -83,6 +95,17 @@ func TestCli_create_todos(t *testing.T) {
 	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
 	assert.Equal(t, todoId, outStruct["id"].(string))
 
+	// annotations without a schema are read-only once set
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"edit", todoId, "--annotation", "about:blank#example="}), "annotation 'about:blank#example' is read-only since it is not understood by this client")
+	{
+		openWs, err := s.OpenWorkspace(context.Background(), wsMeta.Id, true)
+		assert.NoError(t, err)
+		_, err = openWs.SetAnnotationSchema(context.Background(), au.SetAnnotationSchemaParams{Key: "about:blank", Type: au.AnnotationTypeString, UpdatedBy: "Example <email@me.com>"})
+		assert.NoError(t, err)
+		assert.NoError(t, openWs.Flush())
+		assert.NoError(t, openWs.Close())
+	}
+
 	buff.Reset()
 	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{
 		"edit", "--title", "My todo 2", "--description", "Edited description", "--status", "closed", todoId,
@@ -191,3 +214,905 @@ func TestCli_todo_authors(t *testing.T) {
 	assert.NotNil(t, outStruct["updated_at"])
 	assert.Equal(t, "Example2 <email@me.com>", outStruct["updated_by"])
 }
+
+func TestCli_todo_history(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Todo 1", "--description", "Something"}))
+	var outStruct map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	todoId := outStruct["id"].(string)
+
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"edit", todoId, "--title", "Todo 2"}))
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"history", todoId}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	if assert.Len(t, outSlice, 2) {
+		assert.Equal(t, "Example <email@me.com>", outSlice[1]["author"])
+		assert.Contains(t, outSlice[1]["changes"], map[string]interface{}{"field": "title", "before": "Todo 1", "after": "Todo 2"})
+	}
+}
+
+func TestCli_todo_at(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Todo 1"}))
+	var outStruct map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	todoId := outStruct["id"].(string)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"history", todoId}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	createdHash := outSlice[0]["hash"].(string)
+
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"edit", todoId, "--title", "Todo 2"}))
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Todo 3"}))
+
+	atCtx := context.WithValue(ctx, common.AtContextKey, createdHash)
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(atCtx, Command, []string{"list"}))
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	if assert.Len(t, outSlice, 1) {
+		assert.Equal(t, "Todo 1", outSlice[0]["title"])
+	}
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(atCtx, Command, []string{"get", todoId}))
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	assert.Equal(t, "Todo 1", outStruct["title"])
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(atCtx, Command, []string{"history", todoId}))
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	if assert.Len(t, outSlice, 1) {
+		assert.Equal(t, createdHash, outSlice[0]["hash"])
+	}
+
+	assert.EqualError(t, executeAndResetCommand(atCtx, Command, []string{"edit", todoId, "--title", "Todo 4"}), "--at cannot be used with commands that change the workspace")
+}
+
+func TestCli_todo_conflicts(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Todo 1"}))
+	var outStruct map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	todoId := outStruct["id"].(string)
+
+	// simulate two peers that replaced the title at the same time rather than editing its text
+	ws, err := s.OpenWorkspace(ctx, wsMeta.Id, true)
+	assert.NoError(t, err)
+	doc := ws.(au.DocProvider).GetDoc()
+	forked, err := doc.Fork()
+	assert.NoError(t, err)
+	assert.NoError(t, forked.Path("todos", todoId, "title").Set(automerge.NewText("Todo 2")))
+	_, err = forked.Commit("Other <other@me.com> edited todo " + todoId)
+	assert.NoError(t, err)
+	assert.NoError(t, doc.Path("todos", todoId, "title").Set(automerge.NewText("Todo 3")))
+	_, err = doc.Commit("Example <email@me.com> edited todo " + todoId)
+	assert.NoError(t, err)
+	_, err = doc.Merge(forked)
+	assert.NoError(t, err)
+	assert.NoError(t, ws.Flush())
+	assert.NoError(t, ws.Close())
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"get", todoId}))
+	outStruct = nil
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	if assert.Len(t, outStruct["conflicts"], 1) {
+		conflict := outStruct["conflicts"].([]interface{})[0].(map[string]interface{})
+		assert.Equal(t, "title", conflict["field"])
+		assert.Len(t, conflict["values"], 2)
+	}
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"resolve", todoId, "title", "Todo 4"}))
+	outStruct = nil
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	assert.Equal(t, "Todo 4", outStruct["title"])
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"get", todoId}))
+	outStruct = nil
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	assert.NotContains(t, outStruct, "conflicts")
+}
+
+func TestCli_todo_tree(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Parent"}))
+	var outStruct map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	parentId := outStruct["id"].(string)
+
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Child", "--parent", parentId}))
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Child", "--parent", "unknown"}), "parent todo with id 'unknown' does not exist")
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list", "--tree"}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	if assert.Len(t, outSlice, 1) {
+		assert.Equal(t, "Parent", outSlice[0]["title"])
+		if assert.Len(t, outSlice[0]["children"], 1) {
+			assert.Equal(t, "Child", outSlice[0]["children"].([]interface{})[0].(map[string]interface{})["title"])
+		}
+	}
+}
+
+func TestCli_todo_blocked_by(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Blocker"}))
+	var outStruct map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	blockerId := outStruct["id"].(string)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Blocked", "--blocked-by", blockerId}))
+	outStruct = nil
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	blockedId := outStruct["id"].(string)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	blocked := make(map[string]interface{})
+	for _, item := range outSlice {
+		blocked[item["title"].(string)] = item["blocked"]
+	}
+	assert.Equal(t, map[string]interface{}{"Blocker": nil, "Blocked": true}, blocked)
+
+	// a blocker that is not listed since it is hidden still blocks
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"edit", blockerId, "--start", "2999-01-01"}))
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	outSlice = nil
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	if assert.Len(t, outSlice, 1) {
+		assert.Equal(t, "Blocked", outSlice[0]["title"])
+		assert.Equal(t, true, outSlice[0]["blocked"])
+	}
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"graph"}))
+	assert.Contains(t, buff.String(), fmt.Sprintf("\"%s\" -> \"%s\"\n", blockerId, blockedId))
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"edit", blockedId, "--status", "closed"}))
+	assert.Contains(t, buff.String(), fmt.Sprintf("warning: todo '%s' was closed while still blocked by open todos: %s\n", blockedId, blockerId))
+}
+
+func TestCli_todo_labels(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Todo 1"}))
+	var outStruct map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	todoId := outStruct["id"].(string)
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Todo 2"}))
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"label", "add", todoId, "good first issue", "bug"}))
+	outStruct = nil
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	assert.Equal(t, map[string]interface{}{
+		"https://aurelian.one/annotations/label#good%20first%20issue": "true",
+		"https://aurelian.one/annotations/label#bug":                  "true",
+	}, outStruct["annotations"])
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list", "--label", "good first issue"}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	if assert.Len(t, outSlice, 1) {
+		assert.Equal(t, todoId, outSlice[0]["id"])
+	}
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"label", "rm", todoId, "bug"}))
+	outStruct = nil
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	assert.Equal(t, map[string]interface{}{
+		"https://aurelian.one/annotations/label#good%20first%20issue": "true",
+	}, outStruct["annotations"])
+}
+
+func TestCli_todo_move(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	ids := make([]string, 0)
+	for _, title := range []string{"Todo 1", "Todo 2", "Todo 3"} {
+		buff.Reset()
+		assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", title}))
+		var outStruct map[string]interface{}
+		assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+		ids = append(ids, outStruct["id"].(string))
+	}
+
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"move", ids[2], "--top"}))
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"move", ids[0], "--after", ids[1]}))
+	assert.Error(t, executeAndResetCommand(ctx, Command, []string{"move", ids[0]}))
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	titles := make([]string, 0)
+	for _, todo := range outSlice {
+		titles = append(titles, todo["title"].(string))
+	}
+	assert.Equal(t, []string{"Todo 3", "Todo 2", "Todo 1"}, titles)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"rebalance"}))
+	assert.Equal(t, "rebalanced: 3\n", buff.String())
+}
+
+func TestCli_todo_schedule(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	listTitles := func(args ...string) []string {
+		buff.Reset()
+		assert.NoError(t, executeAndResetCommand(ctx, Command, append([]string{"list"}, args...)))
+		var outSlice []map[string]interface{}
+		assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+		titles := make([]string, 0)
+		for _, todo := range outSlice {
+			titles = append(titles, todo["title"].(string))
+		}
+		return titles
+	}
+
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Overdue", "--due", "2020-01-01"}))
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Future", "--start", "2999-01-01T00:00:00Z"}))
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Snoozed"}))
+	var outStruct map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	snoozedId := outStruct["id"].(string)
+
+	assert.Equal(t, []string{"Overdue", "Snoozed"}, listTitles())
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"snooze", snoozedId, "3d"}))
+	outStruct = nil
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	assert.Contains(t, outStruct["annotations"], au.AurelianHideUntilAnnotation)
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"snooze", snoozedId, "later"}), "invalid snooze 'later', expected a duration like 4h or 3d, or a time")
+
+	assert.Equal(t, []string{"Overdue"}, listTitles())
+	assert.Equal(t, []string{"Overdue", "Future", "Snoozed"}, listTitles("--show-hidden"))
+	assert.Equal(t, []string{"Overdue"}, listTitles("--overdue", "--show-hidden"))
+	assert.Equal(t, []string{"Overdue"}, listTitles("--due-before", "2021-01-01"))
+}
+
+func TestCli_todo_assign(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	ids := make([]string, 0)
+	for _, title := range []string{"Todo 1", "Todo 2"} {
+		buff.Reset()
+		assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", title}))
+		var outStruct map[string]interface{}
+		assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+		ids = append(ids, outStruct["id"].(string))
+	}
+
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"assign", ids[0]}))
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"assign", ids[1], "Other <other@me.com>"}))
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list", "--assignee", "me"}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	if assert.Len(t, outSlice, 1) {
+		assert.Equal(t, ids[0], outSlice[0]["id"])
+	}
+
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"unassign", ids[0], "me"}))
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list", "--assignee", "me"}))
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	assert.Len(t, outSlice, 0)
+}
+
+func TestCli_todo_check(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Todo 1"}))
+	var outStruct map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	todoId := outStruct["id"].(string)
+
+	for _, text := range []string{"Write it", "Test it", "Ship it"} {
+		assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"check", "add", todoId, text}))
+	}
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"check", "toggle", todoId, "1"}))
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"check", "move", todoId, "3", "--before", "2"}))
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"check", "rm", todoId, "4"}), "checklist item 4 does not exist, todo '"+todoId+"' has 3 items")
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"check", "rm", todoId, "3"}))
+	outStruct = nil
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	assert.Equal(t, "1/2", outStruct["checklist"])
+	items := outStruct["checklist_items"].([]interface{})
+	if assert.Len(t, items, 2) {
+		assert.Equal(t, "Write it", items[0].(map[string]interface{})["text"])
+		assert.Equal(t, true, items[0].(map[string]interface{})["done"])
+		assert.Equal(t, "Ship it", items[1].(map[string]interface{})["text"])
+	}
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	if assert.Len(t, outSlice, 1) {
+		assert.Equal(t, "1/2", outSlice[0]["checklist"])
+		assert.NotContains(t, outSlice[0], "checklist_items")
+	}
+}
+
+func TestCli_inbox(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+	otherCtx := context.WithValue(ctx, common.CurrentAuthorContextKey, "Other Person <other@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+	InboxCommand.SetOut(buff)
+	InboxCommand.SetErr(buff)
+
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Mine"}))
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(otherCtx, Command, []string{"create", "--title", "Please review", "--description", "@email can you look at this? cc @otherperson"}))
+	var outStruct map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	todoId := outStruct["id"].(string)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, InboxCommand, []string{}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	if assert.Len(t, outSlice, 1) {
+		assert.Equal(t, todoId, outSlice[0]["todo_id"])
+		assert.Equal(t, "Please review", outSlice[0]["title"])
+		assert.Equal(t, "Other Person <other@me.com>", outSlice[0]["mentioned_by"])
+	}
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(otherCtx, InboxCommand, []string{}))
+	assert.Equal(t, "[]\n", buff.String())
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, InboxCommand, []string{"--since", "2999-01-01"}))
+	assert.Equal(t, "[]\n", buff.String())
+	assert.EqualError(t, executeAndResetCommand(ctx, InboxCommand, []string{"--since", "soon"}), "invalid since 'soon', expected a duration like 4h or 3d, or a time")
+}
+
+func TestCli_todo_copy_and_move(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+	otherMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Other"})
+	assert.NoError(t, err)
+
+	var todoId string
+	{
+		openWs, err := s.OpenWorkspace(context.Background(), wsMeta.Id, true)
+		assert.NoError(t, err)
+		todo, err := openWs.CreateTodo(context.Background(), au.CreateTodoParams{Title: "Portable", CreatedBy: "Someone <someone@me.com>"})
+		assert.NoError(t, err)
+		todoId = todo.Id
+		_, err = openWs.CreateComment(context.Background(), todoId, au.CreateCommentParams{MediaType: au.DefaultCommentMediaType, Content: []byte("hello"), CreatedBy: "Someone <someone@me.com>"})
+		assert.NoError(t, err)
+		assert.NoError(t, openWs.Flush())
+		assert.NoError(t, openWs.Close())
+	}
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"copy", todoId, "--to", "Example"}), "the destination workspace must be different to the current workspace")
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"copy", todoId, "--to", "Unknown"}), "workspace 'Unknown' does not exist")
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"copy", todoId, "--to", "Other"}))
+	var outStruct map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	copyId := outStruct["id"].(string)
+	assert.NotEqual(t, todoId, copyId)
+	assert.Equal(t, "Someone <someone@me.com>", outStruct["created_by"])
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Child", "--parent", todoId}))
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	childId := outStruct["id"].(string)
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"move", todoId, "--to", otherMeta.Id}), "cannot move todo '"+todoId+"' since it has 1 children, move or reparent them first")
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"delete", childId}))
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"move", todoId, "--to", otherMeta.Id}))
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	movedId := outStruct["id"].(string)
+	assert.Equal(t, todoId, movedId)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	assert.Equal(t, "[]\n", buff.String())
+
+	otherWs, err := s.OpenWorkspace(context.Background(), otherMeta.Id, false)
+	assert.NoError(t, err)
+	defer otherWs.Close()
+	todos, err := otherWs.ListTodos(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, todos, 2)
+	for _, id := range []string{copyId, movedId} {
+		todo, err := otherWs.GetTodo(context.Background(), id)
+		assert.NoError(t, err)
+		assert.Equal(t, "Portable", todo.Title)
+		assert.Equal(t, wsMeta.Id+"/"+todoId, todo.Annotations[au.AurelianOriginAnnotation])
+		comments, err := otherWs.ListComments(context.Background(), id)
+		assert.NoError(t, err)
+		if assert.Len(t, comments, 1) {
+			assert.Equal(t, "hello", string(comments[0].Content))
+		}
+	}
+}
+
+func TestCli_todo_create_from_template(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+	{
+		openWs, err := s.OpenWorkspace(context.Background(), wsMeta.Id, true)
+		assert.NoError(t, err)
+		_, err = openWs.AddTodoTemplate(context.Background(), au.AddTodoTemplateParams{
+			Name: "release", Title: "Release {{version}}", Description: "Ship {{version}}",
+			Annotations: map[string]string{"about:blank#kind": "release"}, CreatedBy: "Someone <someone@me.com>",
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, openWs.Flush())
+		assert.NoError(t, openWs.Close())
+	}
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"create", "--template", "release"}), "template variable 'version' is not set")
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Hello", "--var", "version=1"}), "the var flag can only be used with a template")
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"create", "--template", "nope"}), "template 'nope' does not exist")
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{
+		"create", "--template", "release", "--var", "version=1.2", "--annotation", "about:blank#kind=hotfix", "--status", "closed",
+	}))
+	var outStruct map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	assert.Equal(t, "Release 1.2", outStruct["title"])
+	assert.Equal(t, "Ship 1.2", outStruct["description"])
+	assert.Equal(t, "closed", outStruct["status"])
+	assert.Equal(t, map[string]interface{}{"about:blank#kind": "hotfix"}, outStruct["annotations"])
+}
+
+func TestCli_todo_repeat(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff, errBuff := new(bytes.Buffer), new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(errBuff)
+	defer Command.SetErr(buff)
+
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Nope", "--repeat", "hourly"}), "recurrence rule part 'HOURLY' is not supported")
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Standup notes", "--due", "2024-01-01T09:00:00Z", "--repeat", "daily"}))
+	var outStruct map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	todoId := outStruct["id"].(string)
+	assert.Equal(t, "FREQ=DAILY", outStruct["annotations"].(map[string]interface{})[au.AurelianRecurrenceAnnotation])
+	nextId, _ := au.NextOccurrenceId(todoId)
+
+	errBuff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"edit", todoId, "--status", "closed"}))
+	assert.Equal(t, "todo '"+todoId+"' repeats, the next occurrence is '"+nextId+"'\n", errBuff.String())
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"get", nextId}))
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	assert.Equal(t, "open", outStruct["status"])
+	assert.Equal(t, map[string]interface{}{
+		au.AurelianDueAnnotation:        "2024-01-02T09:00:00Z",
+		au.AurelianRecurrenceAnnotation: "FREQ=DAILY",
+		au.AurelianPreviousAnnotation:   todoId,
+	}, outStruct["annotations"])
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"edit", nextId, "--repeat", "never"}))
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	assert.NotContains(t, outStruct["annotations"], au.AurelianRecurrenceAnnotation)
+}
+
+func TestCli_todo_time(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", "Timed", "--annotation", au.LabelAnnotationKey("ops") + "=true"}))
+	var outStruct map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	todoId := outStruct["id"].(string)
+	assert.NotContains(t, outStruct, "time_spent")
+
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"time", "log", todoId, "soon"}), "invalid duration 'soon', expected a duration like 90m, 4h, 3d, or 2w")
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"time", "log", todoId, "30m", "--at", "2024-01-02T10:00:00Z"}))
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"time", "start", todoId}))
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"time", "start", todoId}), "a timer is already running on todo '"+todoId+"'")
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"time", "stop", todoId}))
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"time", "log", todoId, "1h", "--at", "2024-01-05T10:00:00Z"}))
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"get", todoId}))
+	outStruct = nil
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	assert.Equal(t, "1h30m0s", outStruct["time_spent"])
+	assert.Equal(t, map[string]interface{}{"Example <email@me.com>": "1h30m0s"}, outStruct["time_by_author"])
+	assert.NotContains(t, outStruct, "timers")
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"time", "report", "--since", "2024-01-01", "--until", "2024-01-03"}))
+	outStruct = nil
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	assert.Equal(t, "30m0s", outStruct["total"])
+	assert.Equal(t, map[string]interface{}{"Example <email@me.com>": "30m0s"}, outStruct["by_author"])
+	assert.Equal(t, map[string]interface{}{"ops": "30m0s"}, outStruct["by_label"])
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"time", "report", "--since", "2024-01-03", "--until", "2024-01-01"}), "since must be before until")
+}
+
+func TestCli_todo_short_ids(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	// the ids share a prefix and a four character suffix, so each needs a five character suffix
+	firstId, secondId := "01HQ8Z00000000000000AABCDE", "01HQ8Z00000000000000ZZBCDE"
+	{
+		openWs, err := s.OpenWorkspace(context.Background(), wsMeta.Id, true)
+		assert.NoError(t, err)
+		for _, id := range []string{firstId, secondId} {
+			_, err := openWs.ImportTodo(context.Background(), au.ImportTodoParams{
+				Todo: au.Todo{Id: id, Title: "Todo " + id[len(id)-6:], Status: "open", CreatedBy: "Someone <someone@me.com>"}, KeepId: true, SourceWorkspaceId: "01HQ8Y0000000000000000SRCE", ImportedBy: "Someone <someone@me.com>",
+			})
+			assert.NoError(t, err)
+		}
+		assert.NoError(t, openWs.Flush())
+		assert.NoError(t, openWs.Close())
+	}
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"list"}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	shortIds := make(map[string]interface{})
+	for _, o := range outSlice {
+		shortIds[o["id"].(string)] = o["short_id"]
+	}
+	assert.Equal(t, map[string]interface{}{firstId: "ABCDE", secondId: "ZBCDE"}, shortIds)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"get", "zbcde"}))
+	var outStruct map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	assert.Equal(t, secondId, outStruct["id"])
+
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"get", "BCDE"}), "todo id 'BCDE' is ambiguous, it matches: "+firstId+", "+secondId)
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"edit", "01HQ8Z", "--title", "Ambiguous"}), "todo id '01HQ8Z' is ambiguous, it matches: "+firstId+", "+secondId)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"edit", "AABCDE", "--blocked-by", "ZZBCDE"}))
+	outStruct = nil
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	assert.Equal(t, firstId, outStruct["id"])
+	assert.Equal(t, "true", outStruct["annotations"].(map[string]interface{})[au.BlockedByAnnotationKey(secondId)])
+
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"delete", "ZBCDE"}))
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"get", "BCDE"}))
+	outStruct = nil
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+	assert.Equal(t, firstId, outStruct["id"])
+}
+
+func TestCli_todo_bulk_edit(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+
+	ids := make([]string, 0)
+	for _, entry := range []string{"One=sprint", "Two=sprint", "Three=later"} {
+		title, label, _ := strings.Cut(entry, "=")
+		buff.Reset()
+		assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"create", "--title", title, "--annotation", au.LabelAnnotationKey(label) + "=true"}))
+		var outStruct map[string]interface{}
+		assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+		ids = append(ids, outStruct["id"].(string))
+	}
+
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"bulk-edit", "--set-status", "closed"}), "at least one id or filter is required")
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"bulk-edit", "--filter", "label=sprint"}), "nothing to edit, set a status, annotation, or label")
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"bulk-edit", "--filter", "label=missing", "--set-status", "closed"}), "no todos match the filter")
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"bulk-edit", "--filter", "label=sprint", "--set-status", "closed", "--dry-run"}))
+	var dryRun []string
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &dryRun))
+	assert.ElementsMatch(t, ids[:2], dryRun)
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"bulk-edit", "--filter", "label=sprint", "--set-status", "unknown", "--dry-run"}), "cannot edit todo '"+ids[0]+"': status must be open or closed")
+
+	// --at only applies to dry runs, which preview the edit against that point in history
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"history", ids[0]}))
+	var history []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &history))
+	atCtx := context.WithValue(ctx, common.AtContextKey, history[0]["hash"].(string))
+	assert.EqualError(t, executeAndResetCommand(atCtx, Command, []string{"bulk-edit", "--filter", "label=sprint", "--set-status", "closed"}), "--at cannot be used with commands that change the workspace")
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(atCtx, Command, []string{"bulk-edit", "--filter", "label=sprint", "--set-status", "closed", "--dry-run"}))
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &dryRun))
+	assert.Equal(t, ids[:1], dryRun)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"bulk-edit", "--filter", "label=sprint", "--filter", "status=open", "--set-status", "closed", "--add-label", "done", "--remove-label", "sprint"}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	assert.Len(t, outSlice, 2)
+
+	for i, id := range ids {
+		buff.Reset()
+		assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"get", id}))
+		var outStruct map[string]interface{}
+		assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outStruct))
+		annotations := outStruct["annotations"].(map[string]interface{})
+		if i < 2 {
+			assert.Equal(t, "closed", outStruct["status"])
+			assert.Equal(t, map[string]interface{}{au.LabelAnnotationKey("done"): "true"}, annotations)
+		} else {
+			assert.Equal(t, "open", outStruct["status"])
+			assert.Equal(t, map[string]interface{}{au.LabelAnnotationKey("later"): "true"}, annotations)
+		}
+	}
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"history", ids[0]}))
+	assert.Contains(t, buff.String(), "Example <email@me.com> edited 2 todos")
+}
@@ -0,0 +1,120 @@
+package todocmd
+
+import (
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/pkg/au"
+)
+
+var copyCommand = &cobra.Command{
+	Use:   "copy <id>",
+	Short: "Copy a Todo and its Comments to another Workspace",
+	Long: `Copy a Todo and its Comments to another Workspace.
+
+The copy keeps the title, description, annotations, checklist, time entries, and comments along with their original
+authors and timestamps, and has an origin annotation linking back to the source Todo. Parent, blocked-by, previous, and
+next annotations are dropped because they reference Todos in the source Workspace.`,
+	Args:       cobra.ExactArgs(1),
+	ArgAliases: []string{"id"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		to, err := cmd.Flags().GetString("to")
+		if err != nil {
+			return errors.Wrap(err, "failed to get to flag")
+		}
+		return transferTodo(cmd, args[0], to, false)
+	},
+}
+
+// transferTodo copies the todo to the destination workspace and then, when moving, deletes it from the current
+// workspace. The destination is written first so that a failure never loses the todo. A move which fails after the
+// copy is written can be run again and will reuse the existing copy.
+func transferTodo(cmd *cobra.Command, id string, to string, move bool) error {
+	s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+	w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+	if w == "" {
+		return errors.New("current workspace not set")
+	}
+	toId, err := common.ResolveWorkspaceId(cmd.Context(), s, to)
+	if err != nil {
+		return err
+	} else if toId == w {
+		return errors.New("the destination workspace must be different to the current workspace")
+	}
+
+	ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+	if err != nil {
+		return err
+	}
+	defer ws.Close()
+	dest, err := common.OpenWritableWorkspace(cmd.Context(), s, toId)
+	if err != nil {
+		return errors.Wrap(err, "failed to open destination workspace")
+	}
+	defer dest.Close()
+
+	params := au.ImportTodoParams{SourceWorkspaceId: w}
+	if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+		return err
+	} else {
+		params.ImportedBy = v
+	}
+
+	if id, err = common.ResolveTodoId(cmd.Context(), ws, id); err != nil {
+		return err
+	}
+	todo, err := ws.GetTodo(cmd.Context(), id)
+	if err != nil {
+		return err
+	}
+	if move {
+		// deleting the todo here would reparent its children, which would then be left behind in this workspace
+		if children, err := ws.ListTodoChildren(cmd.Context(), id); err != nil {
+			return err
+		} else if len(children) > 0 {
+			return errors.Errorf("cannot move todo '%s' since it has %d children, move or reparent them first", id, len(children))
+		}
+	}
+	params.Todo = *todo
+	if params.Comments, err = ws.ListComments(cmd.Context(), id); err != nil {
+		return err
+	}
+	if params.TimeEntries, err = ws.ListTimeEntries(cmd.Context(), id); err != nil {
+		return err
+	}
+
+	// a move keeps the id of the todo, so a todo with the same id and origin is the result of an earlier attempt
+	var output *au.Todo
+	if move {
+		params.KeepId = true
+		if existing, err := dest.GetTodo(cmd.Context(), id); err == nil && existing.Annotations[au.AurelianOriginAnnotation] == au.FormatTodoOrigin(w, id) {
+			output = existing
+		}
+	}
+	if output == nil {
+		if output, err = dest.ImportTodo(cmd.Context(), params); err != nil {
+			return err
+		} else if err := dest.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush destination workspace to file")
+		}
+	}
+
+	if move {
+		if err := ws.DeleteTodo(cmd.Context(), id, au.DeleteTodoParams{DeletedBy: params.ImportedBy}); err != nil {
+			return errors.Wrapf(err, "todo was copied to '%s' as '%s' but could not be deleted here, run the move again to finish it", toId, output.Id)
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrapf(err, "todo was copied to '%s' as '%s' but could not be deleted here, run the move again to finish it", toId, output.Id)
+		}
+	}
+
+	encoder := yaml.NewEncoder(cmd.OutOrStdout())
+	encoder.SetIndent(2)
+	return encoder.Encode(preMarshalTodo(output))
+}
+
+func init() {
+	copyCommand.Flags().String("to", "", "The id or alias of the Workspace to copy the Todo to")
+	_ = copyCommand.MarkFlagRequired("to")
+}
@@ -18,6 +18,12 @@ import (
 	strictecho "github.com/oapi-codegen/runtime/strictmiddleware/echo"
 )
 
+// Attachment defines model for Attachment.
+type Attachment struct {
+	Hash        string `json:"hash"`
+	SizeInBytes int    `json:"size_in_bytes"`
+}
+
 // Problem An https://datatracker.ietf.org/doc/html/rfc9457 Problem response.
 type Problem struct {
 	// Detail A longer human-readable explanation specific to this occurrence of the Problem.
@@ -135,6 +141,15 @@ type ClientInterface interface {
 	// SynchroniseWorkspaceDocument request
 	SynchroniseWorkspaceDocument(ctx context.Context, id string, reqEditors ...RequestEditorFn) (*http.Response, error)
 
+	// ListWorkspaceAttachments request
+	ListWorkspaceAttachments(ctx context.Context, id string, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// DownloadWorkspaceAttachment request
+	DownloadWorkspaceAttachment(ctx context.Context, id string, hash string, reqEditors ...RequestEditorFn) (*http.Response, error)
+
+	// UploadWorkspaceAttachmentWithBody request with any body
+	UploadWorkspaceAttachmentWithBody(ctx context.Context, id string, hash string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error)
+
 	// DownloadWorkspaceDocument request
 	DownloadWorkspaceDocument(ctx context.Context, id string, reqEditors ...RequestEditorFn) (*http.Response, error)
 }
@@ -175,6 +190,42 @@ func (c *Client) SynchroniseWorkspaceDocument(ctx context.Context, id string, re
 	return c.Client.Do(req)
 }
 
+func (c *Client) ListWorkspaceAttachments(ctx context.Context, id string, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewListWorkspaceAttachmentsRequest(c.Server, id)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) DownloadWorkspaceAttachment(ctx context.Context, id string, hash string, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewDownloadWorkspaceAttachmentRequest(c.Server, id, hash)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
+func (c *Client) UploadWorkspaceAttachmentWithBody(ctx context.Context, id string, hash string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*http.Response, error) {
+	req, err := NewUploadWorkspaceAttachmentRequestWithBody(c.Server, id, hash, contentType, body)
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(ctx)
+	if err := c.applyEditors(ctx, req, reqEditors); err != nil {
+		return nil, err
+	}
+	return c.Client.Do(req)
+}
+
 func (c *Client) DownloadWorkspaceDocument(ctx context.Context, id string, reqEditors ...RequestEditorFn) (*http.Response, error) {
 	req, err := NewDownloadWorkspaceDocumentRequest(c.Server, id)
 	if err != nil {
@@ -282,6 +333,124 @@ func NewSynchroniseWorkspaceDocumentRequest(server string, id string) (*http.Req
 	return req, nil
 }
 
+// NewListWorkspaceAttachmentsRequest generates requests for ListWorkspaceAttachments
+func NewListWorkspaceAttachmentsRequest(server string, id string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "id", runtime.ParamLocationPath, id)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/workspaces/%s/attachments", pathParam0)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewDownloadWorkspaceAttachmentRequest generates requests for DownloadWorkspaceAttachment
+func NewDownloadWorkspaceAttachmentRequest(server string, id string, hash string) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "id", runtime.ParamLocationPath, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParamWithLocation("simple", false, "hash", runtime.ParamLocationPath, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/workspaces/%s/attachments/%s", pathParam0, pathParam1)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("GET", queryURL.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return req, nil
+}
+
+// NewUploadWorkspaceAttachmentRequestWithBody generates requests for UploadWorkspaceAttachment with any type of body
+func NewUploadWorkspaceAttachmentRequestWithBody(server string, id string, hash string, contentType string, body io.Reader) (*http.Request, error) {
+	var err error
+
+	var pathParam0 string
+
+	pathParam0, err = runtime.StyleParamWithLocation("simple", false, "id", runtime.ParamLocationPath, id)
+	if err != nil {
+		return nil, err
+	}
+
+	var pathParam1 string
+
+	pathParam1, err = runtime.StyleParamWithLocation("simple", false, "hash", runtime.ParamLocationPath, hash)
+	if err != nil {
+		return nil, err
+	}
+
+	serverURL, err := url.Parse(server)
+	if err != nil {
+		return nil, err
+	}
+
+	operationPath := fmt.Sprintf("/workspaces/%s/attachments/%s", pathParam0, pathParam1)
+	if operationPath[0] == '/' {
+		operationPath = "." + operationPath
+	}
+
+	queryURL, err := serverURL.Parse(operationPath)
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest("PUT", queryURL.String(), body)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Header.Add("Content-Type", contentType)
+
+	return req, nil
+}
+
 // NewDownloadWorkspaceDocumentRequest generates requests for DownloadWorkspaceDocument
 func NewDownloadWorkspaceDocumentRequest(server string, id string) (*http.Request, error) {
 	var err error
@@ -368,6 +537,15 @@ type ClientWithResponsesInterface interface {
 	// SynchroniseWorkspaceDocumentWithResponse request
 	SynchroniseWorkspaceDocumentWithResponse(ctx context.Context, id string, reqEditors ...RequestEditorFn) (*SynchroniseWorkspaceDocumentResponse, error)
 
+	// ListWorkspaceAttachmentsWithResponse request
+	ListWorkspaceAttachmentsWithResponse(ctx context.Context, id string, reqEditors ...RequestEditorFn) (*ListWorkspaceAttachmentsResponse, error)
+
+	// DownloadWorkspaceAttachmentWithResponse request
+	DownloadWorkspaceAttachmentWithResponse(ctx context.Context, id string, hash string, reqEditors ...RequestEditorFn) (*DownloadWorkspaceAttachmentResponse, error)
+
+	// UploadWorkspaceAttachmentWithBodyWithResponse request with any body
+	UploadWorkspaceAttachmentWithBodyWithResponse(ctx context.Context, id string, hash string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*UploadWorkspaceAttachmentResponse, error)
+
 	// DownloadWorkspaceDocumentWithResponse request
 	DownloadWorkspaceDocumentWithResponse(ctx context.Context, id string, reqEditors ...RequestEditorFn) (*DownloadWorkspaceDocumentResponse, error)
 }
@@ -446,6 +624,79 @@ func (r SynchroniseWorkspaceDocumentResponse) StatusCode() int {
 	return 0
 }
 
+type ListWorkspaceAttachmentsResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON200      *[]Attachment
+	JSON400      *StandardBadRequestProblem
+	JSON404      *StandardNotFoundProblem
+	JSONDefault  *StandardProblemResponse
+}
+
+// Status returns HTTPResponse.Status
+func (r ListWorkspaceAttachmentsResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r ListWorkspaceAttachmentsResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type DownloadWorkspaceAttachmentResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON400      *StandardBadRequestProblem
+	JSON404      *StandardNotFoundProblem
+	JSONDefault  *StandardProblemResponse
+}
+
+// Status returns HTTPResponse.Status
+func (r DownloadWorkspaceAttachmentResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r DownloadWorkspaceAttachmentResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
+type UploadWorkspaceAttachmentResponse struct {
+	Body         []byte
+	HTTPResponse *http.Response
+	JSON400      *StandardBadRequestProblem
+	JSON404      *StandardNotFoundProblem
+	JSONDefault  *StandardProblemResponse
+}
+
+// Status returns HTTPResponse.Status
+func (r UploadWorkspaceAttachmentResponse) Status() string {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.Status
+	}
+	return http.StatusText(0)
+}
+
+// StatusCode returns HTTPResponse.StatusCode
+func (r UploadWorkspaceAttachmentResponse) StatusCode() int {
+	if r.HTTPResponse != nil {
+		return r.HTTPResponse.StatusCode
+	}
+	return 0
+}
+
 type DownloadWorkspaceDocumentResponse struct {
 	Body         []byte
 	HTTPResponse *http.Response
@@ -497,6 +748,33 @@ func (c *ClientWithResponses) SynchroniseWorkspaceDocumentWithResponse(ctx conte
 	return ParseSynchroniseWorkspaceDocumentResponse(rsp)
 }
 
+// ListWorkspaceAttachmentsWithResponse request returning *ListWorkspaceAttachmentsResponse
+func (c *ClientWithResponses) ListWorkspaceAttachmentsWithResponse(ctx context.Context, id string, reqEditors ...RequestEditorFn) (*ListWorkspaceAttachmentsResponse, error) {
+	rsp, err := c.ListWorkspaceAttachments(ctx, id, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseListWorkspaceAttachmentsResponse(rsp)
+}
+
+// DownloadWorkspaceAttachmentWithResponse request returning *DownloadWorkspaceAttachmentResponse
+func (c *ClientWithResponses) DownloadWorkspaceAttachmentWithResponse(ctx context.Context, id string, hash string, reqEditors ...RequestEditorFn) (*DownloadWorkspaceAttachmentResponse, error) {
+	rsp, err := c.DownloadWorkspaceAttachment(ctx, id, hash, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseDownloadWorkspaceAttachmentResponse(rsp)
+}
+
+// UploadWorkspaceAttachmentWithBodyWithResponse request with arbitrary body returning *UploadWorkspaceAttachmentResponse
+func (c *ClientWithResponses) UploadWorkspaceAttachmentWithBodyWithResponse(ctx context.Context, id string, hash string, contentType string, body io.Reader, reqEditors ...RequestEditorFn) (*UploadWorkspaceAttachmentResponse, error) {
+	rsp, err := c.UploadWorkspaceAttachmentWithBody(ctx, id, hash, contentType, body, reqEditors...)
+	if err != nil {
+		return nil, err
+	}
+	return ParseUploadWorkspaceAttachmentResponse(rsp)
+}
+
 // DownloadWorkspaceDocumentWithResponse request returning *DownloadWorkspaceDocumentResponse
 func (c *ClientWithResponses) DownloadWorkspaceDocumentWithResponse(ctx context.Context, id string, reqEditors ...RequestEditorFn) (*DownloadWorkspaceDocumentResponse, error) {
 	rsp, err := c.DownloadWorkspaceDocument(ctx, id, reqEditors...)
@@ -640,20 +918,27 @@ func ParseSynchroniseWorkspaceDocumentResponse(rsp *http.Response) (*Synchronise
 	return response, nil
 }
 
-// ParseDownloadWorkspaceDocumentResponse parses an HTTP response from a DownloadWorkspaceDocumentWithResponse call
-func ParseDownloadWorkspaceDocumentResponse(rsp *http.Response) (*DownloadWorkspaceDocumentResponse, error) {
+// ParseListWorkspaceAttachmentsResponse parses an HTTP response from a ListWorkspaceAttachmentsWithResponse call
+func ParseListWorkspaceAttachmentsResponse(rsp *http.Response) (*ListWorkspaceAttachmentsResponse, error) {
 	bodyBytes, err := io.ReadAll(rsp.Body)
 	defer func() { _ = rsp.Body.Close() }()
 	if err != nil {
 		return nil, err
 	}
 
-	response := &DownloadWorkspaceDocumentResponse{
+	response := &ListWorkspaceAttachmentsResponse{
 		Body:         bodyBytes,
 		HTTPResponse: rsp,
 	}
 
 	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 200:
+		var dest []Attachment
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON200 = &dest
+
 	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 400:
 		var dest StandardBadRequestProblem
 		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
@@ -680,21 +965,150 @@ func ParseDownloadWorkspaceDocumentResponse(rsp *http.Response) (*DownloadWorksp
 	return response, nil
 }
 
-// ServerInterface represents all server handlers.
-type ServerInterface interface {
-
-	// (GET /workspaces)
-	ListWorkspace(ctx echo.Context) error
-
-	// (GET /workspaces/{id})
-	GetWorkspace(ctx echo.Context, id string) error
+// ParseDownloadWorkspaceAttachmentResponse parses an HTTP response from a DownloadWorkspaceAttachmentWithResponse call
+func ParseDownloadWorkspaceAttachmentResponse(rsp *http.Response) (*DownloadWorkspaceAttachmentResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
 
-	// (GET /workspaces/{id}/actions/sync)
-	SynchroniseWorkspaceDocument(ctx echo.Context, id string) error
+	response := &DownloadWorkspaceAttachmentResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
 
-	// (GET /workspaces/{id}/document)
-	DownloadWorkspaceDocument(ctx echo.Context, id string) error
-}
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 400:
+		var dest StandardBadRequestProblem
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON400 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 404:
+		var dest StandardNotFoundProblem
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON404 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest StandardProblemResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseUploadWorkspaceAttachmentResponse parses an HTTP response from a UploadWorkspaceAttachmentWithResponse call
+func ParseUploadWorkspaceAttachmentResponse(rsp *http.Response) (*UploadWorkspaceAttachmentResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &UploadWorkspaceAttachmentResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 400:
+		var dest StandardBadRequestProblem
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON400 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 404:
+		var dest StandardNotFoundProblem
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON404 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest StandardProblemResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
+
+// ParseDownloadWorkspaceDocumentResponse parses an HTTP response from a DownloadWorkspaceDocumentWithResponse call
+func ParseDownloadWorkspaceDocumentResponse(rsp *http.Response) (*DownloadWorkspaceDocumentResponse, error) {
+	bodyBytes, err := io.ReadAll(rsp.Body)
+	defer func() { _ = rsp.Body.Close() }()
+	if err != nil {
+		return nil, err
+	}
+
+	response := &DownloadWorkspaceDocumentResponse{
+		Body:         bodyBytes,
+		HTTPResponse: rsp,
+	}
+
+	switch {
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 400:
+		var dest StandardBadRequestProblem
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON400 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && rsp.StatusCode == 404:
+		var dest StandardNotFoundProblem
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSON404 = &dest
+
+	case strings.Contains(rsp.Header.Get("Content-Type"), "json") && true:
+		var dest StandardProblemResponse
+		if err := json.Unmarshal(bodyBytes, &dest); err != nil {
+			return nil, err
+		}
+		response.JSONDefault = &dest
+
+	}
+
+	return response, nil
+}
+
+// ServerInterface represents all server handlers.
+type ServerInterface interface {
+
+	// (GET /workspaces)
+	ListWorkspace(ctx echo.Context) error
+
+	// (GET /workspaces/{id})
+	GetWorkspace(ctx echo.Context, id string) error
+
+	// (GET /workspaces/{id}/actions/sync)
+	SynchroniseWorkspaceDocument(ctx echo.Context, id string) error
+
+	// (GET /workspaces/{id}/attachments)
+	ListWorkspaceAttachments(ctx echo.Context, id string) error
+
+	// (GET /workspaces/{id}/attachments/{hash})
+	DownloadWorkspaceAttachment(ctx echo.Context, id string, hash string) error
+
+	// (PUT /workspaces/{id}/attachments/{hash})
+	UploadWorkspaceAttachment(ctx echo.Context, id string, hash string) error
+
+	// (GET /workspaces/{id}/document)
+	DownloadWorkspaceDocument(ctx echo.Context, id string) error
+}
 
 // ServerInterfaceWrapper converts echo contexts to parameters.
 type ServerInterfaceWrapper struct {
@@ -742,6 +1156,70 @@ func (w *ServerInterfaceWrapper) SynchroniseWorkspaceDocument(ctx echo.Context)
 	return err
 }
 
+// ListWorkspaceAttachments converts echo context to params.
+func (w *ServerInterfaceWrapper) ListWorkspaceAttachments(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.ListWorkspaceAttachments(ctx, id)
+	return err
+}
+
+// DownloadWorkspaceAttachment converts echo context to params.
+func (w *ServerInterfaceWrapper) DownloadWorkspaceAttachment(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// ------------- Path parameter "hash" -------------
+	var hash string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "hash", ctx.Param("hash"), &hash, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter hash: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.DownloadWorkspaceAttachment(ctx, id, hash)
+	return err
+}
+
+// UploadWorkspaceAttachment converts echo context to params.
+func (w *ServerInterfaceWrapper) UploadWorkspaceAttachment(ctx echo.Context) error {
+	var err error
+	// ------------- Path parameter "id" -------------
+	var id string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "id", ctx.Param("id"), &id, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter id: %s", err))
+	}
+
+	// ------------- Path parameter "hash" -------------
+	var hash string
+
+	err = runtime.BindStyledParameterWithOptions("simple", "hash", ctx.Param("hash"), &hash, runtime.BindStyledParameterOptions{ParamLocation: runtime.ParamLocationPath, Explode: false, Required: true})
+	if err != nil {
+		return echo.NewHTTPError(http.StatusBadRequest, fmt.Sprintf("Invalid format for parameter hash: %s", err))
+	}
+
+	// Invoke the callback with all the unmarshaled arguments
+	err = w.Handler.UploadWorkspaceAttachment(ctx, id, hash)
+	return err
+}
+
 // DownloadWorkspaceDocument converts echo context to params.
 func (w *ServerInterfaceWrapper) DownloadWorkspaceDocument(ctx echo.Context) error {
 	var err error
@@ -789,6 +1267,9 @@ func RegisterHandlersWithBaseURL(router EchoRouter, si ServerInterface, baseURL
 	router.GET(baseURL+"/workspaces", wrapper.ListWorkspace)
 	router.GET(baseURL+"/workspaces/:id", wrapper.GetWorkspace)
 	router.GET(baseURL+"/workspaces/:id/actions/sync", wrapper.SynchroniseWorkspaceDocument)
+	router.GET(baseURL+"/workspaces/:id/attachments", wrapper.ListWorkspaceAttachments)
+	router.GET(baseURL+"/workspaces/:id/attachments/:hash", wrapper.DownloadWorkspaceAttachment)
+	router.PUT(baseURL+"/workspaces/:id/attachments/:hash", wrapper.UploadWorkspaceAttachment)
 	router.GET(baseURL+"/workspaces/:id/document", wrapper.DownloadWorkspaceDocument)
 
 }
@@ -961,6 +1442,171 @@ func (response SynchroniseWorkspaceDocumentdefaultJSONResponse) VisitSynchronise
 	return json.NewEncoder(w).Encode(response.Body)
 }
 
+type ListWorkspaceAttachmentsRequestObject struct {
+	Id string `json:"id"`
+}
+
+type ListWorkspaceAttachmentsResponseObject interface {
+	VisitListWorkspaceAttachmentsResponse(w http.ResponseWriter) error
+}
+
+type ListWorkspaceAttachments200JSONResponse []Attachment
+
+func (response ListWorkspaceAttachments200JSONResponse) VisitListWorkspaceAttachmentsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(200)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListWorkspaceAttachments400JSONResponse struct {
+	StandardBadRequestProblemJSONResponse
+}
+
+func (response ListWorkspaceAttachments400JSONResponse) VisitListWorkspaceAttachmentsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListWorkspaceAttachments404JSONResponse struct {
+	StandardNotFoundProblemJSONResponse
+}
+
+func (response ListWorkspaceAttachments404JSONResponse) VisitListWorkspaceAttachmentsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type ListWorkspaceAttachmentsdefaultJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response ListWorkspaceAttachmentsdefaultJSONResponse) VisitListWorkspaceAttachmentsResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type DownloadWorkspaceAttachmentRequestObject struct {
+	Id   string `json:"id"`
+	Hash string `json:"hash"`
+}
+
+type DownloadWorkspaceAttachmentResponseObject interface {
+	VisitDownloadWorkspaceAttachmentResponse(w http.ResponseWriter) error
+}
+
+type DownloadWorkspaceAttachment200ApplicationoctetStreamResponse struct {
+	Body          io.Reader
+	ContentLength int64
+}
+
+func (response DownloadWorkspaceAttachment200ApplicationoctetStreamResponse) VisitDownloadWorkspaceAttachmentResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/octet-stream")
+	if response.ContentLength != 0 {
+		w.Header().Set("Content-Length", fmt.Sprint(response.ContentLength))
+	}
+	w.WriteHeader(200)
+
+	if closer, ok := response.Body.(io.ReadCloser); ok {
+		defer closer.Close()
+	}
+	_, err := io.Copy(w, response.Body)
+	return err
+}
+
+type DownloadWorkspaceAttachment400JSONResponse struct {
+	StandardBadRequestProblemJSONResponse
+}
+
+func (response DownloadWorkspaceAttachment400JSONResponse) VisitDownloadWorkspaceAttachmentResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DownloadWorkspaceAttachment404JSONResponse struct {
+	StandardNotFoundProblemJSONResponse
+}
+
+func (response DownloadWorkspaceAttachment404JSONResponse) VisitDownloadWorkspaceAttachmentResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type DownloadWorkspaceAttachmentdefaultJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response DownloadWorkspaceAttachmentdefaultJSONResponse) VisitDownloadWorkspaceAttachmentResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
+type UploadWorkspaceAttachmentRequestObject struct {
+	Id   string `json:"id"`
+	Hash string `json:"hash"`
+	Body io.Reader
+}
+
+type UploadWorkspaceAttachmentResponseObject interface {
+	VisitUploadWorkspaceAttachmentResponse(w http.ResponseWriter) error
+}
+
+type UploadWorkspaceAttachment204Response struct {
+}
+
+func (response UploadWorkspaceAttachment204Response) VisitUploadWorkspaceAttachmentResponse(w http.ResponseWriter) error {
+	w.WriteHeader(204)
+	return nil
+}
+
+type UploadWorkspaceAttachment400JSONResponse struct {
+	StandardBadRequestProblemJSONResponse
+}
+
+func (response UploadWorkspaceAttachment400JSONResponse) VisitUploadWorkspaceAttachmentResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(400)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UploadWorkspaceAttachment404JSONResponse struct {
+	StandardNotFoundProblemJSONResponse
+}
+
+func (response UploadWorkspaceAttachment404JSONResponse) VisitUploadWorkspaceAttachmentResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(404)
+
+	return json.NewEncoder(w).Encode(response)
+}
+
+type UploadWorkspaceAttachmentdefaultJSONResponse struct {
+	Body       Problem
+	StatusCode int
+}
+
+func (response UploadWorkspaceAttachmentdefaultJSONResponse) VisitUploadWorkspaceAttachmentResponse(w http.ResponseWriter) error {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(response.StatusCode)
+
+	return json.NewEncoder(w).Encode(response.Body)
+}
+
 type DownloadWorkspaceDocumentRequestObject struct {
 	Id string `json:"id"`
 }
@@ -1034,6 +1680,15 @@ type StrictServerInterface interface {
 	// (GET /workspaces/{id}/actions/sync)
 	SynchroniseWorkspaceDocument(ctx context.Context, request SynchroniseWorkspaceDocumentRequestObject) (SynchroniseWorkspaceDocumentResponseObject, error)
 
+	// (GET /workspaces/{id}/attachments)
+	ListWorkspaceAttachments(ctx context.Context, request ListWorkspaceAttachmentsRequestObject) (ListWorkspaceAttachmentsResponseObject, error)
+
+	// (GET /workspaces/{id}/attachments/{hash})
+	DownloadWorkspaceAttachment(ctx context.Context, request DownloadWorkspaceAttachmentRequestObject) (DownloadWorkspaceAttachmentResponseObject, error)
+
+	// (PUT /workspaces/{id}/attachments/{hash})
+	UploadWorkspaceAttachment(ctx context.Context, request UploadWorkspaceAttachmentRequestObject) (UploadWorkspaceAttachmentResponseObject, error)
+
 	// (GET /workspaces/{id}/document)
 	DownloadWorkspaceDocument(ctx context.Context, request DownloadWorkspaceDocumentRequestObject) (DownloadWorkspaceDocumentResponseObject, error)
 }
@@ -1123,6 +1778,85 @@ func (sh *strictHandler) SynchroniseWorkspaceDocument(ctx echo.Context, id strin
 	return nil
 }
 
+// ListWorkspaceAttachments operation middleware
+func (sh *strictHandler) ListWorkspaceAttachments(ctx echo.Context, id string) error {
+	var request ListWorkspaceAttachmentsRequestObject
+
+	request.Id = id
+
+	handler := func(ctx echo.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.ListWorkspaceAttachments(ctx.Request().Context(), request.(ListWorkspaceAttachmentsRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "ListWorkspaceAttachments")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		return err
+	} else if validResponse, ok := response.(ListWorkspaceAttachmentsResponseObject); ok {
+		return validResponse.VisitListWorkspaceAttachmentsResponse(ctx.Response())
+	} else if response != nil {
+		return fmt.Errorf("unexpected response type: %T", response)
+	}
+	return nil
+}
+
+// DownloadWorkspaceAttachment operation middleware
+func (sh *strictHandler) DownloadWorkspaceAttachment(ctx echo.Context, id string, hash string) error {
+	var request DownloadWorkspaceAttachmentRequestObject
+
+	request.Id = id
+	request.Hash = hash
+
+	handler := func(ctx echo.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.DownloadWorkspaceAttachment(ctx.Request().Context(), request.(DownloadWorkspaceAttachmentRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "DownloadWorkspaceAttachment")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		return err
+	} else if validResponse, ok := response.(DownloadWorkspaceAttachmentResponseObject); ok {
+		return validResponse.VisitDownloadWorkspaceAttachmentResponse(ctx.Response())
+	} else if response != nil {
+		return fmt.Errorf("unexpected response type: %T", response)
+	}
+	return nil
+}
+
+// UploadWorkspaceAttachment operation middleware
+func (sh *strictHandler) UploadWorkspaceAttachment(ctx echo.Context, id string, hash string) error {
+	var request UploadWorkspaceAttachmentRequestObject
+
+	request.Id = id
+	request.Hash = hash
+
+	request.Body = ctx.Request().Body
+
+	handler := func(ctx echo.Context, request interface{}) (interface{}, error) {
+		return sh.ssi.UploadWorkspaceAttachment(ctx.Request().Context(), request.(UploadWorkspaceAttachmentRequestObject))
+	}
+	for _, middleware := range sh.middlewares {
+		handler = middleware(handler, "UploadWorkspaceAttachment")
+	}
+
+	response, err := handler(ctx, request)
+
+	if err != nil {
+		return err
+	} else if validResponse, ok := response.(UploadWorkspaceAttachmentResponseObject); ok {
+		return validResponse.VisitUploadWorkspaceAttachmentResponse(ctx.Response())
+	} else if response != nil {
+		return fmt.Errorf("unexpected response type: %T", response)
+	}
+	return nil
+}
+
 // DownloadWorkspaceDocument operation middleware
 func (sh *strictHandler) DownloadWorkspaceDocument(ctx echo.Context, id string) error {
 	var request DownloadWorkspaceDocumentRequestObject
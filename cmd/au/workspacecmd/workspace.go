@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"context"
 	"fmt"
+	"io"
 	"log/slog"
 	"net"
 	"net/http"
@@ -38,6 +39,7 @@ Workspaces are identified by a ULID and have a human-readable alias.
 
 type marshallableWorkspaceMetadata struct {
 	Id            string    `yaml:"id"`
+	ShortId       string    `yaml:"short_id,omitempty"`
 	Alias         string    `yaml:"alias"`
 	CreatedAt     time.Time `yaml:"created_at"`
 	SizeBytes     int64     `yaml:"size_bytes"`
@@ -62,7 +64,9 @@ var initCommand = &cobra.Command{
 	RunE: func(cmd *cobra.Command, args []string) error {
 		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
 		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
-		metadata, err := s.CreateWorkspace(cmd.Context(), au.CreateWorkspaceParams{Alias: cmd.Flags().Arg(0)})
+		// the author is optional here, but when it is known its key signs the change that creates the workspace
+		author, _ := cmd.Context().Value(common.CurrentAuthorContextKey).(string)
+		metadata, err := s.CreateWorkspace(cmd.Context(), au.CreateWorkspaceParams{Alias: cmd.Flags().Arg(0), CreatedBy: author})
 		if err != nil {
 			return err
 		}
@@ -88,9 +92,16 @@ var listCommand = &cobra.Command{
 			return err
 		}
 
+		ids := make([]string, len(metadataList))
+		for i, m := range metadataList {
+			ids[i] = m.Id
+		}
+		shortIds := au.ShortIds(ids)
+
 		preMarshalledWorkspaces := make([]*marshallableWorkspaceMetadata, len(metadataList))
 		for i, m := range metadataList {
 			preMarshalledWorkspaces[i] = preMarshalWorkspace(&m)
+			preMarshalledWorkspaces[i].ShortId = shortIds[m.Id]
 		}
 
 		encoder := yaml.NewEncoder(cmd.OutOrStdout())
@@ -120,16 +131,20 @@ var getCommand = &cobra.Command{
 }
 
 var useCommand = &cobra.Command{
-	Use:        "use <uid>",
-	Short:      "Set the current Workspace",
+	Use:        "use <uid|alias>",
+	Short:      "Set the current Workspace by id or alias",
 	Args:       cobra.ExactArgs(1),
 	ArgAliases: []string{"uid"},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
-		if metadata, err := s.GetWorkspace(cmd.Context(), cmd.Flags().Arg(0)); err != nil {
+		id, err := common.ResolveWorkspaceId(cmd.Context(), s, cmd.Flags().Arg(0))
+		if err != nil {
+			return err
+		}
+		if metadata, err := s.GetWorkspace(cmd.Context(), id); err != nil {
 			return err
 		} else {
-			if err := s.SetCurrentWorkspace(cmd.Context(), cmd.Flags().Arg(0)); err != nil {
+			if err := s.SetCurrentWorkspace(cmd.Context(), id); err != nil {
 				return err
 			}
 			encoder := yaml.NewEncoder(cmd.OutOrStdout())
@@ -146,17 +161,21 @@ var deleteCommand = &cobra.Command{
 	ArgAliases: []string{"uid"},
 	RunE: func(cmd *cobra.Command, args []string) error {
 		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
-		if _, err := s.GetWorkspace(cmd.Context(), cmd.Flags().Arg(0)); err != nil {
+		uid, err := common.ResolveWorkspaceId(cmd.Context(), s, cmd.Flags().Arg(0))
+		if err != nil {
+			return err
+		}
+		if _, err := s.GetWorkspace(cmd.Context(), uid); err != nil {
 			return err
 		} else {
 			if id, err := s.GetCurrentWorkspace(cmd.Context()); err != nil {
 				return err
-			} else if id == cmd.Flags().Arg(0) {
+			} else if id == uid {
 				if err := s.SetCurrentWorkspace(cmd.Context(), ""); err != nil {
 					return err
 				}
 			}
-			if err := s.DeleteWorkspace(cmd.Context(), cmd.Flags().Arg(0)); err != nil {
+			if err := s.DeleteWorkspace(cmd.Context(), uid); err != nil {
 				return err
 			}
 		}
@@ -165,8 +184,15 @@ var deleteCommand = &cobra.Command{
 }
 
 var syncServerCommand = &cobra.Command{
-	Use:        "serve <localhost:80>",
-	Short:      "Start a local webserver serving all Workspaces",
+	Use:   "serve <localhost:80>",
+	Short: "Start a local webserver serving all Workspaces",
+	Long: strings.TrimSpace(`
+Start a local webserver serving all Workspaces.
+
+Changes synced by clients are rejected when the role of their author does not allow them: readers cannot change the Workspace, commenters can only add and edit Comments, contributors cannot delete Todos, Comments, checklist items, time entries, or settings, and only admins can change the members. Changes that leave every value as it was are accepted from any member, so readers can still sync. The author of a change is the one claimed in its message, so every change must be signed by a trusted key of its author whenever the Workspace has members.
+
+While a Workspace has no members, its creator is its only admin if the change that created it is signed by a trusted key of the creator. Otherwise all changes are accepted, unless --require-signatures is set to reject unsigned changes to these Workspaces too.
+`),
 	Args:       cobra.ExactArgs(1),
 	ArgAliases: []string{"address"},
 	RunE: func(cmd *cobra.Command, args []string) error {
@@ -175,7 +201,13 @@ var syncServerCommand = &cobra.Command{
 		server.HideBanner = true
 		server.HidePort = true
 		server.Use(embedEchoContextMiddleware)
-		RegisterHandlers(server, NewStrictHandler(&workspaceServerImpl{Storage: s}, []StrictMiddlewareFunc{}))
+		impl := &workspaceServerImpl{Storage: s}
+		if v, err := cmd.Flags().GetBool("require-signatures"); err != nil {
+			return errors.Wrap(err, "failed to get require-signatures flag")
+		} else {
+			impl.RequireSignatures = v
+		}
+		RegisterHandlers(server, NewStrictHandler(impl, []StrictMiddlewareFunc{}))
 		go func() {
 			<-cmd.Context().Done()
 			_ = server.Shutdown(cmd.Context())
@@ -208,7 +240,7 @@ var syncClientCommand = &cobra.Command{
 		if w == "" {
 			return errors.New("current workspace not set")
 		}
-		ws, err := s.OpenWorkspace(cmd.Context(), w, true)
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
 		if err != nil {
 			return err
 		}
@@ -300,7 +332,170 @@ var authorSetCommand = &cobra.Command{
 	},
 }
 
+var revertCommand = &cobra.Command{
+	Use:        "revert <change-hash>",
+	Short:      "Revert the Todo and Comment fields modified by a change",
+	Long:       "Revert applies a new change which restores the fields modified by the given change to their previous values. Fields that have been modified again since are left alone. The change hash may be a unique prefix.",
+	Args:       cobra.ExactArgs(1),
+	ArgAliases: []string{"change-hash"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		var params au.RevertChangeParams
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.RevertedBy = v
+		}
+
+		if entry, err := ws.RevertChange(cmd.Context(), cmd.Flags().Arg(0), params); err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		} else {
+			encoder := yaml.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent(2)
+			return encoder.Encode(common.PreMarshalHistory([]au.HistoryEntry{*entry}))
+		}
+	},
+}
+
+var UndoCommand = &cobra.Command{
+	Use:     "undo",
+	GroupID: "core",
+	Short:   "Revert the most recent change made by the current author in the current Workspace",
+	Long:    "Undo reverts the most recent change made by the author which has not already been undone. Running it again will undo the change before that. Reverts made with 'au workspace revert' are regular changes, so undo right after a revert undoes the revert.",
+	Args:    cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenWritableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+
+		var params au.RevertChangeParams
+		if v, err := common.CurrentWritingAuthor(cmd, ws); err != nil {
+			return err
+		} else {
+			params.RevertedBy = v
+		}
+
+		target := params.RevertedBy
+		if v, err := cmd.Flags().GetString("author"); err != nil {
+			return errors.Wrap(err, "failed to get author flag")
+		} else if v != "" && v != "me" {
+			target = v
+		}
+
+		hash, err := ws.LatestChangeByAuthor(cmd.Context(), target)
+		if err != nil {
+			return err
+		}
+		params.Undo = true
+		if entry, err := ws.RevertChange(cmd.Context(), hash, params); err != nil {
+			return err
+		} else if err := ws.Flush(); err != nil {
+			return errors.Wrap(err, "failed to flush to file")
+		} else {
+			encoder := yaml.NewEncoder(cmd.OutOrStdout())
+			encoder.SetIndent(2)
+			return encoder.Encode(common.PreMarshalHistory([]au.HistoryEntry{*entry}))
+		}
+	},
+}
+
+type marshallableVerification struct {
+	Hash      string    `yaml:"hash"`
+	At        time.Time `yaml:"at"`
+	Author    string    `yaml:"author,omitempty"`
+	Message   string    `yaml:"message,omitempty"`
+	Status    string    `yaml:"status"`
+	PublicKey string    `yaml:"public_key,omitempty"`
+}
+
+var verifyCommand = &cobra.Command{
+	Use:          "verify",
+	SilenceUsage: true,
+	Short:        "Verify the signatures of the changes in the current Workspace",
+	Long: strings.TrimSpace(`
+Verify checks every change in the current Workspace against the public keys trusted in the storage directory and lists the changes that are unsigned, signed by a key that is not trusted for the author they claim, or whose signature does not match the change. The command exits with code 1 when any change is listed.
+
+The change that created the Workspace is signed when it was created by an author with a key. Otherwise it has the status 'genesis' and is accepted, since it only holds the initial empty containers.
+
+Changes are signed when their author has a key, see 'au key generate'.
+`),
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		s := cmd.Context().Value(common.StorageContextKey).(au.StorageProvider)
+		w := cmd.Context().Value(common.CurrentWorkspaceIdContextKey).(string)
+		if w == "" {
+			return errors.New("current workspace not set")
+		}
+		ws, err := common.OpenReadableWorkspace(cmd.Context(), s, w)
+		if err != nil {
+			return err
+		}
+		defer ws.Close()
+		dws, ok := ws.(au.DocProvider)
+		if !ok {
+			return errors.New("no doc available")
+		}
+
+		keys, err := s.ListPublicKeys(cmd.Context())
+		if err != nil {
+			return err
+		}
+		verifications, err := au.VerifyChanges(dws.GetDoc(), keys)
+		if err != nil {
+			return err
+		}
+		all, err := cmd.Flags().GetBool("all")
+		if err != nil {
+			return errors.Wrap(err, "failed to get all flag")
+		}
+		output := make([]marshallableVerification, 0)
+		problems := 0
+		for _, v := range verifications {
+			if v.Status != au.SignatureStatusValid && v.Status != au.SignatureStatusGenesis {
+				problems++
+			} else if !all {
+				continue
+			}
+			output = append(output, marshallableVerification{
+				Hash: v.Hash, At: v.At, Author: v.Author, Message: v.Message, Status: v.Status, PublicKey: v.PublicKey,
+			})
+		}
+
+		encoder := yaml.NewEncoder(cmd.OutOrStdout())
+		encoder.SetIndent(2)
+		if err := encoder.Encode(output); err != nil {
+			return err
+		} else if problems > 0 {
+			return &common.ExitWithCode{Code: 1}
+		}
+		return nil
+	},
+}
+
 func init() {
+	syncServerCommand.Flags().Bool("require-signatures", false, "Reject synced changes that are not signed by a trusted key of the author they claim, even in Workspaces without members")
+	verifyCommand.Flags().Bool("all", false, "List the valid changes too")
+	UndoCommand.Flags().String("author", "me", "Undo the most recent change by this 'Name <email>' author, or 'me' for the current author")
+
 	Command.AddCommand(
 		initCommand,
 		getCommand,
@@ -311,6 +506,8 @@ func init() {
 		syncClientCommand,
 		syncImportCommand,
 		authorSetCommand,
+		revertCommand,
+		verifyCommand,
 	)
 }
 
@@ -323,6 +520,8 @@ func embedEchoContextMiddleware(next echo.HandlerFunc) echo.HandlerFunc {
 
 type workspaceServerImpl struct {
 	Storage au.StorageProvider
+	// RequireSignatures rejects synced changes that are not signed by a trusted key of their author.
+	RequireSignatures bool
 }
 
 func (w *workspaceServerImpl) ListWorkspace(ctx context.Context, request ListWorkspaceRequestObject) (ListWorkspaceResponseObject, error) {
@@ -369,11 +568,24 @@ func (w *workspaceServerImpl) SynchroniseWorkspaceDocument(ctx context.Context,
 		}
 		return nil, err
 	} else {
+		defer ws.Close()
 		dws, ok := ws.(au.DocProvider)
 		if !ok {
 			return nil, errors.New("not a doc provider")
 		}
 
+		// the keys are loaded for every sync so that newly trusted keys apply without a restart
+		keys, err := w.Storage.ListPublicKeys(ctx)
+		if err != nil {
+			return nil, err
+		}
+		filters := make([]auws.ChangeFilter, 0)
+		if w.RequireSignatures {
+			filters = append(filters, au.RequireSignatures(keys))
+		}
+		// the roles of the members are read from the workspace as it is before the incoming changes are applied
+		filters = append(filters, au.AuthorizeChanges(dws.GetDoc(), keys))
+
 		c := ctx.Value("echo").(echo.Context)
 		upgrader := websocket.Upgrader{
 			ReadBufferSize:  1024,
@@ -384,8 +596,13 @@ func (w *workspaceServerImpl) SynchroniseWorkspaceDocument(ctx context.Context,
 			return nil, err
 		}
 		defer conn.Close()
-		if err := auws.Sync(ctx, slog.Default(), conn, dws.GetDoc(), false); err != nil {
-			return nil, errors.Wrap(err, "failed to sync")
+		// the connection is hijacked at this point, so errors can only be logged rather than returned to the client
+		if err := auws.Sync(ctx, slog.Default(), conn, dws.GetDoc(), false, filters...); err != nil {
+			slog.Warn("failed to sync", "ws", request.Id, "err", err)
+		}
+		// changes accepted before a failure or rejection are kept
+		if err := ws.Flush(); err != nil {
+			slog.Error("failed to flush to file", "ws", request.Id, "err", err)
 		}
 		return nil, nil
 	}
@@ -411,3 +628,110 @@ func (w *workspaceServerImpl) DownloadWorkspaceDocument(ctx context.Context, req
 		}, nil
 	}
 }
+
+// referencedAttachment returns the attachment with the given hash if it is referenced by a comment in the workspace.
+// Attachments are only served and accepted for workspaces that reference them.
+func (w *workspaceServerImpl) referencedAttachment(ctx context.Context, id, hash string) (*au.Attachment, error) {
+	ws, err := w.Storage.OpenWorkspace(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	defer ws.Close()
+	attachments, err := ws.ListAttachments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, a := range attachments {
+		if a.Hash == hash {
+			return &a, nil
+		}
+	}
+	return nil, errors.Wrapf(os.ErrNotExist, "attachment '%s' is not referenced by workspace '%s'", hash, id)
+}
+
+func (w *workspaceServerImpl) ListWorkspaceAttachments(ctx context.Context, request ListWorkspaceAttachmentsRequestObject) (ListWorkspaceAttachmentsResponseObject, error) {
+	if ws, err := w.Storage.OpenWorkspace(ctx, request.Id, false); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return ListWorkspaceAttachments404JSONResponse{StandardNotFoundProblemJSONResponse{
+				Status: http.StatusNotFound,
+			}}, nil
+		}
+		return nil, err
+	} else {
+		defer ws.Close()
+		attachments, err := ws.ListAttachments(ctx)
+		if err != nil {
+			return nil, err
+		}
+		output := make([]Attachment, 0, len(attachments))
+		for _, a := range attachments {
+			if size, err := w.Storage.StatBlob(ctx, a.Hash); err == nil {
+				output = append(output, Attachment{Hash: a.Hash, SizeInBytes: int(size)})
+			} else if !errors.Is(err, os.ErrNotExist) {
+				return nil, err
+			}
+		}
+		return ListWorkspaceAttachments200JSONResponse(output), nil
+	}
+}
+
+func (w *workspaceServerImpl) DownloadWorkspaceAttachment(ctx context.Context, request DownloadWorkspaceAttachmentRequestObject) (DownloadWorkspaceAttachmentResponseObject, error) {
+	if err := au.ValidateAttachmentHash(request.Hash); err != nil {
+		return DownloadWorkspaceAttachment400JSONResponse{StandardBadRequestProblemJSONResponse{
+			Status: http.StatusBadRequest, Title: "invalid hash", Detail: err.Error(),
+		}}, nil
+	}
+	if _, err := w.referencedAttachment(ctx, request.Id, request.Hash); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return DownloadWorkspaceAttachment404JSONResponse{StandardNotFoundProblemJSONResponse{
+				Status: http.StatusNotFound,
+			}}, nil
+		}
+		return nil, err
+	}
+	data, err := w.Storage.GetBlob(ctx, request.Hash)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return DownloadWorkspaceAttachment404JSONResponse{StandardNotFoundProblemJSONResponse{
+				Status: http.StatusNotFound, Title: "attachment not uploaded", Detail: "the attachment has not been uploaded to the server yet",
+			}}, nil
+		}
+		return nil, err
+	}
+	return DownloadWorkspaceAttachment200ApplicationoctetStreamResponse{
+		Body:          bytes.NewReader(data),
+		ContentLength: int64(len(data)),
+	}, nil
+}
+
+func (w *workspaceServerImpl) UploadWorkspaceAttachment(ctx context.Context, request UploadWorkspaceAttachmentRequestObject) (UploadWorkspaceAttachmentResponseObject, error) {
+	if err := au.ValidateAttachmentHash(request.Hash); err != nil {
+		return UploadWorkspaceAttachment400JSONResponse{StandardBadRequestProblemJSONResponse{
+			Status: http.StatusBadRequest, Title: "invalid hash", Detail: err.Error(),
+		}}, nil
+	}
+	if _, err := w.referencedAttachment(ctx, request.Id, request.Hash); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return UploadWorkspaceAttachment404JSONResponse{StandardNotFoundProblemJSONResponse{
+				Status: http.StatusNotFound,
+			}}, nil
+		}
+		return nil, err
+	}
+	data, err := io.ReadAll(io.LimitReader(request.Body, au.MaximumAttachmentSize+1))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read body")
+	} else if len(data) > au.MaximumAttachmentSize {
+		return UploadWorkspaceAttachment400JSONResponse{StandardBadRequestProblemJSONResponse{
+			Status: http.StatusBadRequest, Title: "attachment too large", Detail: fmt.Sprintf("attachment should be at most %d bytes", au.MaximumAttachmentSize),
+		}}, nil
+	} else if h := au.HashBlob(data); h != request.Hash {
+		return UploadWorkspaceAttachment400JSONResponse{StandardBadRequestProblemJSONResponse{
+			Status: http.StatusBadRequest, Title: "hash mismatch", Detail: fmt.Sprintf("content has hash '%s'", h),
+		}}, nil
+	}
+	if _, err := w.Storage.PutBlob(ctx, data); err != nil {
+		return nil, err
+	}
+	return UploadWorkspaceAttachment204Response{}, nil
+}
@@ -6,18 +6,22 @@ import (
 	"log/slog"
 	"net"
 	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/automerge/automerge-go"
 	"github.com/gorilla/websocket"
+	"github.com/labstack/echo/v4"
 	"github.com/spf13/cobra"
 	"github.com/stretchr/testify/assert"
 	"gopkg.in/yaml.v3"
 
 	"github.com/aurelian-one/au/cmd/au/common"
+	"github.com/aurelian-one/au/internal"
 	"github.com/aurelian-one/au/pkg/au"
 	"github.com/aurelian-one/au/pkg/auws"
 )
@@ -85,6 +89,13 @@ func TestCli_create_and_delete(t *testing.T) {
 	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
 	assert.Len(t, outSlice, 1)
 	assert.Equal(t, workspaceId, outSlice[0].(map[string]interface{})["id"])
+	assert.Equal(t, workspaceId[len(workspaceId)-4:], outSlice[0].(map[string]interface{})["short_id"])
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"use", "Example Workspace"}))
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &out))
+	assert.Equal(t, workspaceId, out.Id)
+	assert.EqualError(t, executeAndResetCommand(ctx, Command, []string{"use", "Unknown"}), "workspace 'Unknown' does not exist")
 
 	buff.Reset()
 	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"set-author", "Example <name@email>"}))
@@ -110,6 +121,19 @@ func TestCli_serve(t *testing.T) {
 	assert.NoError(t, err)
 	workspaceId := wsMeta.Id
 
+	attachmentData := []byte("some binary content")
+	ws, err := s.OpenWorkspace(context.Background(), workspaceId, true)
+	assert.NoError(t, err)
+	todo, err := ws.CreateTodo(context.Background(), au.CreateTodoParams{Title: "Todo", CreatedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+	_, err = ws.CreateComment(context.Background(), todo.Id, au.CreateCommentParams{
+		MediaType: au.DefaultCommentMediaType, CreatedBy: "Example <email@me.com>",
+		Attachment: &au.Attachment{Hash: au.HashBlob(attachmentData), Filename: "data.bin", MediaType: "application/octet-stream", Size: int64(len(attachmentData))},
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, ws.Flush())
+	assert.NoError(t, ws.Close())
+
 	ctx := context.Background()
 	ctx = context.WithValue(ctx, common.StorageContextKey, s)
 	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
@@ -177,6 +201,327 @@ func TestCli_serve(t *testing.T) {
 			defer conn.Close()
 			assert.NoError(t, auws.Sync(ctx, slog.Default(), conn, doc, true))
 		})
+
+		t.Run("synced changes are saved and the workspace is released", func(t *testing.T) {
+			_, err := au.NewInMemoryWorkspaceProvider(doc).CreateTodo(ctx, au.CreateTodoParams{Title: "Other", CreatedBy: "Example <email@me.com>"})
+			assert.NoError(t, err)
+			req, _ := NewSynchroniseWorkspaceDocumentRequest("ws://"+address, workspaceId)
+
+			// the previous sync releases the workspace once the server has seen the client disconnect
+			var conn *websocket.Conn
+			if !assert.Eventually(t, func() bool {
+				conn, _, err = websocket.DefaultDialer.Dial(req.URL.String(), nil)
+				return err == nil
+			}, time.Second*10, time.Millisecond*100) {
+				return
+			}
+			defer conn.Close()
+			assert.NoError(t, auws.Sync(ctx, slog.Default(), conn, doc, true))
+
+			// the server saves the workspace after the client has disconnected
+			assert.Eventually(t, func() bool {
+				ws, err := s.OpenWorkspace(context.Background(), workspaceId, false)
+				assert.NoError(t, err)
+				defer ws.Close()
+				todos, _ := ws.ListTodos(context.Background())
+				return len(todos) == 2
+			}, time.Second*10, time.Millisecond*100)
+		})
+	})
+
+	t.Run("can upload and download attachments", func(t *testing.T) {
+		data := attachmentData
+		hash := au.HashBlob(data)
+
+		resp, err := c.UploadWorkspaceAttachmentWithBodyWithResponse(ctx, workspaceId, au.HashBlob([]byte("other")), "application/octet-stream", bytes.NewReader([]byte("other")))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode())
+
+		listResp, err := c.ListWorkspaceAttachmentsWithResponse(ctx, workspaceId)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, listResp.StatusCode())
+		assert.Empty(t, *listResp.JSON200)
+
+		resp, err = c.UploadWorkspaceAttachmentWithBodyWithResponse(ctx, workspaceId, hash, "application/octet-stream", bytes.NewReader([]byte("other")))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode())
+		resp, err = c.UploadWorkspaceAttachmentWithBodyWithResponse(ctx, workspaceId, hash, "application/octet-stream", bytes.NewReader(data))
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNoContent, resp.StatusCode())
+
+		listResp, err = c.ListWorkspaceAttachmentsWithResponse(ctx, workspaceId)
+		assert.NoError(t, err)
+		assert.Equal(t, []Attachment{{Hash: hash, SizeInBytes: len(data)}}, *listResp.JSON200)
+
+		downloadResp, err := c.DownloadWorkspaceAttachmentWithResponse(ctx, workspaceId, hash)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusOK, downloadResp.StatusCode())
+		assert.Equal(t, data, downloadResp.Body)
 	})
 
 }
+
+func TestCli_revert_and_undo(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+	defer os.RemoveAll(td)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+
+	ws, _ := s.OpenWorkspace(context.Background(), wsMeta.Id, true)
+	todo, err := ws.CreateTodo(context.Background(), au.CreateTodoParams{Title: "Todo", Description: "Important", CreatedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+	_, err = ws.EditTodo(context.Background(), todo.Id, au.EditTodoParams{Description: internal.Ref(""), UpdatedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+	editHash := ws.(au.DocProvider).GetDoc().Heads()[0].String()
+	assert.NoError(t, ws.Flush())
+	assert.NoError(t, ws.Close())
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+	ctx = context.WithValue(ctx, common.CurrentAuthorContextKey, "Example <email@me.com>")
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(buff)
+	UndoCommand.SetOut(buff)
+	UndoCommand.SetErr(buff)
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"revert", editHash[:10]}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	if assert.Len(t, outSlice, 1) {
+		assert.Equal(t, "Example <email@me.com> reverted change "+editHash, outSlice[0]["message"])
+	}
+
+	ws, _ = s.OpenWorkspace(context.Background(), wsMeta.Id, false)
+	todo, _ = ws.GetTodo(context.Background(), todo.Id)
+	assert.Equal(t, "Important", todo.Description)
+	_ = ws.Close()
+
+	// undo right after the revert undoes the revert, and then continues with the changes before it
+	descriptionAfterUndo := func() string {
+		buff.Reset()
+		assert.NoError(t, executeAndResetCommand(ctx, UndoCommand, []string{}))
+		ws, _ := s.OpenWorkspace(context.Background(), wsMeta.Id, false)
+		defer ws.Close()
+		todo, _ := ws.GetTodo(context.Background(), todo.Id)
+		return todo.Description
+	}
+	assert.Equal(t, "", descriptionAfterUndo())
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	if assert.Len(t, outSlice, 1) {
+		assert.Contains(t, outSlice[0]["message"], "Example <email@me.com> undid change ")
+	}
+	assert.Equal(t, "Important", descriptionAfterUndo())
+
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, UndoCommand, []string{}))
+	ws, _ = s.OpenWorkspace(context.Background(), wsMeta.Id, false)
+	_, err = ws.GetTodo(context.Background(), todo.Id)
+	assert.EqualError(t, err, "failed to get todo: todo with id '"+todo.Id+"' does not exist")
+	_ = ws.Close()
+}
+
+func TestCli_verify_and_require_signatures(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+	defer os.RemoveAll(td)
+
+	s, _ := au.NewDirectoryStorage(td)
+	wsMeta, err := s.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example"})
+	assert.NoError(t, err)
+	_, err = s.GenerateSigningKey(context.Background(), "Alice <alice@me.com>")
+	assert.NoError(t, err)
+	ws, _ := s.OpenWorkspace(context.Background(), wsMeta.Id, true)
+	_, err = ws.CreateTodo(context.Background(), au.CreateTodoParams{Title: "Todo", CreatedBy: "Alice <alice@me.com>"})
+	assert.NoError(t, err)
+	assert.NoError(t, ws.Flush())
+	assert.NoError(t, ws.Close())
+
+	ctx := context.Background()
+	ctx = context.WithValue(ctx, common.StorageContextKey, s)
+	ctx = context.WithValue(ctx, common.CurrentWorkspaceIdContextKey, wsMeta.Id)
+
+	buff := new(bytes.Buffer)
+	Command.SetOut(buff)
+	Command.SetErr(new(bytes.Buffer))
+
+	// the change that created the workspace has no author, so it is accepted as the genesis and not listed
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"verify", "--all=false"}))
+	var outSlice []map[string]interface{}
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	assert.Len(t, outSlice, 0)
+	buff.Reset()
+	assert.NoError(t, executeAndResetCommand(ctx, Command, []string{"verify", "--all"}))
+	assert.NoError(t, yaml.Unmarshal(buff.Bytes(), &outSlice))
+	if assert.Len(t, outSlice, 2) {
+		assert.Equal(t, au.SignatureStatusGenesis, outSlice[0]["status"])
+		assert.Equal(t, "Alice <alice@me.com>", outSlice[1]["author"])
+		assert.Equal(t, au.SignatureStatusValid, outSlice[1]["status"])
+	}
+
+	server := echo.New()
+	server.Use(embedEchoContextMiddleware)
+	RegisterHandlers(server, NewStrictHandler(&workspaceServerImpl{Storage: s, RequireSignatures: true}, []StrictMiddlewareFunc{}))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	// the client has its own storage and key, which the server has to trust
+	clientDir, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+	defer os.RemoveAll(clientDir)
+	clientStorage, _ := au.NewDirectoryStorage(clientDir)
+	bobKey, err := clientStorage.GenerateSigningKey(context.Background(), "Bob <bob@me.com>")
+	assert.NoError(t, err)
+	raw, err := os.ReadFile(filepath.Join(td, wsMeta.Id+au.Suffix))
+	assert.NoError(t, err)
+	_, err = clientStorage.ImportWorkspace(context.Background(), wsMeta.Id, raw)
+	assert.NoError(t, err)
+	clientWs, err := clientStorage.OpenWorkspace(context.Background(), wsMeta.Id, true)
+	assert.NoError(t, err)
+	defer clientWs.Close()
+	_, err = clientWs.CreateTodo(context.Background(), au.CreateTodoParams{Title: "Other", CreatedBy: "Bob <bob@me.com>"})
+	assert.NoError(t, err)
+
+	var rejected *auws.RejectedError
+	if err := syncTestClient(t, httpServer, s, wsMeta.Id, clientWs); assert.ErrorAs(t, err, &rejected) {
+		assert.Regexp(t, `^change [0-9a-f]{8} is untrusted$`, rejected.Reason)
+	}
+
+	// the same changes are accepted once the server trusts the key
+	assert.NoError(t, s.TrustPublicKey(context.Background(), "Bob <bob@me.com>", bobKey))
+	assert.NoError(t, syncTestClient(t, httpServer, s, wsMeta.Id, clientWs))
+	serverWs, err := s.OpenWorkspace(context.Background(), wsMeta.Id, false)
+	assert.NoError(t, err)
+	defer serverWs.Close()
+	todos, err := serverWs.ListTodos(context.Background())
+	assert.NoError(t, err)
+	assert.Len(t, todos, 2)
+}
+
+// syncTestClient syncs the client workspace with the server once the server has released the workspace from any
+// previous sync, and waits for the server to flush and release it again before returning.
+func syncTestClient(t *testing.T, httpServer *httptest.Server, s au.StorageProvider, id string, clientWs au.WorkspaceProvider) error {
+	waitForRelease(t, s, id)
+	defer waitForRelease(t, s, id)
+	req, _ := NewSynchroniseWorkspaceDocumentRequest("ws://"+httpServer.Listener.Addr().String(), id)
+	conn, _, err := websocket.DefaultDialer.Dial(req.URL.String(), nil)
+	if !assert.NoError(t, err) {
+		return err
+	}
+	defer conn.Close()
+	return auws.Sync(context.Background(), slog.Default(), conn, clientWs.(au.DocProvider).GetDoc(), true)
+}
+
+// waitForRelease waits until the workspace can be opened for writing, which is once the server has flushed and
+// closed it at the end of a sync.
+func waitForRelease(t *testing.T, s au.StorageProvider, id string) {
+	assert.Eventually(t, func() bool {
+		ws, err := s.OpenWorkspace(context.Background(), id, true)
+		if err != nil {
+			return false
+		}
+		return ws.Close() == nil
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestCli_serve_authorization(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+	defer os.RemoveAll(td)
+	s, _ := au.NewDirectoryStorage(td)
+	admin, reader := "Alice <alice@me.com>", "Erin <erin@me.com>"
+
+	// each member has their own storage and key, which the server trusts
+	newClient := func(author string) (au.StorageProvider, string) {
+		dir, err := os.MkdirTemp(os.TempDir(), "au")
+		assert.NoError(t, err)
+		t.Cleanup(func() {
+			_ = os.RemoveAll(dir)
+		})
+		cs, _ := au.NewDirectoryStorage(dir)
+		key, err := cs.GenerateSigningKey(context.Background(), author)
+		assert.NoError(t, err)
+		assert.NoError(t, s.TrustPublicKey(context.Background(), author, key))
+		return cs, dir
+	}
+	adminStorage, adminDir := newClient(admin)
+	readerStorage, _ := newClient(reader)
+
+	// the workspace is created by the admin and starts without members
+	wsMeta, err := adminStorage.CreateWorkspace(context.Background(), au.CreateWorkspaceParams{Alias: "Example", CreatedBy: admin})
+	assert.NoError(t, err)
+	raw, err := os.ReadFile(filepath.Join(adminDir, wsMeta.Id+au.Suffix))
+	assert.NoError(t, err)
+	_, err = s.ImportWorkspace(context.Background(), wsMeta.Id, raw)
+	assert.NoError(t, err)
+	_, err = readerStorage.ImportWorkspace(context.Background(), wsMeta.Id, raw)
+	assert.NoError(t, err)
+
+	server := echo.New()
+	server.Use(embedEchoContextMiddleware)
+	RegisterHandlers(server, NewStrictHandler(&workspaceServerImpl{Storage: s}, []StrictMiddlewareFunc{}))
+	httpServer := httptest.NewServer(server)
+	defer httpServer.Close()
+
+	serverTodos := func() []au.Todo {
+		ws, err := s.OpenWorkspace(context.Background(), wsMeta.Id, false)
+		assert.NoError(t, err)
+		defer ws.Close()
+		todos, err := ws.ListTodos(context.Background())
+		assert.NoError(t, err)
+		return todos
+	}
+
+	// the signed creator is the admin before any member is added
+	adminWs, err := adminStorage.OpenWorkspace(context.Background(), wsMeta.Id, true)
+	assert.NoError(t, err)
+	defer adminWs.Close()
+	todo, err := adminWs.CreateTodo(context.Background(), au.CreateTodoParams{Title: "Todo", CreatedBy: admin})
+	assert.NoError(t, err)
+	_, err = adminWs.AddMember(context.Background(), au.AddMemberParams{Author: reader, Role: au.MemberRoleReader, AddedBy: admin})
+	assert.NoError(t, err)
+	assert.NoError(t, syncTestClient(t, httpServer, s, wsMeta.Id, adminWs))
+	if todos := serverTodos(); assert.Len(t, todos, 1) {
+		assert.Equal(t, "Todo", todos[0].Title)
+	}
+
+	// readers push nothing and still pull
+	readerWs, err := readerStorage.OpenWorkspace(context.Background(), wsMeta.Id, true)
+	assert.NoError(t, err)
+	defer readerWs.Close()
+	assert.NoError(t, syncTestClient(t, httpServer, s, wsMeta.Id, readerWs))
+	readerTodo, err := readerWs.GetTodo(context.Background(), todo.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Todo", readerTodo.Title)
+
+	var rejected *auws.RejectedError
+	_, err = readerWs.EditTodo(context.Background(), todo.Id, au.EditTodoParams{Title: internal.Ref("Edited"), UpdatedBy: reader})
+	assert.NoError(t, err)
+	if err := syncTestClient(t, httpServer, s, wsMeta.Id, readerWs); assert.ErrorAs(t, err, &rejected) {
+		assert.Regexp(t, `^change [0-9a-f]{8}: readers cannot change the workspace$`, rejected.Reason)
+	}
+	assert.Equal(t, "Todo", serverTodos()[0].Title)
+
+	// unsigned changes are rejected once the workspace has members
+	raw, err = os.ReadFile(filepath.Join(td, wsMeta.Id+au.Suffix))
+	assert.NoError(t, err)
+	doc, err := automerge.Load(raw)
+	assert.NoError(t, err)
+	unsignedWs := au.NewInMemoryWorkspaceProvider(doc)
+	_, err = unsignedWs.EditTodo(context.Background(), todo.Id, au.EditTodoParams{Title: internal.Ref("Edited"), UpdatedBy: admin})
+	assert.NoError(t, err)
+	if err := syncTestClient(t, httpServer, s, wsMeta.Id, unsignedWs); assert.ErrorAs(t, err, &rejected) {
+		assert.Regexp(t, `^change [0-9a-f]{8} is unsigned$`, rejected.Reason)
+	}
+
+	_, err = adminWs.EditTodo(context.Background(), todo.Id, au.EditTodoParams{Title: internal.Ref("Edited"), UpdatedBy: admin})
+	assert.NoError(t, err)
+	assert.NoError(t, syncTestClient(t, httpServer, s, wsMeta.Id, adminWs))
+	assert.Equal(t, "Edited", serverTodos()[0].Title)
+}
@@ -0,0 +1,68 @@
+package au
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+)
+
+// annotationSet is a set of values stored on a todo as one annotation per value, such as labels and assignees. The key of
+// each annotation is the prefix followed by the url-encoded value in the fragment, and an empty annotation value means
+// the value is not in the set.
+type annotationSet string
+
+// key returns the annotation key for the value.
+func (s annotationSet) key(value string) string {
+	return string(s) + "#" + (&url.URL{Fragment: value}).EscapedFragment()
+}
+
+// value returns the decoded value if the key belongs to the set.
+func (s annotationSet) value(key string) (string, bool) {
+	if !strings.HasPrefix(key, string(s)+"#") {
+		return "", false
+	}
+	u, err := url.Parse(key)
+	if err != nil || u.Fragment == "" {
+		return "", false
+	}
+	return u.Fragment, true
+}
+
+// values returns the sorted values in the set on the todo.
+func (s annotationSet) values(todo *Todo) []string {
+	output := make([]string, 0)
+	for k, v := range todo.Annotations {
+		if value, ok := s.value(k); ok && v != "" {
+			output = append(output, value)
+		}
+	}
+	slices.Sort(output)
+	return output
+}
+
+// removeFromAnnotationSet removes the values from the set on the todo in a single edit. Values which are not present
+// are ignored. Keys are matched by their decoded value so that values which were not url-encoded when added are also
+// removed. The todo is read and edited under the same lock so that values added in between are not missed.
+func (p *inMemoryWorkspaceProvider) removeFromAnnotationSet(s annotationSet, id string, values []string, updatedBy string) (*Todo, error) {
+	if err := ValidatedAuthor(updatedBy); err != nil {
+		return nil, err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	td, err := getTodoInner(p.Doc.Path("todos").Map(), id)
+	if err != nil {
+		return nil, err
+	}
+	annotations := make(map[string]string, len(values))
+	for k := range td.Annotations {
+		if value, ok := s.value(k); ok && slices.Contains(values, value) {
+			annotations[k] = ""
+		}
+	}
+	if len(annotations) == 0 {
+		return td, nil
+	}
+	return p.editTodoInner(id, EditTodoParams{Annotations: annotations, UpdatedBy: updatedBy})
+}
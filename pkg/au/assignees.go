@@ -0,0 +1,55 @@
+package au
+
+import (
+	"context"
+	"slices"
+	"strings"
+)
+
+// assigneeSet holds the assignees of a todo.
+const assigneeSet = annotationSet(AurelianAssigneeAnnotation)
+
+// AssigneeAnnotationKey returns the annotation key that assigns the todo to the author, with the author url-encoded in
+// the fragment.
+func AssigneeAnnotationKey(author string) string {
+	return assigneeSet.key(author)
+}
+
+// TodoAssignees returns the sorted assignees of the todo.
+func TodoAssignees(todo *Todo) []string {
+	return assigneeSet.values(todo)
+}
+
+// FilterTodosByAssignee returns the todos assigned to the author.
+func FilterTodosByAssignee(todos []Todo, author string) []Todo {
+	output := make([]Todo, 0, len(todos))
+	for _, t := range todos {
+		if slices.Contains(TodoAssignees(&t), author) {
+			output = append(output, t)
+		}
+	}
+	return output
+}
+
+// AssignTodo adds the authors to the assignees of the todo in a single edit.
+func (p *inMemoryWorkspaceProvider) AssignTodo(ctx context.Context, id string, assignees []string, updatedBy string) (*Todo, error) {
+	annotations := make(map[string]string, len(assignees))
+	for _, a := range assignees {
+		a = strings.TrimSpace(a)
+		if err := ValidatedAuthor(a); err != nil {
+			return nil, err
+		}
+		annotations[AssigneeAnnotationKey(a)] = "true"
+	}
+	return p.EditTodo(ctx, id, EditTodoParams{Annotations: annotations, UpdatedBy: updatedBy})
+}
+
+// UnassignTodo removes the authors from the assignees of the todo in a single edit. Authors which are not assigned are
+// ignored.
+func (p *inMemoryWorkspaceProvider) UnassignTodo(ctx context.Context, id string, assignees []string, updatedBy string) (*Todo, error) {
+	trimmed := make([]string, len(assignees))
+	for i, a := range assignees {
+		trimmed[i] = strings.TrimSpace(a)
+	}
+	return p.removeFromAnnotationSet(assigneeSet, id, trimmed, updatedBy)
+}
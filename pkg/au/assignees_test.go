@@ -0,0 +1,40 @@
+package au
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAssignees(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, true)
+	author := "Example <email@me.com>"
+
+	_, err := wsp.CreateTodo(context.Background(), CreateTodoParams{
+		Title: "Do the thing", Annotations: map[string]string{AurelianAssigneeAnnotation + "#bob": "true"}, CreatedBy: author,
+	})
+	assert.EqualError(t, err, "invalid annotation key 'https://aurelian.one/annotations/assignee#bob': 'aurelian.one' 'assignee' annotation fragment is not valid: invalid author string, expected 'Name <email>'")
+
+	td, err := wsp.CreateTodo(context.Background(), CreateTodoParams{Title: "Do the thing", CreatedBy: author})
+	assert.NoError(t, err)
+	_, err = wsp.CreateTodo(context.Background(), CreateTodoParams{Title: "Do another thing", CreatedBy: author})
+	assert.NoError(t, err)
+
+	_, err = wsp.AssignTodo(context.Background(), td.Id, []string{"bob"}, author)
+	assert.EqualError(t, err, "invalid author string, expected 'Name <email>'")
+
+	td, err = wsp.AssignTodo(context.Background(), td.Id, []string{"Bob Smith <bob@me.com>", author}, author)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Bob Smith <bob@me.com>", author}, TodoAssignees(td))
+	assert.Contains(t, td.Annotations, "https://aurelian.one/annotations/assignee#Bob%20Smith%20%3Cbob@me.com%3E")
+
+	todos, _ := wsp.ListTodos(context.Background())
+	assert.Len(t, FilterTodosByAssignee(todos, "Bob Smith <bob@me.com>"), 1)
+
+	td, err = wsp.UnassignTodo(context.Background(), td.Id, []string{" Bob Smith <bob@me.com> "}, author)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{author}, TodoAssignees(td))
+}
@@ -0,0 +1,209 @@
+package au
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"mime"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+// MaximumAttachmentSize is the largest blob that may be attached to a comment.
+const MaximumAttachmentSize = 25 * 1024 * 1024
+
+// MaximumCommentContentSize is the largest content that may be stored inline in a comment. Larger content should be
+// added as an attachment so that it does not permanently bloat the document history or exceed the sync message limit.
+const MaximumCommentContentSize = 32 * 1024
+
+const MaximumAttachmentFilenameLength = 255
+
+// AttachmentHashPrefix is the algorithm prefix of every attachment hash.
+const AttachmentHashPrefix = "sha256:"
+
+// Attachment is a reference from a comment to a blob stored outside the document.
+type Attachment struct {
+	Hash      string
+	Filename  string
+	MediaType string
+	Size      int64
+}
+
+var validAttachmentHashPattern = regexp.MustCompile(`^sha256:[0-9a-f]{64}$`)
+
+// HashBlob returns the content address of the data.
+func HashBlob(data []byte) string {
+	sum := sha256.Sum256(data)
+	return AttachmentHashPrefix + hex.EncodeToString(sum[:])
+}
+
+func ValidateAttachmentHash(input string) error {
+	if !validAttachmentHashPattern.MatchString(input) {
+		return errors.Errorf("invalid attachment hash '%s', expected sha256:<64 hex characters>", input)
+	}
+	return nil
+}
+
+func ValidateAttachment(input Attachment) (Attachment, error) {
+	if err := ValidateAttachmentHash(input.Hash); err != nil {
+		return input, err
+	}
+	if input.Size <= 0 {
+		return input, errors.New("attachment is empty")
+	} else if d := int64(MaximumAttachmentSize); input.Size > d {
+		return input, errors.Errorf("attachment is too large, it should be at most %d bytes", d)
+	}
+	if _, _, err := mime.ParseMediaType(input.MediaType); err != nil {
+		return input, errors.Wrap(err, "invalid attachment mime type")
+	}
+	if f, err := ValidateAndCleanUnicode(input.Filename, false); err != nil {
+		return input, errors.Wrap(err, "invalid attachment filename")
+	} else if f = strings.TrimSpace(f); f == "" || f == "." || f == ".." || strings.ContainsAny(f, `/\`) {
+		return input, errors.Errorf("invalid attachment filename '%s'", input.Filename)
+	} else if d := MaximumAttachmentFilenameLength; len(f) > d {
+		return input, errors.Errorf("attachment filename is too long, it should be at most %d characters", d)
+	} else {
+		input.Filename = f
+	}
+	return input, nil
+}
+
+// ListAttachments returns every attachment referenced by a comment in the workspace, sorted and unique by hash.
+func (p *inMemoryWorkspaceProvider) ListAttachments(ctx context.Context) ([]Attachment, error) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	output := make([]Attachment, 0)
+	todos := p.Doc.Path("todos").Map()
+	todoIds, _ := todos.Keys()
+	for _, todoId := range todoIds {
+		commentsValue, _ := p.Doc.Path("todos", todoId, "comments").Get()
+		if commentsValue.Kind() != automerge.KindMap {
+			continue
+		}
+		commentIds, _ := commentsValue.Map().Keys()
+		for _, commentId := range commentIds {
+			if c, err := getCommentInner(commentsValue.Map(), commentId); err == nil && c.Attachment != nil {
+				if !slices.ContainsFunc(output, func(a Attachment) bool { return a.Hash == c.Attachment.Hash }) {
+					output = append(output, *c.Attachment)
+				}
+			}
+		}
+	}
+	slices.SortFunc(output, func(a, b Attachment) int {
+		return strings.Compare(a.Hash, b.Hash)
+	})
+	return output, nil
+}
+
+func setAttachmentInner(comment *automerge.Map, attachment Attachment) error {
+	newAttachment := automerge.NewMap()
+	if err := comment.Set("attachment", newAttachment); err != nil {
+		return errors.Wrap(err, "failed to set attachment")
+	} else if err := newAttachment.Set("hash", attachment.Hash); err != nil {
+		return errors.Wrap(err, "failed to set attachment hash")
+	} else if err := newAttachment.Set("filename", attachment.Filename); err != nil {
+		return errors.Wrap(err, "failed to set attachment filename")
+	} else if err := newAttachment.Set("media_type", attachment.MediaType); err != nil {
+		return errors.Wrap(err, "failed to set attachment media type")
+	} else if err := newAttachment.Set("size", attachment.Size); err != nil {
+		return errors.Wrap(err, "failed to set attachment size")
+	}
+	return nil
+}
+
+func getAttachmentInner(comment *automerge.Map) *Attachment {
+	attachmentValue, _ := comment.Get("attachment")
+	if attachmentValue.Kind() != automerge.KindMap {
+		return nil
+	}
+	output := new(Attachment)
+	if v, _ := attachmentValue.Map().Get("hash"); v.Kind() == automerge.KindStr {
+		output.Hash = v.Str()
+	}
+	if v, _ := attachmentValue.Map().Get("filename"); v.Kind() == automerge.KindStr {
+		output.Filename = v.Str()
+	}
+	if v, _ := attachmentValue.Map().Get("media_type"); v.Kind() == automerge.KindStr {
+		output.MediaType = v.Str()
+	}
+	if v, _ := attachmentValue.Map().Get("size"); v.Kind() == automerge.KindInt64 {
+		output.Size = v.Int64()
+	}
+	if ValidateAttachmentHash(output.Hash) != nil {
+		return nil
+	}
+	return output
+}
+
+func (d *directoryStorage) blobPath(hash string) string {
+	return filepath.Join(d.Path, "blobs", "sha256", strings.TrimPrefix(hash, AttachmentHashPrefix))
+}
+
+// PutBlob stores the data by its hash and returns the hash. Storing the same data again is a no-op.
+func (d *directoryStorage) PutBlob(ctx context.Context, data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", errors.New("attachment is empty")
+	} else if len(data) > MaximumAttachmentSize {
+		return "", errors.Errorf("attachment is too large, it should be at most %d bytes", MaximumAttachmentSize)
+	}
+	hash := HashBlob(data)
+	path := d.blobPath(hash)
+	if _, err := os.Stat(path); err == nil {
+		return hash, nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), os.FileMode(0755)); err != nil {
+		return "", errors.Wrap(err, "failed to create blob directory")
+	}
+	// a unique temporary file in the same directory keeps concurrent writers of the same blob apart and the rename atomic
+	f, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*.temp")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create blob")
+	}
+	defer os.Remove(f.Name())
+	if _, err := f.Write(data); err != nil {
+		_ = f.Close()
+		return "", errors.Wrap(err, "failed to write blob")
+	} else if err := f.Close(); err != nil {
+		return "", errors.Wrap(err, "failed to write blob")
+	} else if err := os.Chmod(f.Name(), os.FileMode(0644)); err != nil {
+		return "", errors.Wrap(err, "failed to set blob permissions")
+	}
+	if err := os.Rename(f.Name(), path); err != nil {
+		return "", errors.Wrap(err, "failed to move blob to target")
+	}
+	return hash, nil
+}
+
+// StatBlob returns the size of the blob with the given hash without reading it. The error wraps os.ErrNotExist if the
+// blob is not stored locally.
+func (d *directoryStorage) StatBlob(ctx context.Context, hash string) (int64, error) {
+	if err := ValidateAttachmentHash(hash); err != nil {
+		return 0, err
+	}
+	info, err := os.Stat(d.blobPath(hash))
+	if err != nil {
+		return 0, errors.Wrap(err, "failed to stat blob")
+	}
+	return info.Size(), nil
+}
+
+// GetBlob returns the data with the given hash. The error wraps os.ErrNotExist if the blob is not stored locally.
+func (d *directoryStorage) GetBlob(ctx context.Context, hash string) ([]byte, error) {
+	if err := ValidateAttachmentHash(hash); err != nil {
+		return nil, err
+	}
+	data, err := os.ReadFile(d.blobPath(hash))
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read blob")
+	} else if HashBlob(data) != hash {
+		return nil, errors.Errorf("blob '%s' is corrupt", hash)
+	}
+	return data, nil
+}
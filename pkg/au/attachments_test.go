@@ -0,0 +1,109 @@
+package au
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateAttachment(t *testing.T) {
+	hash := HashBlob([]byte("hello"))
+	a, err := ValidateAttachment(Attachment{Hash: hash, Filename: " report.pdf ", MediaType: "application/pdf", Size: 5})
+	assert.NoError(t, err)
+	assert.Equal(t, "report.pdf", a.Filename)
+
+	for _, tc := range []struct {
+		input Attachment
+		err   string
+	}{
+		{Attachment{Hash: "md5:abc", Filename: "a", MediaType: "text/plain", Size: 1}, "invalid attachment hash 'md5:abc', expected sha256:<64 hex characters>"},
+		{Attachment{Hash: hash, Filename: "a", MediaType: "text/plain", Size: 0}, "attachment is empty"},
+		{Attachment{Hash: hash, Filename: "a", MediaType: "text/plain", Size: MaximumAttachmentSize + 1}, "attachment is too large, it should be at most 26214400 bytes"},
+		{Attachment{Hash: hash, Filename: "a", MediaType: "", Size: 1}, "invalid attachment mime type: mime: no media type"},
+		{Attachment{Hash: hash, Filename: "../a", MediaType: "text/plain", Size: 1}, "invalid attachment filename '../a'"},
+	} {
+		t.Run(tc.err, func(t *testing.T) {
+			_, err := ValidateAttachment(tc.input)
+			assert.EqualError(t, err, tc.err)
+		})
+	}
+}
+
+func TestAttachments(t *testing.T) {
+	ws := newTestWorkspace(t)
+	author := "Alice <alice@me.com>"
+	ctx := context.Background()
+
+	td, err := ws.CreateTodo(ctx, CreateTodoParams{Title: "Do the thing", CreatedBy: author})
+	assert.NoError(t, err)
+
+	_, err = ws.CreateComment(ctx, td.Id, CreateCommentParams{
+		MediaType: DefaultCommentMediaType, Content: []byte(strings.Repeat("x", MaximumCommentContentSize+1)), CreatedBy: author,
+	})
+	assert.EqualError(t, err, "content is too large, it should be at most 32768 bytes, use an attachment instead")
+
+	attachment := Attachment{Hash: HashBlob([]byte("image")), Filename: "image.png", MediaType: "image/png", Size: 5}
+	c, err := ws.CreateComment(ctx, td.Id, CreateCommentParams{MediaType: DefaultCommentMediaType, Attachment: &attachment, CreatedBy: author})
+	assert.NoError(t, err)
+	assert.Equal(t, &attachment, c.Attachment)
+	assert.Empty(t, c.Content)
+
+	_, err = ws.CreateComment(ctx, td.Id, CreateCommentParams{MediaType: DefaultCommentMediaType, Content: []byte("same again"), Attachment: &attachment, CreatedBy: author})
+	assert.NoError(t, err)
+	_, err = ws.CreateComment(ctx, td.Id, CreateCommentParams{MediaType: DefaultCommentMediaType, Content: []byte("plain"), CreatedBy: author})
+	assert.NoError(t, err)
+
+	attachments, err := ws.ListAttachments(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []Attachment{attachment}, attachments)
+}
+
+func TestDirectoryStorage_blobs(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "")
+	require.NoError(t, err)
+	defer os.RemoveAll(td)
+	s, err := NewDirectoryStorage(td)
+	require.NoError(t, err)
+	ctx := context.Background()
+
+	_, err = s.GetBlob(ctx, HashBlob([]byte("hello")))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = s.StatBlob(ctx, HashBlob([]byte("hello")))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+	_, err = s.PutBlob(ctx, nil)
+	assert.EqualError(t, err, "attachment is empty")
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			hash, err := s.PutBlob(ctx, []byte("hello"))
+			assert.NoError(t, err)
+			assert.Equal(t, HashBlob([]byte("hello")), hash)
+		}()
+	}
+	wg.Wait()
+	data, err := s.GetBlob(ctx, HashBlob([]byte("hello")))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("hello"), data)
+	size, err := s.StatBlob(ctx, HashBlob([]byte("hello")))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(5), size)
+
+	// no temporary files are left next to the blob
+	entries, err := os.ReadDir(filepath.Join(td, "blobs", "sha256"))
+	assert.NoError(t, err)
+	assert.Len(t, entries, 1)
+
+	// workspaces are still listed correctly alongside the blobs directory
+	workspaces, err := s.ListWorkspaces(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, workspaces)
+}
@@ -0,0 +1,156 @@
+package au
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+// todoObjectMaps are the maps within a Todo whose entries are objects that can be deleted.
+var todoObjectMaps = []string{"comments", "checklist", "time_entries"}
+
+// AuthorizeChanges returns a filter for incoming sync changes which rejects the changes that the role of their author
+// does not allow. Readers cannot change the workspace, commenters can only add and edit comments, contributors cannot
+// delete todos, comments, checklist items, time entries, or settings, and only admins can change the members.
+//
+// The roles are read from the given document, which should be the accepted document that the changes are applied to
+// after the filter passes, rather than from the changes themselves so that a change cannot grant its own permissions.
+// While the document has no members, the creator of the workspace is its only admin when the change that created it is
+// signed by a trusted key, and nothing is rejected otherwise. Since the author is the one claimed by the commit message,
+// every change must be signed by a trusted key of its author whenever there are members.
+func AuthorizeChanges(doc *automerge.Doc, keys AuthorKeys) func(staged *automerge.Doc, changes []*automerge.Change) error {
+	return func(staged *automerge.Doc, changes []*automerge.Change) error {
+		members, err := authorizationMembersInner(doc, keys)
+		if err != nil {
+			return err
+		} else if len(members) == 0 {
+			return nil
+		}
+		signatures := signatureIndex(changes)
+		for _, change := range changes {
+			if isSignatureChange(change) {
+				continue
+			} else if v := verifyChange(change, signatures[change.Hash()], keys); v.Status != SignatureStatusValid {
+				return errors.Errorf("change %s is %s", v.Hash[:8], v.Status)
+			} else if err := authorizeChange(staged, change, members); err != nil {
+				return errors.Wrapf(err, "change %s", change.Hash().String()[:8])
+			}
+		}
+		return nil
+	}
+}
+
+// authorizationMembersInner returns the members of the workspace or, when it has none, the creator of the workspace as
+// its admin if the change that created it is signed by a trusted key of the creator.
+func authorizationMembersInner(doc *automerge.Doc, keys AuthorKeys) ([]Member, error) {
+	if members := listMembersInner(doc); len(members) > 0 {
+		return members, nil
+	}
+	creator, err := workspaceCreatorInner(doc)
+	if err != nil || creator == nil {
+		return nil, err
+	}
+	changes, err := doc.Changes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get changes")
+	}
+	genesis := workspaceGenesis(changes)
+	if verifyChange(genesis, signatureIndex(changes)[genesis.Hash()], keys).Status != SignatureStatusValid {
+		return nil, nil
+	}
+	return []Member{*creator}, nil
+}
+
+// workspaceGenesis returns the change that created the workspace, which is the only change without dependencies, or
+// nil if there is more than one.
+func workspaceGenesis(changes []*automerge.Change) *automerge.Change {
+	var output *automerge.Change
+	for _, change := range changes {
+		if len(change.Dependencies()) > 0 {
+			continue
+		} else if output != nil {
+			return nil
+		}
+		output = change
+	}
+	return output
+}
+
+func authorizeChange(doc *automerge.Doc, change *automerge.Change, members []Member) error {
+	author := commitAuthor(change.Message())
+	if author == "" {
+		return errors.New("has no author")
+	}
+	member := ResolveMember(members, author)
+	if member == nil {
+		return errors.Errorf("'%s' is not a member", author)
+	} else if member.Role == MemberRoleAdmin {
+		return nil
+	}
+
+	before, after, err := forkAroundChange(doc, change)
+	if err != nil {
+		return err
+	}
+	// changes that leave every value as it was, such as the migration of missing containers, are allowed for any role
+	for _, fc := range diffLeaves(flattenPath(before), flattenPath(after)) {
+		parts := strings.Split(fc.Field, "/")
+		if member.Role == MemberRoleReader {
+			return errors.New("readers cannot change the workspace")
+		} else if len(parts) > 1 && parts[0] == "settings" && parts[1] == "members" {
+			return errors.New("only admins can change members")
+		} else if member.Role == MemberRoleCommenter && (len(parts) < 4 || parts[0] != "todos" || parts[2] != "comments") {
+			return errors.New("commenters can only change comments")
+		}
+	}
+	if member.Role == MemberRoleContributor || member.Role == MemberRoleCommenter {
+		remaining := make(map[string]bool)
+		for _, path := range deletableObjects(after) {
+			remaining[path] = true
+		}
+		for _, path := range deletableObjects(before) {
+			if !remaining[path] {
+				return errors.Errorf("%ss cannot delete %s", member.Role, path)
+			}
+		}
+	}
+	return nil
+}
+
+// deletableObjects returns the sorted paths of the todos, the objects within todos, and the settings entries.
+func deletableObjects(doc *automerge.Doc) []string {
+	output := make([]string, 0)
+	if todos, _ := doc.Path("todos").Get(); todos.Kind() == automerge.KindMap {
+		ids, _ := todos.Map().Keys()
+		for _, id := range ids {
+			todo, _ := todos.Map().Get(id)
+			if todo.Kind() != automerge.KindMap {
+				continue
+			}
+			output = append(output, "todos/"+id)
+			for _, name := range todoObjectMaps {
+				if objects, _ := todo.Map().Get(name); objects.Kind() == automerge.KindMap {
+					keys, _ := objects.Map().Keys()
+					for _, k := range keys {
+						output = append(output, "todos/"+id+"/"+name+"/"+k)
+					}
+				}
+			}
+		}
+	}
+	if settings, _ := doc.Path("settings").Get(); settings.Kind() == automerge.KindMap {
+		names, _ := settings.Map().Keys()
+		for _, name := range names {
+			if entries, _ := settings.Map().Get(name); entries.Kind() == automerge.KindMap {
+				keys, _ := entries.Map().Keys()
+				for _, k := range keys {
+					output = append(output, "settings/"+name+"/"+k)
+				}
+			}
+		}
+	}
+	slices.Sort(output)
+	return output
+}
@@ -0,0 +1,149 @@
+package au
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/automerge/automerge-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aurelian-one/au/internal"
+)
+
+func TestAuthorizeChanges_without_members(t *testing.T) {
+	ws := newTestWorkspace(t)
+	ctx := context.Background()
+	td, err := ws.CreateTodo(ctx, CreateTodoParams{Title: "Example", CreatedBy: "Alice <alice@me.com>"})
+	assert.NoError(t, err)
+
+	// any change is accepted while there are no members and the workspace has no signed creator
+	staged, err := ws.Doc.Fork()
+	assert.NoError(t, err)
+	assert.NoError(t, NewInMemoryWorkspaceProvider(staged).DeleteTodo(ctx, td.Id, DeleteTodoParams{DeletedBy: "Anyone <anyone@me.com>"}))
+	changes, err := staged.Changes(ws.Doc.Heads()...)
+	assert.NoError(t, err)
+	assert.NoError(t, AuthorizeChanges(ws.Doc, AuthorKeys{})(staged, changes))
+}
+
+func TestAuthorizeChanges(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ctx := context.Background()
+	admin := "Alice <alice@me.com>"
+	authors := map[string]string{
+		admin:                      MemberRoleAdmin,
+		"Bob <bob@me.com>":         MemberRoleEditor,
+		"Carol <carol@me.com>":     MemberRoleContributor,
+		"Dave <dave@me.com>":       MemberRoleCommenter,
+		"Erin <erin@me.com>":       MemberRoleReader,
+		"Mallory <mallory@me.com>": "",
+	}
+	for author := range authors {
+		_, err := s.GenerateSigningKey(ctx, author)
+		assert.NoError(t, err)
+	}
+	keys, err := s.ListPublicKeys(ctx)
+	assert.NoError(t, err)
+	meta, err := s.CreateWorkspace(ctx, CreateWorkspaceParams{Alias: "testing", CreatedBy: admin})
+	assert.NoError(t, err)
+	opened, err := s.OpenWorkspace(ctx, meta.Id, true)
+	assert.NoError(t, err)
+	t.Cleanup(func() {
+		_ = opened.Close()
+	})
+	ws := opened.(*directoryStorageWorkspace).Doc
+	doc := ws.Doc
+
+	td, err := ws.CreateTodo(ctx, CreateTodoParams{Title: "Example", CreatedBy: admin})
+	assert.NoError(t, err)
+	comment, err := ws.CreateComment(ctx, td.Id, CreateCommentParams{MediaType: DefaultCommentMediaType, Content: []byte("hi"), CreatedBy: admin})
+	assert.NoError(t, err)
+
+	authorize := AuthorizeChanges(doc, keys)
+	stagedChanges := func(f func(ws *inMemoryWorkspaceProvider) error) error {
+		staged, err := doc.Fork()
+		assert.NoError(t, err)
+		assert.NoError(t, f(&inMemoryWorkspaceProvider{Doc: staged, SigningKeys: ws.SigningKeys}))
+		changes, err := staged.Changes(doc.Heads()...)
+		assert.NoError(t, err)
+		return authorize(staged, changes)
+	}
+
+	// the signed creator is the only admin while there are no members
+	assert.NoError(t, stagedChanges(func(ws *inMemoryWorkspaceProvider) error {
+		return ws.DeleteTodo(ctx, td.Id, DeleteTodoParams{DeletedBy: admin})
+	}))
+	if err := stagedChanges(func(ws *inMemoryWorkspaceProvider) error {
+		return ws.DeleteTodo(ctx, td.Id, DeleteTodoParams{DeletedBy: "Bob <bob@me.com>"})
+	}); assert.Error(t, err) {
+		assert.Regexp(t, `^change [0-9a-f]{8}: 'Bob <bob@me.com>' is not a member$`, err.Error())
+	}
+
+	// adding the first member adds the creator as an admin too
+	_, err = ws.AddMember(ctx, AddMemberParams{Author: "Bob <bob@me.com>", Role: MemberRoleEditor, AddedBy: admin})
+	assert.NoError(t, err)
+	members, err := ws.ListMembers(ctx)
+	assert.NoError(t, err)
+	if assert.Len(t, members, 2) {
+		assert.Equal(t, Member{Email: "alice@me.com", Name: "Alice", Role: MemberRoleAdmin, Aliases: []string{}}, members[0])
+	}
+	for author, role := range authors {
+		if role != "" && role != MemberRoleAdmin && role != MemberRoleEditor {
+			_, err := ws.AddMember(ctx, AddMemberParams{Author: author, Role: role, AddedBy: admin})
+			assert.NoError(t, err)
+		}
+	}
+
+	for name, tc := range map[string]struct {
+		f        func(ws *inMemoryWorkspaceProvider) error
+		expected string
+	}{
+		"editor deletes todo": {f: func(ws *inMemoryWorkspaceProvider) error {
+			return ws.DeleteTodo(ctx, td.Id, DeleteTodoParams{DeletedBy: "Bob <bob@me.com>"})
+		}},
+		"editor adds member": {f: func(ws *inMemoryWorkspaceProvider) error {
+			_, err := ws.AddMember(ctx, AddMemberParams{Author: "Frank <frank@me.com>", Role: MemberRoleAdmin, AddedBy: "Bob <bob@me.com>"})
+			return err
+		}, expected: "only admins can change members"},
+		"contributor edits todo": {f: func(ws *inMemoryWorkspaceProvider) error {
+			_, err := ws.EditTodo(ctx, td.Id, EditTodoParams{Title: internal.Ref("Edited"), UpdatedBy: "Carol <carol@me.com>"})
+			return err
+		}},
+		"contributor deletes comment": {f: func(ws *inMemoryWorkspaceProvider) error {
+			return ws.DeleteComment(ctx, td.Id, comment.Id, DeleteCommentParams{DeletedBy: "Carol <carol@me.com>"})
+		}, expected: "contributors cannot delete todos/" + td.Id + "/comments/" + comment.Id},
+		"commenter comments": {f: func(ws *inMemoryWorkspaceProvider) error {
+			_, err := ws.CreateComment(ctx, td.Id, CreateCommentParams{MediaType: DefaultCommentMediaType, Content: []byte("me too"), CreatedBy: "Dave <dave@me.com>"})
+			return err
+		}},
+		"commenter edits todo": {f: func(ws *inMemoryWorkspaceProvider) error {
+			_, err := ws.EditTodo(ctx, td.Id, EditTodoParams{Title: internal.Ref("Edited"), UpdatedBy: "Dave <dave@me.com>"})
+			return err
+		}, expected: "commenters can only change comments"},
+		"reader comments": {f: func(ws *inMemoryWorkspaceProvider) error {
+			_, err := ws.CreateComment(ctx, td.Id, CreateCommentParams{MediaType: DefaultCommentMediaType, Content: []byte("me too"), CreatedBy: "Erin <erin@me.com>"})
+			return err
+		}, expected: "readers cannot change the workspace"},
+		"stranger edits todo": {f: func(ws *inMemoryWorkspaceProvider) error {
+			_, err := ws.EditTodo(ctx, td.Id, EditTodoParams{Title: internal.Ref("Edited"), UpdatedBy: "Mallory <mallory@me.com>"})
+			return err
+		}, expected: "'Mallory <mallory@me.com>' is not a member"},
+		"reader makes a change without values": {f: func(ws *inMemoryWorkspaceProvider) error {
+			_, err := ws.commit("Erin <erin@me.com> migrated workspace", automerge.CommitOptions{AllowEmpty: true})
+			return err
+		}},
+		"admin makes an unsigned change": {f: func(ws *inMemoryWorkspaceProvider) error {
+			ws.SigningKeys = nil
+			_, err := ws.EditTodo(ctx, td.Id, EditTodoParams{Title: internal.Ref("Edited"), UpdatedBy: admin})
+			return err
+		}, expected: "is unsigned"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			if err := stagedChanges(tc.f); tc.expected == "" {
+				assert.NoError(t, err)
+			} else if assert.Error(t, err) {
+				assert.Regexp(t, `^change [0-9a-f]{8}:? `+regexp.QuoteMeta(tc.expected)+`$`, err.Error())
+			}
+		})
+	}
+}
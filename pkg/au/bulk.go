@@ -0,0 +1,176 @@
+package au
+
+import (
+	"context"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+// TodoFilter selects todos by their fields. Every non-empty field must match.
+type TodoFilter struct {
+	// Labels must all be present on the todo.
+	Labels   []string
+	Assignee string
+	// Status matches either the open or closed status or the workflow status of the todo.
+	Status string
+	Parent string
+	// Title matches todos whose title contains it, ignoring case.
+	Title string
+	// Statuses are the workflow statuses of the workspace, which are needed to match the workflow status of todos.
+	Statuses []WorkflowStatus
+}
+
+// ParseTodoFilter parses key=value expressions into a filter. The keys are label, which may be repeated, assignee,
+// status, parent, and title.
+func ParseTodoFilter(expressions []string) (TodoFilter, error) {
+	var output TodoFilter
+	for _, e := range expressions {
+		k, v, ok := strings.Cut(e, "=")
+		if v = strings.TrimSpace(v); !ok || v == "" {
+			return TodoFilter{}, errors.Errorf("invalid filter '%s', must be key=value", e)
+		}
+		switch strings.TrimSpace(k) {
+		case "label":
+			output.Labels = append(output.Labels, v)
+		case "assignee":
+			output.Assignee = v
+		case "status":
+			output.Status = v
+		case "parent":
+			output.Parent = v
+		case "title":
+			output.Title = v
+		default:
+			return TodoFilter{}, errors.Errorf("invalid filter key '%s', expected one of label, assignee, status, parent, or title", k)
+		}
+	}
+	return output, nil
+}
+
+// IsEmpty returns whether the filter matches every todo.
+func (f TodoFilter) IsEmpty() bool {
+	return len(f.Labels) == 0 && f.Assignee == "" && f.Status == "" && f.Parent == "" && f.Title == ""
+}
+
+// Matches returns whether the todo matches every field of the filter.
+func (f TodoFilter) Matches(todo *Todo) bool {
+	if labels := TodoLabels(todo); slices.ContainsFunc(f.Labels, func(l string) bool { return !slices.Contains(labels, l) }) {
+		return false
+	} else if f.Assignee != "" && !slices.Contains(TodoAssignees(todo), f.Assignee) {
+		return false
+	} else if f.Status != "" && f.Status != todo.Status && f.Status != TodoWorkflowStatus(todo, f.Statuses) {
+		return false
+	} else if f.Parent != "" && f.Parent != todo.Annotations[AurelianParentAnnotation] {
+		return false
+	} else if f.Title != "" && !strings.Contains(strings.ToLower(todo.Title), strings.ToLower(f.Title)) {
+		return false
+	}
+	return true
+}
+
+// FilterTodos returns the todos that match the filter.
+func FilterTodos(todos []Todo, filter TodoFilter) []Todo {
+	output := make([]Todo, 0, len(todos))
+	for _, t := range todos {
+		if filter.Matches(&t) {
+			output = append(output, t)
+		}
+	}
+	return output
+}
+
+// BulkEditTodos applies the same edit to every todo in a single change. The edit is checked against every todo before
+// any of them are modified, so either all of the todos are edited or none are. Parents may be closed along with all of
+// their open children. Recurring todos which are closed have their next occurrence created in the same change.
+func (p *inMemoryWorkspaceProvider) BulkEditTodos(ctx context.Context, ids []string, params EditTodoParams) ([]Todo, error) {
+	params, err := validateEditTodoParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	todos := p.Doc.Path("todos").Map()
+	ids, prepared, closing, err := prepareBulkEditTodosInner(p.Doc, todos, ids, params)
+	if err != nil {
+		return nil, err
+	}
+
+	updatedAt := time.Now().UTC().Truncate(time.Second)
+	output := make([]Todo, len(ids))
+	for i, id := range ids {
+		if err := applyEditTodoInner(todos, id, prepared[i], updatedAt); err != nil {
+			return nil, err
+		}
+		if closing[id] {
+			if _, err := spawnNextOccurrenceInner(p.Doc, todos, id, params.UpdatedBy, updatedAt); err != nil {
+				return nil, err
+			}
+		}
+		edited, err := getTodoInner(todos, id)
+		if err != nil {
+			return nil, err
+		}
+		output[i] = *edited
+	}
+
+	if _, err := p.commit(params.UpdatedBy+" edited "+strconv.Itoa(len(ids))+" todos", automerge.CommitOptions{AllowEmpty: true}); err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
+	return output, nil
+}
+
+// CheckBulkEditTodos runs the same checks as BulkEditTodos without modifying the workspace, so that a dry run reports
+// the edits which would be refused.
+func (p *inMemoryWorkspaceProvider) CheckBulkEditTodos(ctx context.Context, ids []string, params EditTodoParams) error {
+	params, err := validateEditTodoParams(params)
+	if err != nil {
+		return err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	_, _, _, err = prepareBulkEditTodosInner(p.Doc, p.Doc.Path("todos").Map(), ids, params)
+	return err
+}
+
+// prepareBulkEditTodosInner checks the edit against every todo without modifying the document. It returns the sorted
+// unique ids along with the edit resolved for each of them and the ids of the todos which the edit closes.
+func prepareBulkEditTodosInner(doc *automerge.Doc, todos *automerge.Map, ids []string, params EditTodoParams) ([]string, []EditTodoParams, map[string]bool, error) {
+	if len(ids) == 0 {
+		return nil, nil, nil, errors.New("no todos to edit")
+	}
+	ids = slices.Clone(ids)
+	slices.Sort(ids)
+	ids = slices.Compact(ids)
+	prepared := make([]EditTodoParams, len(ids))
+	closing := make(map[string]bool)
+	for i, id := range ids {
+		td, pr, err := prepareEditTodoInner(doc, todos, id, params)
+		if err != nil {
+			return nil, nil, nil, errors.Wrapf(err, "cannot edit todo '%s'", id)
+		}
+		prepared[i] = pr
+		if pr.Status != nil && *pr.Status == "closed" && td.Status != "closed" {
+			closing[id] = true
+		}
+	}
+	for _, id := range ids {
+		if !closing[id] {
+			continue
+		}
+		for _, childId := range childIdsInner(todos, id) {
+			if todoFieldInner(todos, childId, "status") == "open" && !closing[childId] {
+				return nil, nil, nil, errors.Errorf("cannot close todo '%s' since its child '%s' is open and not being closed", id, childId)
+			}
+		}
+	}
+	return ids, prepared, closing, nil
+}
@@ -0,0 +1,100 @@
+package au
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aurelian-one/au/internal"
+)
+
+func TestParseTodoFilter(t *testing.T) {
+	f, err := ParseTodoFilter([]string{"label=a", "label=b", "status=open", "title=Fix"})
+	assert.NoError(t, err)
+	assert.Equal(t, TodoFilter{Labels: []string{"a", "b"}, Status: "open", Title: "Fix"}, f)
+	assert.False(t, f.IsEmpty())
+
+	_, err = ParseTodoFilter([]string{"label"})
+	assert.EqualError(t, err, "invalid filter 'label', must be key=value")
+	_, err = ParseTodoFilter([]string{"colour=red"})
+	assert.EqualError(t, err, "invalid filter key 'colour', expected one of label, assignee, status, parent, or title")
+
+	todo := &Todo{Title: "Fix the bug", Status: "open", Annotations: map[string]string{LabelAnnotationKey("a"): "true", LabelAnnotationKey("b"): "true"}}
+	assert.True(t, f.Matches(todo))
+	todo.Status = "closed"
+	assert.False(t, f.Matches(todo))
+
+	// the workflow status only matches while its category matches the status of the todo
+	f = TodoFilter{Status: "doing", Statuses: []WorkflowStatus{{Name: "doing", Category: "open"}}}
+	todo.Annotations[AurelianWorkflowStatusAnnotation] = "doing"
+	assert.False(t, f.Matches(todo))
+	todo.Status = "open"
+	assert.True(t, f.Matches(todo))
+}
+
+func TestBulkEditTodos(t *testing.T) {
+	ws := newTestWorkspace(t)
+	doc := ws.Doc
+	alice := "Alice <alice@me.com>"
+	ctx := context.Background()
+
+	parent, err := ws.CreateTodo(ctx, CreateTodoParams{Title: "Parent", CreatedBy: alice})
+	assert.NoError(t, err)
+	child, err := ws.CreateTodo(ctx, CreateTodoParams{Title: "Child", CreatedBy: alice, Annotations: map[string]string{AurelianParentAnnotation: parent.Id}})
+	assert.NoError(t, err)
+	recurring, err := ws.CreateTodo(ctx, CreateTodoParams{Title: "Weekly", CreatedBy: alice, Annotations: map[string]string{AurelianRecurrenceAnnotation: "FREQ=WEEKLY"}})
+	assert.NoError(t, err)
+
+	_, err = ws.BulkEditTodos(ctx, nil, EditTodoParams{Status: internal.Ref("closed"), UpdatedBy: alice})
+	assert.EqualError(t, err, "no todos to edit")
+
+	// closing the parent without its child fails and leaves every todo untouched
+	_, err = ws.BulkEditTodos(ctx, []string{parent.Id, recurring.Id}, EditTodoParams{Status: internal.Ref("closed"), UpdatedBy: alice})
+	assert.EqualError(t, err, "cannot close todo '"+parent.Id+"' since its child '"+child.Id+"' is open and not being closed")
+	todos, err := ws.ListTodos(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, todos, 3)
+	for _, td := range todos {
+		assert.Equal(t, "open", td.Status)
+	}
+
+	// checking the edit runs the same checks without changing anything
+	changesBefore, _ := doc.Changes()
+	assert.EqualError(t, ws.CheckBulkEditTodos(ctx, []string{parent.Id, recurring.Id}, EditTodoParams{Status: internal.Ref("closed"), UpdatedBy: alice}), "cannot close todo '"+parent.Id+"' since its child '"+child.Id+"' is open and not being closed")
+	assert.NoError(t, ws.CheckBulkEditTodos(ctx, []string{parent.Id, child.Id}, EditTodoParams{Status: internal.Ref("closed"), UpdatedBy: alice}))
+	changesAfter, _ := doc.Changes()
+	assert.Len(t, changesAfter, len(changesBefore))
+
+	edited, err := ws.BulkEditTodos(ctx, []string{parent.Id, child.Id, recurring.Id, child.Id}, EditTodoParams{
+		Status:      internal.Ref("closed"),
+		Annotations: map[string]string{LabelAnnotationKey("done"): "true"},
+		UpdatedBy:   alice,
+	})
+	assert.NoError(t, err)
+	assert.Len(t, edited, 3)
+	for _, td := range edited {
+		assert.Equal(t, "closed", td.Status)
+		assert.Equal(t, []string{"done"}, TodoLabels(&td))
+	}
+	changesAfter, _ = doc.Changes()
+	if assert.Len(t, changesAfter, len(changesBefore)+1) {
+		assert.Equal(t, alice+" edited 3 todos", changesAfter[len(changesAfter)-1].Message())
+	}
+
+	nextId, _ := NextOccurrenceId(recurring.Id)
+	next, err := ws.GetTodo(ctx, nextId)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "open", next.Status)
+		assert.Equal(t, "Weekly", next.Title)
+	}
+	closed, err := ws.GetTodo(ctx, recurring.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, nextId, closed.Annotations[AurelianNextAnnotation])
+
+	history, err := ws.GetTodoHistory(ctx, child.Id)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 2) {
+		assert.Equal(t, alice+" edited 3 todos", history[1].Message)
+	}
+}
@@ -0,0 +1,403 @@
+package au
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"slices"
+
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+// The Go bindings of automerge do not expose the operations within a change, so the few places that need them, such as
+// finding the conflicting values of a key, decode the raw bytes of the change instead. The format is described in
+// https://automerge.org/automerge-binary-format-spec/.
+
+var changeChunkMagic = []byte{0x85, 0x6f, 0x4a, 0x83}
+
+const (
+	changeChunkType = 1
+	// maximumColumnLength limits the number of entries in a column so that a malicious change cannot exhaust memory.
+	maximumColumnLength = 1 << 20
+)
+
+// The actions of operations which are distinguished.
+const (
+	opActionDelete    = 3
+	opActionIncrement = 5
+)
+
+// The column specifications of the operation columns which are decoded. The spec is the column id shifted left by 4
+// bits combined with the column type.
+const (
+	columnObjActor  = 0<<4 | 1
+	columnObjCtr    = 0<<4 | 2
+	columnKeyStr    = 1<<4 | 5
+	columnInsert    = 3<<4 | 4
+	columnAction    = 4<<4 | 2
+	columnPredNum   = 7<<4 | 0
+	columnPredActor = 7<<4 | 1
+	columnPredCtr   = 7<<4 | 3
+	columnDeflate   = 1 << 3
+)
+
+// changeChunk is a decoded uncompressed change chunk.
+type changeChunk struct {
+	Actor       string
+	StartOp     uint64
+	Message     string
+	OtherActors []string
+	columns     map[uint64][]byte
+}
+
+// opId identifies an operation, and the object created by it, by the counter and the hex actor id. The zero opId is
+// the root map.
+type opId struct {
+	Counter uint64
+	Actor   string
+}
+
+// compare orders operation ids the way automerge does to pick the winner of conflicting values.
+func (o opId) compare(other opId) int {
+	if o.Counter < other.Counter {
+		return -1
+	} else if o.Counter > other.Counter {
+		return 1
+	}
+	return bytes.Compare([]byte(o.Actor), []byte(other.Actor))
+}
+
+// changeOp is an operation of a change. Key is only set for operations on maps.
+type changeOp struct {
+	Id     opId
+	Obj    opId
+	Key    *string
+	Insert bool
+	Action uint64
+	Pred   []opId
+}
+
+type byteReader struct {
+	data []byte
+	pos  int
+	err  error
+}
+
+func (r *byteReader) remaining() bool {
+	return r.err == nil && r.pos < len(r.data)
+}
+
+func (r *byteReader) fail(message string) {
+	if r.err == nil {
+		r.err = errors.New(message)
+	}
+	r.pos = len(r.data)
+}
+
+func (r *byteReader) uleb() uint64 {
+	var value uint64
+	for shift := 0; shift < 64; shift += 7 {
+		if r.pos >= len(r.data) {
+			r.fail("unexpected end of data")
+			return 0
+		}
+		b := r.data[r.pos]
+		r.pos++
+		value |= uint64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			return value
+		}
+	}
+	r.fail("integer overflow")
+	return 0
+}
+
+func (r *byteReader) sleb() int64 {
+	var value int64
+	for shift := 0; shift < 64; shift += 7 {
+		if r.pos >= len(r.data) {
+			r.fail("unexpected end of data")
+			return 0
+		}
+		b := r.data[r.pos]
+		r.pos++
+		value |= int64(b&0x7f) << shift
+		if b&0x80 == 0 {
+			if shift+7 < 64 && b&0x40 != 0 {
+				value |= -1 << (shift + 7)
+			}
+			return value
+		}
+	}
+	r.fail("integer overflow")
+	return 0
+}
+
+func (r *byteReader) bytes(n uint64) []byte {
+	if n > uint64(len(r.data)-r.pos) {
+		r.fail("unexpected end of data")
+		return nil
+	}
+	out := r.data[r.pos : r.pos+int(n)]
+	r.pos += int(n)
+	return out
+}
+
+// parseChangeChunk decodes the header and operation columns of the raw bytes of a change as returned by Change.Save.
+func parseChangeChunk(raw []byte) (*changeChunk, error) {
+	if len(raw) < 9 || !bytes.Equal(raw[:4], changeChunkMagic) {
+		return nil, errors.New("not an automerge chunk")
+	} else if raw[8] != changeChunkType {
+		return nil, errors.Errorf("unsupported chunk type %d", raw[8])
+	}
+	r := &byteReader{data: raw, pos: 9}
+	length := r.uleb()
+	body := r.bytes(length)
+	if r.err != nil {
+		return nil, errors.Wrap(r.err, "invalid chunk")
+	} else if checksum := sha256.Sum256(raw[8:r.pos]); !bytes.Equal(checksum[:4], raw[4:8]) {
+		return nil, errors.New("invalid chunk checksum")
+	}
+
+	chunk := &changeChunk{columns: make(map[uint64][]byte)}
+	r = &byteReader{data: body}
+	r.bytes(r.uleb() * 32)
+	chunk.Actor = hex.EncodeToString(r.bytes(r.uleb()))
+	r.uleb()
+	chunk.StartOp = r.uleb()
+	r.sleb()
+	chunk.Message = string(r.bytes(r.uleb()))
+	for n := r.uleb(); n > 0 && r.err == nil; n-- {
+		chunk.OtherActors = append(chunk.OtherActors, hex.EncodeToString(r.bytes(r.uleb())))
+	}
+	type columnSpec struct{ spec, length uint64 }
+	specs := make([]columnSpec, 0)
+	for n := r.uleb(); n > 0 && r.err == nil; n-- {
+		specs = append(specs, columnSpec{spec: r.uleb(), length: r.uleb()})
+	}
+	for _, s := range specs {
+		if s.spec&columnDeflate != 0 {
+			return nil, errors.New("compressed columns are not supported")
+		}
+		chunk.columns[s.spec] = r.bytes(s.length)
+	}
+	if r.err != nil {
+		return nil, errors.Wrap(r.err, "invalid change")
+	}
+	return chunk, nil
+}
+
+func (c *changeChunk) actor(index uint64) (string, error) {
+	if index == 0 {
+		return c.Actor, nil
+	} else if index <= uint64(len(c.OtherActors)) {
+		return c.OtherActors[index-1], nil
+	}
+	return "", errors.Errorf("invalid actor index %d", index)
+}
+
+// decodeRLE decodes a run length encoded column, where nil entries are nulls.
+func decodeRLE[T any](data []byte, read func(*byteReader) T) ([]*T, error) {
+	r := &byteReader{data: data}
+	out := make([]*T, 0)
+	for r.remaining() {
+		n := r.sleb()
+		if n > maximumColumnLength-int64(len(out)) || -n > maximumColumnLength-int64(len(out)) {
+			return nil, errors.New("column is too long")
+		}
+		switch {
+		case n > 0:
+			v := read(r)
+			for i := int64(0); i < n; i++ {
+				out = append(out, &v)
+			}
+		case n < 0:
+			for i := int64(0); i < -n; i++ {
+				v := read(r)
+				out = append(out, &v)
+			}
+		default:
+			nulls := r.uleb()
+			if nulls > uint64(maximumColumnLength-len(out)) {
+				return nil, errors.New("column is too long")
+			}
+			for i := uint64(0); i < nulls; i++ {
+				out = append(out, nil)
+			}
+		}
+	}
+	return out, r.err
+}
+
+// decodeDelta decodes a delta encoded column into the absolute values.
+func decodeDelta(data []byte) ([]*int64, error) {
+	deltas, err := decodeRLE(data, (*byteReader).sleb)
+	var total int64
+	for i, d := range deltas {
+		if d != nil {
+			total += *d
+			v := total
+			deltas[i] = &v
+		}
+	}
+	return deltas, err
+}
+
+// decodeBooleans decodes a boolean column of alternating run lengths, starting with false.
+func decodeBooleans(data []byte) ([]bool, error) {
+	r := &byteReader{data: data}
+	out := make([]bool, 0)
+	for value := false; r.remaining(); value = !value {
+		n := r.uleb()
+		if n > uint64(maximumColumnLength-len(out)) {
+			return nil, errors.New("column is too long")
+		}
+		for i := uint64(0); i < n; i++ {
+			out = append(out, value)
+		}
+	}
+	return out, r.err
+}
+
+func readString(r *byteReader) string {
+	return string(r.bytes(r.uleb()))
+}
+
+// ops decodes the operations of the change. Values are not decoded.
+func (c *changeChunk) ops() ([]changeOp, error) {
+	objActors, err1 := decodeRLE(c.columns[columnObjActor], (*byteReader).uleb)
+	objCtrs, err2 := decodeRLE(c.columns[columnObjCtr], (*byteReader).uleb)
+	keys, err3 := decodeRLE(c.columns[columnKeyStr], readString)
+	inserts, err4 := decodeBooleans(c.columns[columnInsert])
+	actions, err5 := decodeRLE(c.columns[columnAction], (*byteReader).uleb)
+	predNums, err6 := decodeRLE(c.columns[columnPredNum], (*byteReader).uleb)
+	predActors, err7 := decodeRLE(c.columns[columnPredActor], (*byteReader).uleb)
+	predCtrs, err8 := decodeDelta(c.columns[columnPredCtr])
+	for _, err := range []error{err1, err2, err3, err4, err5, err6, err7, err8} {
+		if err != nil {
+			return nil, errors.Wrap(err, "invalid operation column")
+		}
+	}
+
+	output := make([]changeOp, len(actions))
+	predIndex := 0
+	for i, action := range actions {
+		op := changeOp{Id: opId{Counter: c.StartOp + uint64(i), Actor: c.Actor}}
+		if action == nil {
+			return nil, errors.Errorf("operation %d has no action", i)
+		}
+		op.Action = *action
+		if i < len(objCtrs) && objCtrs[i] != nil {
+			if i >= len(objActors) || objActors[i] == nil {
+				return nil, errors.Errorf("operation %d has no object actor", i)
+			}
+			actor, err := c.actor(*objActors[i])
+			if err != nil {
+				return nil, err
+			}
+			op.Obj = opId{Counter: *objCtrs[i], Actor: actor}
+		}
+		if i < len(keys) {
+			op.Key = keys[i]
+		}
+		op.Insert = i < len(inserts) && inserts[i]
+		if i < len(predNums) && predNums[i] != nil {
+			for j := uint64(0); j < *predNums[i]; j++ {
+				if predIndex >= len(predActors) || predIndex >= len(predCtrs) || predActors[predIndex] == nil || predCtrs[predIndex] == nil {
+					return nil, errors.Errorf("operation %d has an invalid predecessor", i)
+				}
+				actor, err := c.actor(*predActors[predIndex])
+				if err != nil {
+					return nil, err
+				}
+				op.Pred = append(op.Pred, opId{Counter: uint64(*predCtrs[predIndex]), Actor: actor})
+				predIndex++
+			}
+		}
+		output[i] = op
+	}
+	return output, nil
+}
+
+// mapOpIndex indexes the operations which write the keys of maps so that the values that are currently visible, and
+// therefore the conflicting values of a key, can be found without forking the document.
+type mapOpIndex struct {
+	writes     map[opId]map[string][]indexedOp
+	superseded map[opId]bool
+}
+
+type indexedOp struct {
+	Id     opId
+	Change *automerge.Change
+}
+
+func indexMapOps(doc *automerge.Doc) (*mapOpIndex, error) {
+	changes, err := doc.Changes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get changes")
+	}
+	index := &mapOpIndex{writes: make(map[opId]map[string][]indexedOp), superseded: make(map[opId]bool)}
+	for _, change := range changes {
+		chunk, err := parseChangeChunk(change.Save())
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode change %s", change.Hash())
+		}
+		ops, err := chunk.ops()
+		if err != nil {
+			return nil, errors.Wrapf(err, "failed to decode change %s", change.Hash())
+		}
+		for _, op := range ops {
+			// an increment refers to the counter it changes as a predecessor without replacing it
+			if op.Action == opActionIncrement {
+				continue
+			}
+			for _, pred := range op.Pred {
+				index.superseded[pred] = true
+			}
+			if op.Key != nil && op.Action != opActionDelete {
+				keys, ok := index.writes[op.Obj]
+				if !ok {
+					keys = make(map[string][]indexedOp)
+					index.writes[op.Obj] = keys
+				}
+				keys[*op.Key] = append(keys[*op.Key], indexedOp{Id: op.Id, Change: change})
+			}
+		}
+	}
+	return index, nil
+}
+
+// visible returns the writes to the key of the map object which have not been superseded, ordered so that the value
+// which automerge shows is last.
+func (x *mapOpIndex) visible(obj opId, key string) []indexedOp {
+	output := make([]indexedOp, 0)
+	for _, op := range x.writes[obj][key] {
+		if !x.superseded[op.Id] {
+			output = append(output, op)
+		}
+	}
+	slices.SortFunc(output, func(a, b indexedOp) int {
+		return a.Id.compare(b.Id)
+	})
+	return output
+}
+
+// object returns the id of the object which is shown at the key of the map object.
+func (x *mapOpIndex) object(obj opId, key string) (opId, bool) {
+	if v := x.visible(obj, key); len(v) > 0 {
+		return v[len(v)-1].Id, true
+	}
+	return opId{}, false
+}
+
+// keys returns the keys of the map object which currently have a value.
+func (x *mapOpIndex) keys(obj opId) []string {
+	output := make([]string, 0)
+	for key := range x.writes[obj] {
+		if len(x.visible(obj, key)) > 0 {
+			output = append(output, key)
+		}
+	}
+	return output
+}
@@ -0,0 +1,323 @@
+package au
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/automerge/automerge-go"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+)
+
+const MaximumChecklistItemLength = 200
+
+// ChecklistItem is a single step in the checklist of a todo. Items are stored in a map keyed by id rather than an
+// Automerge list so that moving an item only changes its position, and concurrent moves and ticks of the same item
+// merge instead of duplicating it.
+type ChecklistItem struct {
+	Id       string
+	Text     string
+	Done     bool
+	Position float64
+}
+
+type AddChecklistItemParams struct {
+	Text string
+	// Before optionally places the new item directly above the item with this id, otherwise it is added at the end.
+	Before    string
+	CreatedBy string
+}
+
+type EditChecklistItemParams struct {
+	Text *string
+	Done *bool
+	// Before and After optionally move the item directly above or below the item with the given id.
+	Before    string
+	After     string
+	UpdatedBy string
+}
+
+type DeleteChecklistItemParams struct {
+	DeletedBy string
+}
+
+func ValidateChecklistItemText(input string) (string, error) {
+	if pt, err := ValidateAndCleanUnicode(input, false); err != nil {
+		return "", errors.Wrap(err, "invalid checklist item")
+	} else if pt = strings.TrimSpace(pt); pt == "" {
+		return "", errors.New("checklist item cannot be empty")
+	} else if d := MaximumChecklistItemLength; len(pt) > d {
+		return "", errors.Errorf("checklist item is too long, it should be at most %d characters", d)
+	} else {
+		return pt, nil
+	}
+}
+
+// ChecklistProgress returns the number of done items and the total number of items.
+func ChecklistProgress(items []ChecklistItem) (int, int) {
+	done := 0
+	for _, item := range items {
+		if item.Done {
+			done++
+		}
+	}
+	return done, len(items)
+}
+
+func (p *inMemoryWorkspaceProvider) AddChecklistItem(ctx context.Context, todoId string, params AddChecklistItemParams) (*ChecklistItem, error) {
+	text, err := ValidateChecklistItemText(params.Text)
+	if err != nil {
+		return nil, err
+	} else if err := ValidatedAuthor(params.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	todoValue, err := p.Doc.Path("todos").Map().Get(todoId)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get todo")
+	} else if todoValue.Kind() != automerge.KindMap {
+		return nil, errors.Errorf("todo with id '%s' does not exist", todoId)
+	}
+	items := checklistInner(todoValue.Map())
+	i := len(items)
+	if params.Before != "" {
+		if i = slices.IndexFunc(items, func(item ChecklistItem) bool { return item.Id == params.Before }); i < 0 {
+			return nil, errors.Errorf("checklist item with id '%s' does not exist", params.Before)
+		}
+	}
+
+	checklistValue, _ := todoValue.Map().Get("checklist")
+	if checklistValue.Kind() != automerge.KindMap {
+		if err := todoValue.Map().Set("checklist", automerge.NewMap()); err != nil {
+			return nil, errors.Wrap(err, "failed to set checklist")
+		}
+		checklistValue, _ = todoValue.Map().Get("checklist")
+	}
+	position, ok := checklistPosition(items, i)
+	if !ok {
+		// there is no room between the neighbours, so spread all the positions out again and retry
+		if err := rebalanceChecklistInner(checklistValue.Map(), items); err != nil {
+			return nil, err
+		}
+		position, _ = checklistPosition(checklistInner(todoValue.Map()), i)
+	}
+	item := ChecklistItem{Id: ulid.Make().String(), Text: text, Position: position}
+	newItem := automerge.NewMap()
+	if err := checklistValue.Map().Set(item.Id, newItem); err != nil {
+		return nil, errors.Wrap(err, "failed to set checklist item")
+	} else if err := newItem.Set("text", item.Text); err != nil {
+		return nil, errors.Wrap(err, "failed to set text")
+	} else if err := newItem.Set("done", false); err != nil {
+		return nil, errors.Wrap(err, "failed to set done")
+	} else if err := newItem.Set("position", item.Position); err != nil {
+		return nil, errors.Wrap(err, "failed to set position")
+	}
+	if err := touchTodoInner(todoValue.Map(), params.CreatedBy); err != nil {
+		return nil, err
+	}
+
+	if _, err := p.commit(params.CreatedBy + " edited checklist of todo " + todoId); err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
+	return &item, nil
+}
+
+// EditChecklistItem changes the text or done flag of an item, or moves it relative to another item.
+func (p *inMemoryWorkspaceProvider) EditChecklistItem(ctx context.Context, todoId, itemId string, params EditChecklistItemParams) (*ChecklistItem, error) {
+	if params.Text != nil {
+		o, err := ValidateChecklistItemText(*params.Text)
+		if err != nil {
+			return nil, err
+		}
+		params.Text = &o
+	}
+	if params.Before != "" && params.After != "" {
+		return nil, errors.New("only one of before or after may be set")
+	} else if params.Before == itemId || params.After == itemId {
+		return nil, errors.New("cannot move a checklist item relative to itself")
+	} else if err := ValidatedAuthor(params.UpdatedBy); err != nil {
+		return nil, err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	todoValue, err := p.Doc.Path("todos").Map().Get(todoId)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get todo")
+	} else if todoValue.Kind() != automerge.KindMap {
+		return nil, errors.Errorf("todo with id '%s' does not exist", todoId)
+	}
+	itemValue, err := checklistItemValueInner(todoValue.Map(), itemId)
+	if err != nil {
+		return nil, err
+	}
+
+	if params.Text != nil {
+		if err := itemValue.Map().Set("text", *params.Text); err != nil {
+			return nil, errors.Wrap(err, "failed to set text")
+		}
+	}
+	if params.Done != nil {
+		if err := itemValue.Map().Set("done", *params.Done); err != nil {
+			return nil, errors.Wrap(err, "failed to set done")
+		}
+	}
+	if target := params.Before + params.After; target != "" {
+		others := func() []ChecklistItem {
+			return slices.DeleteFunc(checklistInner(todoValue.Map()), func(item ChecklistItem) bool { return item.Id == itemId })
+		}
+		items := others()
+		i := slices.IndexFunc(items, func(item ChecklistItem) bool { return item.Id == target })
+		if i < 0 {
+			return nil, errors.Errorf("checklist item with id '%s' does not exist", target)
+		} else if params.After != "" {
+			i++
+		}
+		position, ok := checklistPosition(items, i)
+		if !ok {
+			checklistValue, _ := todoValue.Map().Get("checklist")
+			if err := rebalanceChecklistInner(checklistValue.Map(), items); err != nil {
+				return nil, err
+			}
+			position, _ = checklistPosition(others(), i)
+		}
+		if err := itemValue.Map().Set("position", position); err != nil {
+			return nil, errors.Wrap(err, "failed to set position")
+		}
+	}
+	if err := touchTodoInner(todoValue.Map(), params.UpdatedBy); err != nil {
+		return nil, err
+	}
+
+	if _, err := p.commit(params.UpdatedBy+" edited checklist of todo "+todoId, automerge.CommitOptions{AllowEmpty: true}); err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
+	items := checklistInner(todoValue.Map())
+	i := slices.IndexFunc(items, func(item ChecklistItem) bool { return item.Id == itemId })
+	return &items[i], nil
+}
+
+func (p *inMemoryWorkspaceProvider) DeleteChecklistItem(ctx context.Context, todoId, itemId string, params DeleteChecklistItemParams) error {
+	if err := ValidatedAuthor(params.DeletedBy); err != nil {
+		return err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	todoValue, err := p.Doc.Path("todos").Map().Get(todoId)
+	if err != nil {
+		return errors.Wrap(err, "failed to get todo")
+	} else if todoValue.Kind() != automerge.KindMap {
+		return errors.Errorf("todo with id '%s' does not exist", todoId)
+	}
+	if _, err := checklistItemValueInner(todoValue.Map(), itemId); err != nil {
+		return err
+	}
+	checklistValue, _ := todoValue.Map().Get("checklist")
+	if err := checklistValue.Map().Delete(itemId); err != nil {
+		return errors.Wrap(err, "failed to delete checklist item")
+	} else if err := touchTodoInner(todoValue.Map(), params.DeletedBy); err != nil {
+		return err
+	}
+
+	if _, err := p.commit(params.DeletedBy + " edited checklist of todo " + todoId); err != nil {
+		return errors.Wrap(err, "failed to commit")
+	}
+	return nil
+}
+
+func checklistItemValueInner(todo *automerge.Map, itemId string) (*automerge.Value, error) {
+	if checklistValue, _ := todo.Get("checklist"); checklistValue.Kind() == automerge.KindMap {
+		if itemValue, _ := checklistValue.Map().Get(itemId); itemValue.Kind() == automerge.KindMap {
+			return itemValue, nil
+		}
+	}
+	return nil, errors.Errorf("checklist item with id '%s' does not exist", itemId)
+}
+
+// checklistInner returns the items of the checklist ordered by position. Items with the same position, which may be
+// produced by concurrent moves, are ordered by id so that every peer shows the same order.
+func checklistInner(todo *automerge.Map) []ChecklistItem {
+	output := make([]ChecklistItem, 0)
+	checklistValue, _ := todo.Get("checklist")
+	if checklistValue.Kind() != automerge.KindMap {
+		return output
+	}
+	ids, _ := checklistValue.Map().Keys()
+	for _, id := range ids {
+		itemValue, _ := checklistValue.Map().Get(id)
+		if itemValue.Kind() != automerge.KindMap {
+			continue
+		}
+		item := ChecklistItem{Id: id}
+		if v, _ := itemValue.Map().Get("text"); v.Kind() == automerge.KindStr {
+			item.Text = v.Str()
+		}
+		if v, _ := itemValue.Map().Get("done"); v.Kind() == automerge.KindBool {
+			item.Done = v.Bool()
+		}
+		if v, _ := itemValue.Map().Get("position"); v.Kind() == automerge.KindFloat64 {
+			item.Position = v.Float64()
+		}
+		output = append(output, item)
+	}
+	slices.SortStableFunc(output, func(a, b ChecklistItem) int {
+		if a.Position < b.Position {
+			return -1
+		} else if a.Position > b.Position {
+			return 1
+		}
+		return strings.Compare(a.Id, b.Id)
+	})
+	return output
+}
+
+// checklistPosition returns a position that places an item at index i of the ordered items. The second return value is
+// false if there is no room between the neighbouring positions, either because repeated moves into the same gap have
+// exhausted the precision or because concurrent moves gave both neighbours the same position.
+func checklistPosition(items []ChecklistItem, i int) (float64, bool) {
+	switch {
+	case len(items) == 0:
+		return 0, true
+	case i <= 0:
+		return math.Floor(items[0].Position) - 1, true
+	case i >= len(items):
+		return math.Ceil(items[len(items)-1].Position) + 1, true
+	}
+	above, below := items[i-1].Position, items[i].Position
+	mid := above + (below-above)/2
+	return mid, below > above && mid > above && mid < below
+}
+
+// rebalanceChecklistInner sets the positions of the ordered items to 0 up to len(items) - 1. Positions that already
+// have the right value are not rewritten.
+func rebalanceChecklistInner(checklist *automerge.Map, items []ChecklistItem) error {
+	for i, item := range items {
+		if item.Position == float64(i) {
+			continue
+		}
+		itemValue, _ := checklist.Get(item.Id)
+		if err := itemValue.Map().Set("position", float64(i)); err != nil {
+			return errors.Wrap(err, "failed to set position")
+		}
+	}
+	return nil
+}
+
+// touchTodoInner stamps the updated_at and updated_by fields of the todo.
+func touchTodoInner(todo *automerge.Map, updatedBy string) error {
+	if err := todo.Set("updated_at", time.Now().UTC().Truncate(time.Second)); err != nil {
+		return errors.Wrap(err, "failed to set updated_at")
+	} else if err := todo.Set("updated_by", updatedBy); err != nil {
+		return errors.Wrap(err, "failed to set updated_by")
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package au
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aurelian-one/au/internal"
+)
+
+func checklistTexts(todo *Todo) []string {
+	output := make([]string, len(todo.Checklist))
+	for i, item := range todo.Checklist {
+		output[i] = item.Text
+	}
+	return output
+}
+
+func TestChecklist(t *testing.T) {
+	wsA := newTestWorkspace(t)
+	doc := wsA.Doc
+	author := "Alice <alice@me.com>"
+	ctx := context.Background()
+
+	td, err := wsA.CreateTodo(ctx, CreateTodoParams{Title: "Do the thing", CreatedBy: author})
+	assert.NoError(t, err)
+	assert.Empty(t, td.Checklist)
+
+	_, err = wsA.AddChecklistItem(ctx, td.Id, AddChecklistItemParams{Text: "  ", CreatedBy: author})
+	assert.EqualError(t, err, "checklist item cannot be empty")
+	first, err := wsA.AddChecklistItem(ctx, td.Id, AddChecklistItemParams{Text: "First", CreatedBy: author})
+	assert.NoError(t, err)
+	third, err := wsA.AddChecklistItem(ctx, td.Id, AddChecklistItemParams{Text: "Third", CreatedBy: author})
+	assert.NoError(t, err)
+	second, err := wsA.AddChecklistItem(ctx, td.Id, AddChecklistItemParams{Text: "Second", Before: third.Id, CreatedBy: author})
+	assert.NoError(t, err)
+	td, _ = wsA.GetTodo(ctx, td.Id)
+	assert.Equal(t, []string{"First", "Second", "Third"}, checklistTexts(td))
+
+	forked, err := doc.Fork()
+	assert.NoError(t, err)
+	wsB := NewInMemoryWorkspaceProvider(forked)
+
+	// concurrently tick the first item on one peer while moving it to the end and renaming it on the other
+	_, err = wsA.EditChecklistItem(ctx, td.Id, first.Id, EditChecklistItemParams{Done: internal.Ref(true), UpdatedBy: author})
+	assert.NoError(t, err)
+	_, err = wsB.EditChecklistItem(ctx, td.Id, first.Id, EditChecklistItemParams{
+		Text: internal.Ref("First, but last"), After: third.Id, UpdatedBy: "Bob <bob@me.com>",
+	})
+	assert.NoError(t, err)
+	_, err = doc.Merge(forked)
+	assert.NoError(t, err)
+
+	td, _ = wsA.GetTodo(ctx, td.Id)
+	assert.Equal(t, []string{"Second", "Third", "First, but last"}, checklistTexts(td))
+	assert.True(t, td.Checklist[2].Done)
+	done, total := ChecklistProgress(td.Checklist)
+	assert.Equal(t, 1, done)
+	assert.Equal(t, 3, total)
+
+	_, err = wsA.EditChecklistItem(ctx, td.Id, second.Id, EditChecklistItemParams{Before: second.Id, UpdatedBy: author})
+	assert.EqualError(t, err, "cannot move a checklist item relative to itself")
+	assert.NoError(t, wsA.DeleteChecklistItem(ctx, td.Id, second.Id, DeleteChecklistItemParams{DeletedBy: author}))
+	assert.EqualError(t, wsA.DeleteChecklistItem(ctx, td.Id, second.Id, DeleteChecklistItemParams{DeletedBy: author}), "checklist item with id '"+second.Id+"' does not exist")
+	td, _ = wsA.GetTodo(ctx, td.Id)
+	assert.Equal(t, []string{"Third", "First, but last"}, checklistTexts(td))
+
+	// repeatedly moving into the same gap exhausts the precision of the positions, which are then spread out again
+	last, err := wsA.AddChecklistItem(ctx, td.Id, AddChecklistItemParams{Text: "Last", CreatedBy: author})
+	assert.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		moved := []string{third.Id, first.Id}[i%2]
+		_, err = wsA.EditChecklistItem(ctx, td.Id, moved, EditChecklistItemParams{Before: last.Id, UpdatedBy: author})
+		assert.NoError(t, err)
+	}
+	td, _ = wsA.GetTodo(ctx, td.Id)
+	assert.Equal(t, []string{"Third", "First, but last", "Last"}, checklistTexts(td))
+	assert.Less(t, td.Checklist[0].Position, td.Checklist[1].Position)
+	assert.Less(t, td.Checklist[1].Position, td.Checklist[2].Position)
+}
@@ -0,0 +1,180 @@
+package au
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+const resolveMessageInfix = " resolved conflict on "
+
+// TodoConflict describes a field of a Todo that was written concurrently by multiple peers where neither write has
+// been superseded by a later write, so that Automerge keeps all the written values. The current value of the field is
+// one of the Values, chosen deterministically by Automerge. Concurrent edits to the text of the title are merged
+// rather than kept as conflicting values.
+type TodoConflict struct {
+	Field  string
+	Values []ConflictValue
+}
+
+// ConflictValue is one of the concurrently written values of a TodoConflict.
+type ConflictValue struct {
+	Value   string
+	Author  string
+	ActorId string
+	Hash    string
+	At      time.Time
+}
+
+type ResolveTodoConflictParams struct {
+	// Field is the conflicting field, either title, status, or annotations/<key>.
+	Field      string
+	Value      string
+	ResolvedBy string
+}
+
+// isConflictableField returns whether the field of a todo is replaced as a whole when written. The description is
+// excluded since concurrent edits to it are merged character by character.
+func isConflictableField(field string) bool {
+	return field == "title" || field == "status" || strings.HasPrefix(field, "annotations/")
+}
+
+func (p *inMemoryWorkspaceProvider) ListTodoConflicts(ctx context.Context, todoId string) ([]TodoConflict, error) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	if _, err := getTodoInner(p.Doc.Path("todos").Map(), todoId); err != nil {
+		return nil, err
+	}
+	return getTodoConflictsInner(p.Doc, todoId)
+}
+
+// getTodoConflictsInner finds the conflicts from the operations of the document. The value of each conflicting write
+// is read from the document as of the change that wrote it, so only conflicts require forking the document.
+func getTodoConflictsInner(doc *automerge.Doc, todoId string) ([]TodoConflict, error) {
+	index, err := indexMapOps(doc)
+	if err != nil {
+		return nil, err
+	}
+	todosObj, ok := index.object(opId{}, "todos")
+	if !ok {
+		return nil, errors.New("workspace has no todos")
+	}
+	todoObj, ok := index.object(todosObj, todoId)
+	if !ok {
+		return nil, errors.Errorf("todo with id '%s' does not exist", todoId)
+	}
+	fields := map[string][]indexedOp{
+		"title":  index.visible(todoObj, "title"),
+		"status": index.visible(todoObj, "status"),
+	}
+	if annotationsObj, ok := index.object(todoObj, "annotations"); ok {
+		for _, key := range index.keys(annotationsObj) {
+			fields["annotations/"+key] = index.visible(annotationsObj, key)
+		}
+	}
+
+	output := make([]TodoConflict, 0)
+	for field, writes := range fields {
+		if len(writes) < 2 {
+			continue
+		}
+		conflict := TodoConflict{Field: field}
+		for _, w := range writes {
+			value, err := fieldValueAtChange(doc, w.Change, todoId, field)
+			if err != nil {
+				return nil, err
+			}
+			conflict.Values = append(conflict.Values, ConflictValue{
+				Value:   value,
+				Author:  commitAuthor(w.Change.Message()),
+				ActorId: w.Change.ActorID(),
+				Hash:    w.Change.Hash().String(),
+				At:      w.Change.Timestamp().In(time.UTC),
+			})
+		}
+		output = append(output, conflict)
+	}
+	slices.SortFunc(output, func(a, b TodoConflict) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+	return output, nil
+}
+
+// fieldValueAtChange returns the value of the todo field in the document as of the given change.
+func fieldValueAtChange(doc *automerge.Doc, change *automerge.Change, todoId, field string) (string, error) {
+	at, err := doc.Fork(change.Hash())
+	if err != nil {
+		return "", errors.Wrap(err, "failed to fork at change")
+	}
+	path := []any{"todos", todoId}
+	for _, part := range strings.SplitN(field, "/", 2) {
+		path = append(path, part)
+	}
+	v, err := at.Path(path...).Get()
+	if err != nil {
+		return "", errors.Wrapf(err, "failed to get %s", field)
+	}
+	return leafString(v), nil
+}
+
+// ResolveTodoConflict writes the chosen value to a conflicting field. Because the new change depends on all the
+// conflicting writes, it supersedes them on every peer once synchronised.
+func (p *inMemoryWorkspaceProvider) ResolveTodoConflict(ctx context.Context, todoId string, params ResolveTodoConflictParams) (*Todo, error) {
+	if err := ValidatedAuthor(params.ResolvedBy); err != nil {
+		return nil, err
+	}
+	if !isConflictableField(params.Field) {
+		return nil, errors.Errorf("field '%s' cannot be resolved, expected title, status, or annotations/<key>", params.Field)
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	todos := p.Doc.Path("todos").Map()
+	updatedAt := time.Now().UTC().Truncate(time.Second)
+	if params.Field == "title" {
+		title, err := ValidateTodoTitle(params.Value)
+		if err != nil {
+			return nil, err
+		} else if _, err := getTodoInner(todos, todoId); err != nil {
+			return nil, err
+		}
+		// the title is replaced rather than spliced, since conflicting titles are separate text objects
+		todoValue, _ := todos.Get(todoId)
+		if err := todoValue.Map().Set("title", automerge.NewText(title)); err != nil {
+			return nil, errors.Wrap(err, "failed to set title")
+		} else if err := todoValue.Map().Set("updated_at", updatedAt); err != nil {
+			return nil, errors.Wrap(err, "failed to set updated_at")
+		} else if err := todoValue.Map().Set("updated_by", params.ResolvedBy); err != nil {
+			return nil, errors.Wrap(err, "failed to set updated_by")
+		}
+	} else {
+		// status and annotations are written the same way as by EditTodo, so that workflows and schemas apply
+		edit := EditTodoParams{UpdatedBy: params.ResolvedBy}
+		if params.Field == "status" {
+			edit.Status = &params.Value
+		} else {
+			edit.Annotations = map[string]string{strings.TrimPrefix(params.Field, "annotations/"): params.Value}
+		}
+		edit, err := validateEditTodoParams(edit)
+		if err != nil {
+			return nil, err
+		}
+		_, prepared, err := prepareEditTodoInner(p.Doc, todos, todoId, edit)
+		if err != nil {
+			return nil, err
+		} else if err := applyEditTodoInner(todos, todoId, prepared, updatedAt); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := p.commit(params.ResolvedBy + resolveMessageInfix + params.Field + " in todo " + todoId); err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
+	return getTodoInner(todos, todoId)
+}
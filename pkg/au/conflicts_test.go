@@ -0,0 +1,105 @@
+package au
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aurelian-one/au/internal"
+)
+
+func TestTodoConflicts(t *testing.T) {
+	wsA := newTestWorkspace(t)
+	doc := wsA.Doc
+	td, err := wsA.CreateTodo(context.Background(), CreateTodoParams{Title: "Do the thing", CreatedBy: "Alice <alice@me.com>"})
+	assert.NoError(t, err)
+
+	forked, err := doc.Fork()
+	assert.NoError(t, err)
+	wsB := NewInMemoryWorkspaceProvider(forked)
+
+	_, err = wsA.EditTodo(context.Background(), td.Id, EditTodoParams{Status: internal.Ref("closed"), UpdatedBy: "Alice <alice@me.com>"})
+	assert.NoError(t, err)
+	_, err = wsB.EditTodo(context.Background(), td.Id, EditTodoParams{
+		Status: internal.Ref("closed"), Annotations: map[string]string{"about:blank#a": "b"}, UpdatedBy: "Bob <bob@me.com>",
+	})
+	assert.NoError(t, err)
+	_, err = wsB.EditTodo(context.Background(), td.Id, EditTodoParams{Status: internal.Ref("open"), UpdatedBy: "Bob <bob@me.com>"})
+	assert.NoError(t, err)
+	_, err = doc.Merge(forked)
+	assert.NoError(t, err)
+
+	conflicts, err := wsA.ListTodoConflicts(context.Background(), td.Id)
+	assert.NoError(t, err)
+	if assert.Len(t, conflicts, 1) {
+		assert.Equal(t, "status", conflicts[0].Field)
+		values := make(map[string]string)
+		for _, v := range conflicts[0].Values {
+			values[v.Author] = v.Value
+			assert.NotEmpty(t, v.ActorId)
+		}
+		assert.Equal(t, map[string]string{"Alice <alice@me.com>": "closed", "Bob <bob@me.com>": "open"}, values)
+	}
+
+	_, err = wsA.ResolveTodoConflict(context.Background(), td.Id, ResolveTodoConflictParams{Field: "description", Value: "x", ResolvedBy: "Alice <alice@me.com>"})
+	assert.ErrorContains(t, err, "field 'description' cannot be resolved")
+
+	// resolving with the value that is already visible still clears the conflict
+	current, _ := wsA.GetTodo(context.Background(), td.Id)
+	resolved, err := wsA.ResolveTodoConflict(context.Background(), td.Id, ResolveTodoConflictParams{Field: "status", Value: current.Status, ResolvedBy: "Alice <alice@me.com>"})
+	assert.NoError(t, err)
+	assert.Equal(t, current.Status, resolved.Status)
+
+	conflicts, err = wsA.ListTodoConflicts(context.Background(), td.Id)
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+}
+
+func TestTodoConflicts_same_value_and_text(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsA, _ := s.OpenWorkspace(context.Background(), ws.Id, true)
+	td, err := wsA.CreateTodo(context.Background(), CreateTodoParams{Title: "Do the thing", CreatedBy: "Alice <alice@me.com>"})
+	assert.NoError(t, err)
+	doc := wsA.(DocProvider).GetDoc()
+	forked, err := doc.Fork()
+	assert.NoError(t, err)
+	wsB := NewInMemoryWorkspaceProvider(forked)
+
+	// both peers close the todo and edit the text of the title
+	_, err = wsA.EditTodo(context.Background(), td.Id, EditTodoParams{Title: internal.Ref("Do the thing now"), Status: internal.Ref("closed"), UpdatedBy: "Alice <alice@me.com>"})
+	assert.NoError(t, err)
+	_, err = wsB.EditTodo(context.Background(), td.Id, EditTodoParams{Title: internal.Ref("Really do the thing"), Status: internal.Ref("closed"), UpdatedBy: "Bob <bob@me.com>"})
+	assert.NoError(t, err)
+	_, err = doc.Merge(forked)
+	assert.NoError(t, err)
+
+	// the text edits merge, while both writes of the same status are kept by automerge
+	conflicts, err := wsA.ListTodoConflicts(context.Background(), td.Id)
+	assert.NoError(t, err)
+	if assert.Len(t, conflicts, 1) {
+		assert.Equal(t, "status", conflicts[0].Field)
+		authors := make([]string, 0)
+		for _, v := range conflicts[0].Values {
+			assert.Equal(t, "closed", v.Value)
+			authors = append(authors, v.Author)
+		}
+		assert.ElementsMatch(t, []string{"Alice <alice@me.com>", "Bob <bob@me.com>"}, authors)
+	}
+
+	// the resolution goes through the workflow statuses like an edit
+	_, err = wsA.SetWorkflowStatus(context.Background(), SetWorkflowStatusParams{Name: "wont-do", Category: "closed", UpdatedBy: "Alice <alice@me.com>"})
+	assert.NoError(t, err)
+	resolved, err := wsA.ResolveTodoConflict(context.Background(), td.Id, ResolveTodoConflictParams{Field: "status", Value: "wont-do", ResolvedBy: "Alice <alice@me.com>"})
+	assert.NoError(t, err)
+	assert.Equal(t, "closed", resolved.Status)
+	statuses, _ := wsA.ListWorkflowStatuses(context.Background())
+	assert.Equal(t, "wont-do", TodoWorkflowStatus(resolved, statuses))
+	_, err = wsA.ResolveTodoConflict(context.Background(), td.Id, ResolveTodoConflictParams{Field: "status", Value: "unknown", ResolvedBy: "Alice <alice@me.com>"})
+	assert.Error(t, err)
+
+	conflicts, err = wsA.ListTodoConflicts(context.Background(), td.Id)
+	assert.NoError(t, err)
+	assert.Empty(t, conflicts)
+}
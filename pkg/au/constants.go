@@ -4,3 +4,16 @@ const ReservedAnnotationHostname = "aurelian.one"
 const ReservedAnnotationShortHostname = "aurelian"
 
 const AurelianRankAnnotation = "https://" + ReservedAnnotationHostname + "/annotations/rank"
+const AurelianParentAnnotation = "https://" + ReservedAnnotationHostname + "/annotations/parent"
+const AurelianBlockedByAnnotation = "https://" + ReservedAnnotationHostname + "/annotations/blocked-by"
+const AurelianLabelAnnotation = "https://" + ReservedAnnotationHostname + "/annotations/label"
+const AurelianDueAnnotation = "https://" + ReservedAnnotationHostname + "/annotations/due"
+const AurelianStartAnnotation = "https://" + ReservedAnnotationHostname + "/annotations/start"
+const AurelianHideUntilAnnotation = "https://" + ReservedAnnotationHostname + "/annotations/hide-until"
+const AurelianAssigneeAnnotation = "https://" + ReservedAnnotationHostname + "/annotations/assignee"
+const AurelianWorkflowStatusAnnotation = "https://" + ReservedAnnotationHostname + "/annotations/workflow-status"
+const AurelianStatusReasonAnnotation = "https://" + ReservedAnnotationHostname + "/annotations/status-reason"
+const AurelianOriginAnnotation = "https://" + ReservedAnnotationHostname + "/annotations/origin"
+const AurelianRecurrenceAnnotation = "https://" + ReservedAnnotationHostname + "/annotations/recurrence"
+const AurelianPreviousAnnotation = "https://" + ReservedAnnotationHostname + "/annotations/previous"
+const AurelianNextAnnotation = "https://" + ReservedAnnotationHostname + "/annotations/next"
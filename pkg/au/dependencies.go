@@ -0,0 +1,130 @@
+package au
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+// BlockedByAnnotationKey returns the annotation key which marks a todo as blocked by the todo with the given id.
+func BlockedByAnnotationKey(blockerId string) string {
+	return AurelianBlockedByAnnotation + "#" + blockerId
+}
+
+// TodoBlockerIds returns the ids of the todos that block the given todo, sorted. Some may no longer exist.
+func TodoBlockerIds(todo *Todo) []string {
+	output := blockerIdsFromAnnotations(todo.Annotations)
+	slices.Sort(output)
+	return output
+}
+
+func blockerIdsFromAnnotations(annotations map[string]string) []string {
+	output := make([]string, 0)
+	for k, v := range annotations {
+		if id, ok := strings.CutPrefix(k, AurelianBlockedByAnnotation+"#"); ok && v != "" && id != "" {
+			output = append(output, id)
+		}
+	}
+	return output
+}
+
+// ListTodoBlockers returns the existing todos that block the given todo.
+func (p *inMemoryWorkspaceProvider) ListTodoBlockers(ctx context.Context, id string) ([]Todo, error) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	todos := p.Doc.Path("todos").Map()
+	td, err := getTodoInner(todos, id)
+	if err != nil {
+		return nil, err
+	}
+	output := make([]Todo, 0)
+	for _, blockerId := range TodoBlockerIds(td) {
+		if blocker, err := getTodoInner(todos, blockerId); err == nil {
+			output = append(output, *blocker)
+		}
+	}
+	return output, nil
+}
+
+func blockerIdsInner(todos *automerge.Map, id string) []string {
+	v, _ := todos.Get(id)
+	if v.Kind() != automerge.KindMap {
+		return nil
+	}
+	annotationsValue, _ := v.Map().Get("annotations")
+	if annotationsValue.Kind() != automerge.KindMap {
+		return nil
+	}
+	keys, _ := annotationsValue.Map().Keys()
+	annotations := make(map[string]string, len(keys))
+	for _, k := range keys {
+		if x, _ := annotationsValue.Map().Get(k); x.Kind() == automerge.KindStr {
+			annotations[k] = x.Str()
+		}
+	}
+	return blockerIdsFromAnnotations(annotations)
+}
+
+// validateBlockersInner checks that each new blocker exists and that the todo is not already transitively blocking it,
+// which would create a cycle. The id may be empty when the todo is being created.
+func validateBlockersInner(todos *automerge.Map, id string, annotations map[string]string) error {
+	for _, blockerId := range blockerIdsFromAnnotations(annotations) {
+		if v, _ := todos.Get(blockerId); v.Kind() != automerge.KindMap {
+			return errors.Errorf("blocking todo with id '%s' does not exist", blockerId)
+		} else if blockerId == id {
+			return errors.Errorf("todo '%s' cannot block itself", id)
+		} else if id != "" && isBlockedByInner(todos, blockerId, id) {
+			return errors.Errorf("todo '%s' cannot be blocked by '%s' since it would create a cycle", id, blockerId)
+		}
+	}
+	return nil
+}
+
+// isBlockedByInner returns whether the todo is transitively blocked by the target.
+func isBlockedByInner(todos *automerge.Map, id, target string) bool {
+	seen := make(map[string]bool)
+	queue := []string{id}
+	for len(queue) > 0 {
+		next := queue[0]
+		queue = queue[1:]
+		if seen[next] {
+			continue
+		}
+		seen[next] = true
+		for _, blockerId := range blockerIdsInner(todos, next) {
+			if blockerId == target {
+				return true
+			}
+			queue = append(queue, blockerId)
+		}
+	}
+	return false
+}
+
+// unblockDependentsInner removes the blocked-by annotations that reference the todo from all other todos. This is used
+// when the todo is deleted.
+func unblockDependentsInner(todos *automerge.Map, id, updatedBy string) error {
+	key := BlockedByAnnotationKey(id)
+	updatedAt := time.Now().UTC().Truncate(time.Second)
+	todoIds, _ := todos.Keys()
+	for _, todoId := range todoIds {
+		if todoId == id || !slices.Contains(blockerIdsInner(todos, todoId), id) {
+			continue
+		}
+		todoValue, _ := todos.Get(todoId)
+		annotationsValue, _ := todoValue.Map().Get("annotations")
+		if err := annotationsValue.Map().Delete(key); err != nil {
+			return errors.Wrap(err, "failed to delete blocked-by annotation")
+		} else if err := todoValue.Map().Set("updated_at", updatedAt); err != nil {
+			return errors.Wrap(err, "failed to set updated_at")
+		} else if err := todoValue.Map().Set("updated_by", updatedBy); err != nil {
+			return errors.Wrap(err, "failed to set updated_by")
+		}
+	}
+	return nil
+}
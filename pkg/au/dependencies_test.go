@@ -0,0 +1,48 @@
+package au
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTodoBlockers(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, true)
+	author := "Example <email@me.com>"
+
+	_, err := wsp.CreateTodo(context.Background(), CreateTodoParams{
+		Title: "Blocked", Annotations: map[string]string{BlockedByAnnotationKey("unknown"): "true"}, CreatedBy: author,
+	})
+	assert.EqualError(t, err, "blocking todo with id 'unknown' does not exist")
+
+	a, err := wsp.CreateTodo(context.Background(), CreateTodoParams{Title: "First", CreatedBy: author})
+	assert.NoError(t, err)
+	b, err := wsp.CreateTodo(context.Background(), CreateTodoParams{
+		Title: "Second", Annotations: map[string]string{BlockedByAnnotationKey(a.Id): "true"}, CreatedBy: author,
+	})
+	assert.NoError(t, err)
+	c, err := wsp.CreateTodo(context.Background(), CreateTodoParams{
+		Title: "Third", Annotations: map[string]string{BlockedByAnnotationKey(b.Id): "true"}, CreatedBy: author,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{b.Id}, TodoBlockerIds(c))
+
+	_, err = wsp.EditTodo(context.Background(), a.Id, EditTodoParams{Annotations: map[string]string{BlockedByAnnotationKey(a.Id): "true"}, UpdatedBy: author})
+	assert.EqualError(t, err, "todo '"+a.Id+"' cannot block itself")
+	_, err = wsp.EditTodo(context.Background(), a.Id, EditTodoParams{Annotations: map[string]string{BlockedByAnnotationKey(c.Id): "true"}, UpdatedBy: author})
+	assert.EqualError(t, err, "todo '"+a.Id+"' cannot be blocked by '"+c.Id+"' since it would create a cycle")
+
+	blockers, err := wsp.ListTodoBlockers(context.Background(), c.Id)
+	assert.NoError(t, err)
+	if assert.Len(t, blockers, 1) {
+		assert.Equal(t, b.Id, blockers[0].Id)
+	}
+
+	assert.NoError(t, wsp.DeleteTodo(context.Background(), b.Id, DeleteTodoParams{DeletedBy: author}))
+	c, err = wsp.GetTodo(context.Background(), c.Id)
+	assert.NoError(t, err)
+	assert.Empty(t, TodoBlockerIds(c))
+}
@@ -82,6 +82,13 @@ func (d *directoryStorage) CreateWorkspace(ctx context.Context, params CreateWor
 	if err != nil {
 		return nil, err
 	}
+	message := "created workspace"
+	if params.CreatedBy != "" {
+		if err := ValidatedAuthor(params.CreatedBy); err != nil {
+			return nil, err
+		}
+		message = params.CreatedBy + " " + message
+	}
 
 	var chosenId string
 	for i := 0; i < 20; i++ {
@@ -103,6 +110,14 @@ func (d *directoryStorage) CreateWorkspace(ctx context.Context, params CreateWor
 	createdAt := time.Now().UTC().Truncate(time.Second).Local()
 	_ = doc.Path("created_at").Set(createdAt)
 	_ = doc.Path("todos").Set(automerge.NewMap())
+	if _, err := migrateSettingsInner(doc); err != nil {
+		return nil, err
+	}
+	// the change is committed without a timestamp so that the workspace exists as of any time
+	provider := &inMemoryWorkspaceProvider{Doc: doc, SigningKeys: d.signingKey}
+	if _, err := provider.commit(message, automerge.CommitOptions{Time: &time.Time{}}); err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
 
 	content := doc.Save()
 	path := filepath.Join(d.Path, chosenId+Suffix)
@@ -223,12 +238,46 @@ func (d *directoryStorage) OpenWorkspace(ctx context.Context, id string, writeab
 
 	provider := &directoryStorageWorkspace{
 		Path: path, Unlocker: unlocker, Logger: d.Logger.With("ws", id),
-		Doc: &inMemoryWorkspaceProvider{Doc: doc, CurrentMetadata: meta},
+		Doc: &inMemoryWorkspaceProvider{Doc: doc, CurrentMetadata: meta, SigningKeys: d.signingKey},
+	}
+	if writeable {
+		// workspaces created by older versions are migrated in memory and saved along with the next flush
+		if changed, err := migrateSettingsInner(doc); err != nil {
+			return nil, errors.Wrap(err, "failed to migrate workspace")
+		} else if migrated, err := migrateTimeTrackingInner(doc); err != nil {
+			return nil, errors.Wrap(err, "failed to migrate workspace")
+		} else if changed || migrated {
+			message := "migrated workspace"
+			if meta.CurrentAuthor != nil {
+				message = *meta.CurrentAuthor + " " + message
+			}
+			if _, err := provider.Doc.commit(message); err != nil {
+				return nil, errors.Wrap(err, "failed to commit")
+			}
+		}
 	}
 	unlocker = nil
 	return provider, nil
 }
 
+// OpenWorkspaceAt opens a read-only view of the workspace as it was when the given heads were the latest changes.
+func (d *directoryStorage) OpenWorkspaceAt(ctx context.Context, id string, heads []automerge.ChangeHash) (WorkspaceProvider, error) {
+	if len(heads) == 0 {
+		return nil, errors.New("at least one change hash is required")
+	}
+	ws, err := d.OpenWorkspace(ctx, id, false)
+	if err != nil {
+		return nil, err
+	}
+	provider := ws.(*directoryStorageWorkspace)
+	forkedDoc, err := provider.Doc.Doc.Fork(heads...)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to fork workspace at the given changes")
+	}
+	provider.Doc = &inMemoryWorkspaceProvider{Doc: forkedDoc, CurrentMetadata: provider.Doc.CurrentMetadata}
+	return provider, nil
+}
+
 func (d *directoryStorage) ImportWorkspace(ctx context.Context, id string, data []byte) (*WorkspaceMeta, error) {
 	if _, err := ulid.Parse(id); err != nil {
 		return nil, errors.New("invalid workspace id - expected a valid ulid")
@@ -320,10 +369,46 @@ func (d *directoryStorageWorkspace) EditTodo(ctx context.Context, id string, par
 	return d.Doc.EditTodo(ctx, id, params)
 }
 
+func (d *directoryStorageWorkspace) BulkEditTodos(ctx context.Context, ids []string, params EditTodoParams) ([]Todo, error) {
+	return d.Doc.BulkEditTodos(ctx, ids, params)
+}
+
+func (d *directoryStorageWorkspace) CheckBulkEditTodos(ctx context.Context, ids []string, params EditTodoParams) error {
+	return d.Doc.CheckBulkEditTodos(ctx, ids, params)
+}
+
 func (d *directoryStorageWorkspace) DeleteTodo(ctx context.Context, id string, params DeleteTodoParams) error {
 	return d.Doc.DeleteTodo(ctx, id, params)
 }
 
+func (d *directoryStorageWorkspace) ImportTodo(ctx context.Context, params ImportTodoParams) (*Todo, error) {
+	return d.Doc.ImportTodo(ctx, params)
+}
+
+func (d *directoryStorageWorkspace) GetTodoHistory(ctx context.Context, id string) ([]HistoryEntry, error) {
+	return d.Doc.GetTodoHistory(ctx, id)
+}
+
+func (d *directoryStorageWorkspace) ListTodoConflicts(ctx context.Context, id string) ([]TodoConflict, error) {
+	return d.Doc.ListTodoConflicts(ctx, id)
+}
+
+func (d *directoryStorageWorkspace) ResolveTodoConflict(ctx context.Context, id string, params ResolveTodoConflictParams) (*Todo, error) {
+	return d.Doc.ResolveTodoConflict(ctx, id, params)
+}
+
+func (d *directoryStorageWorkspace) ListTodoChildren(ctx context.Context, id string) ([]Todo, error) {
+	return d.Doc.ListTodoChildren(ctx, id)
+}
+
+func (d *directoryStorageWorkspace) ListTodoAncestors(ctx context.Context, id string) ([]Todo, error) {
+	return d.Doc.ListTodoAncestors(ctx, id)
+}
+
+func (d *directoryStorageWorkspace) ListTodoBlockers(ctx context.Context, id string) ([]Todo, error) {
+	return d.Doc.ListTodoBlockers(ctx, id)
+}
+
 func (d *directoryStorageWorkspace) ListComments(ctx context.Context, todoId string) ([]Comment, error) {
 	return d.Doc.ListComments(ctx, todoId)
 }
@@ -344,6 +429,146 @@ func (d *directoryStorageWorkspace) DeleteComment(ctx context.Context, todoId, c
 	return d.Doc.DeleteComment(ctx, todoId, commentId, params)
 }
 
+func (d *directoryStorageWorkspace) GetCommentHistory(ctx context.Context, todoId, commentId string) ([]HistoryEntry, error) {
+	return d.Doc.GetCommentHistory(ctx, todoId, commentId)
+}
+
+func (d *directoryStorageWorkspace) AddChecklistItem(ctx context.Context, todoId string, params AddChecklistItemParams) (*ChecklistItem, error) {
+	return d.Doc.AddChecklistItem(ctx, todoId, params)
+}
+
+func (d *directoryStorageWorkspace) EditChecklistItem(ctx context.Context, todoId, itemId string, params EditChecklistItemParams) (*ChecklistItem, error) {
+	return d.Doc.EditChecklistItem(ctx, todoId, itemId, params)
+}
+
+func (d *directoryStorageWorkspace) DeleteChecklistItem(ctx context.Context, todoId, itemId string, params DeleteChecklistItemParams) error {
+	return d.Doc.DeleteChecklistItem(ctx, todoId, itemId, params)
+}
+
+func (d *directoryStorageWorkspace) ListTimeEntries(ctx context.Context, todoId string) ([]TimeEntry, error) {
+	return d.Doc.ListTimeEntries(ctx, todoId)
+}
+
+func (d *directoryStorageWorkspace) StartTimer(ctx context.Context, todoId string, params StartTimerParams) error {
+	return d.Doc.StartTimer(ctx, todoId, params)
+}
+
+func (d *directoryStorageWorkspace) StopTimer(ctx context.Context, todoId string, params StopTimerParams) (*TimeEntry, error) {
+	return d.Doc.StopTimer(ctx, todoId, params)
+}
+
+func (d *directoryStorageWorkspace) LogTime(ctx context.Context, todoId string, params LogTimeParams) (*TimeEntry, error) {
+	return d.Doc.LogTime(ctx, todoId, params)
+}
+
+func (d *directoryStorageWorkspace) ListAttachments(ctx context.Context) ([]Attachment, error) {
+	return d.Doc.ListAttachments(ctx)
+}
+
+func (d *directoryStorageWorkspace) MoveTodo(ctx context.Context, id string, params MoveTodoParams) (*Todo, error) {
+	return d.Doc.MoveTodo(ctx, id, params)
+}
+
+func (d *directoryStorageWorkspace) RebalanceRanks(ctx context.Context, params RebalanceRanksParams) (int, error) {
+	return d.Doc.RebalanceRanks(ctx, params)
+}
+
+func (d *directoryStorageWorkspace) AddTodoLabels(ctx context.Context, id string, labels []string, updatedBy string) (*Todo, error) {
+	return d.Doc.AddTodoLabels(ctx, id, labels, updatedBy)
+}
+
+func (d *directoryStorageWorkspace) RemoveTodoLabels(ctx context.Context, id string, labels []string, updatedBy string) (*Todo, error) {
+	return d.Doc.RemoveTodoLabels(ctx, id, labels, updatedBy)
+}
+
+func (d *directoryStorageWorkspace) ListLabels(ctx context.Context) (map[string]int, error) {
+	return d.Doc.ListLabels(ctx)
+}
+
+func (d *directoryStorageWorkspace) RenameLabel(ctx context.Context, params RenameLabelParams) (int, error) {
+	return d.Doc.RenameLabel(ctx, params)
+}
+
+func (d *directoryStorageWorkspace) AssignTodo(ctx context.Context, id string, assignees []string, updatedBy string) (*Todo, error) {
+	return d.Doc.AssignTodo(ctx, id, assignees, updatedBy)
+}
+
+func (d *directoryStorageWorkspace) UnassignTodo(ctx context.Context, id string, assignees []string, updatedBy string) (*Todo, error) {
+	return d.Doc.UnassignTodo(ctx, id, assignees, updatedBy)
+}
+
+func (d *directoryStorageWorkspace) ListAuthors(ctx context.Context) ([]string, error) {
+	return d.Doc.ListAuthors(ctx)
+}
+
+func (d *directoryStorageWorkspace) ListMentions(ctx context.Context, author string, since time.Time) ([]Mention, error) {
+	return d.Doc.ListMentions(ctx, author, since)
+}
+
+func (d *directoryStorageWorkspace) ListWorkflowStatuses(ctx context.Context) ([]WorkflowStatus, error) {
+	return d.Doc.ListWorkflowStatuses(ctx)
+}
+
+func (d *directoryStorageWorkspace) SetWorkflowStatus(ctx context.Context, params SetWorkflowStatusParams) (*WorkflowStatus, error) {
+	return d.Doc.SetWorkflowStatus(ctx, params)
+}
+
+func (d *directoryStorageWorkspace) DeleteWorkflowStatus(ctx context.Context, name string, params DeleteWorkflowStatusParams) error {
+	return d.Doc.DeleteWorkflowStatus(ctx, name, params)
+}
+
+func (d *directoryStorageWorkspace) ListTodoTemplates(ctx context.Context) ([]TodoTemplate, error) {
+	return d.Doc.ListTodoTemplates(ctx)
+}
+
+func (d *directoryStorageWorkspace) GetTodoTemplate(ctx context.Context, name string) (*TodoTemplate, error) {
+	return d.Doc.GetTodoTemplate(ctx, name)
+}
+
+func (d *directoryStorageWorkspace) AddTodoTemplate(ctx context.Context, params AddTodoTemplateParams) (*TodoTemplate, error) {
+	return d.Doc.AddTodoTemplate(ctx, params)
+}
+
+func (d *directoryStorageWorkspace) EditTodoTemplate(ctx context.Context, name string, params EditTodoTemplateParams) (*TodoTemplate, error) {
+	return d.Doc.EditTodoTemplate(ctx, name, params)
+}
+
+func (d *directoryStorageWorkspace) DeleteTodoTemplate(ctx context.Context, name string, params DeleteTodoTemplateParams) error {
+	return d.Doc.DeleteTodoTemplate(ctx, name, params)
+}
+
+func (d *directoryStorageWorkspace) ListAnnotationSchemas(ctx context.Context) ([]AnnotationSchema, error) {
+	return d.Doc.ListAnnotationSchemas(ctx)
+}
+
+func (d *directoryStorageWorkspace) SetAnnotationSchema(ctx context.Context, params SetAnnotationSchemaParams) (*AnnotationSchema, error) {
+	return d.Doc.SetAnnotationSchema(ctx, params)
+}
+
+func (d *directoryStorageWorkspace) DeleteAnnotationSchema(ctx context.Context, key string, params DeleteAnnotationSchemaParams) error {
+	return d.Doc.DeleteAnnotationSchema(ctx, key, params)
+}
+
+func (d *directoryStorageWorkspace) ListMembers(ctx context.Context) ([]Member, error) {
+	return d.Doc.ListMembers(ctx)
+}
+
+func (d *directoryStorageWorkspace) AddMember(ctx context.Context, params AddMemberParams) (*Member, error) {
+	return d.Doc.AddMember(ctx, params)
+}
+
+func (d *directoryStorageWorkspace) MergeMembers(ctx context.Context, params MergeMembersParams) (*Member, error) {
+	return d.Doc.MergeMembers(ctx, params)
+}
+
+func (d *directoryStorageWorkspace) RevertChange(ctx context.Context, hash string, params RevertChangeParams) (*HistoryEntry, error) {
+	return d.Doc.RevertChange(ctx, hash, params)
+}
+
+func (d *directoryStorageWorkspace) LatestChangeByAuthor(ctx context.Context, author string) (string, error) {
+	return d.Doc.LatestChangeByAuthor(ctx, author)
+}
+
 func (d *directoryStorageWorkspace) GetDoc() *automerge.Doc {
 	return d.Doc.GetDoc()
 }
@@ -14,6 +14,20 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// newTestWorkspace creates a workspace in a new directory storage and returns its in-memory provider, opened for
+// writing.
+func newTestWorkspace(t *testing.T) *inMemoryWorkspaceProvider {
+	s := newDirectoryStorage(t)
+	meta, err := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	require.NoError(t, err)
+	ws, err := s.OpenWorkspace(context.Background(), meta.Id, true)
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = ws.Close()
+	})
+	return ws.(*directoryStorageWorkspace).Doc
+}
+
 func TestNew_success(t *testing.T) {
 	td, err := os.MkdirTemp(os.TempDir(), "")
 	require.NoError(t, err)
@@ -79,6 +93,10 @@ func TestCreate_success(t *testing.T) {
 				"alias":      "something",
 				"created_at": ws.CreatedAt,
 				"todos":      map[string]interface{}{},
+				"settings": map[string]interface{}{
+					"statuses": map[string]interface{}{}, "templates": map[string]interface{}{},
+					"annotations": map[string]interface{}{}, "members": map[string]interface{}{},
+				},
 			}, out)
 		}
 	}
@@ -247,6 +265,44 @@ func TestOpenWorkspaceWriteable_unlocked(t *testing.T) {
 	assert.NoError(t, wsp2.Close())
 }
 
+func TestOpenWorkspaceWriteable_migrates_settings(t *testing.T) {
+	s := newDirectoryStorage(t)
+	doc := automerge.New()
+	_ = doc.Path("alias").Set("older")
+	_ = doc.Path("created_at").Set(time.Now().UTC().Truncate(time.Second))
+	_ = doc.Path("todos").Set(automerge.NewMap())
+	todoId := ulid.Make().String()
+	_ = doc.Path("todos", todoId).Set(automerge.NewMap())
+	_ = doc.Path("todos", todoId, "title").Set(automerge.NewText("Older"))
+	_ = doc.Path("todos", todoId, "status").Set("open")
+	ws, err := s.ImportWorkspace(context.Background(), ulid.Make().String(), doc.Save())
+	require.NoError(t, err)
+
+	wsp, err := s.OpenWorkspace(context.Background(), ws.Id, false)
+	require.NoError(t, err)
+	v, _ := wsp.(DocProvider).GetDoc().Path("settings").Get()
+	assert.Equal(t, automerge.KindVoid, v.Kind())
+	inner := NewInMemoryWorkspaceProvider(wsp.(DocProvider).GetDoc())
+	_, err = inner.LogTime(context.Background(), todoId, LogTimeParams{Duration: time.Minute, LoggedBy: "Example <email@me.com>"})
+	assert.EqualError(t, err, "todo '"+todoId+"' has no time_entries, the workspace must be opened for writing to migrate it")
+	assert.EqualError(t, inner.StartTimer(context.Background(), todoId, StartTimerParams{StartedBy: "Example <email@me.com>"}), "todo '"+todoId+"' has no timers, the workspace must be opened for writing to migrate it")
+
+	wsp, err = s.OpenWorkspace(context.Background(), ws.Id, true)
+	require.NoError(t, err)
+	defer wsp.Close()
+	for _, name := range settingsMaps {
+		v, _ := wsp.(DocProvider).GetDoc().Path("settings", name).Get()
+		assert.Equal(t, automerge.KindMap, v.Kind(), name)
+	}
+	_, err = wsp.SetWorkflowStatus(context.Background(), SetWorkflowStatusParams{Name: "todo", Category: "open", UpdatedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+	_, err = wsp.LogTime(context.Background(), todoId, LogTimeParams{Duration: time.Minute, LoggedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+	todo, err := wsp.GetTodo(context.Background(), todoId)
+	assert.NoError(t, err)
+	assert.Equal(t, time.Minute, todo.TimeSpent)
+}
+
 func TestOpenWorkspace_flush_multiple(t *testing.T) {
 	s := newDirectoryStorage(t)
 	ws, err := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "example"})
@@ -0,0 +1,144 @@
+package au
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+func (p *inMemoryWorkspaceProvider) ListTodoChildren(ctx context.Context, id string) ([]Todo, error) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	todos := p.Doc.Path("todos").Map()
+	if _, err := getTodoInner(todos, id); err != nil {
+		return nil, err
+	}
+	childIds := childIdsInner(todos, id)
+	output := make([]Todo, len(childIds))
+	for i, childId := range childIds {
+		td, err := getTodoInner(todos, childId)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get todo")
+		}
+		output[i] = *td
+	}
+	return output, nil
+}
+
+// ListTodoAncestors returns the chain of parents of the todo, starting with the direct parent. Parents that no longer
+// exist terminate the chain.
+func (p *inMemoryWorkspaceProvider) ListTodoAncestors(ctx context.Context, id string) ([]Todo, error) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	todos := p.Doc.Path("todos").Map()
+	if _, err := getTodoInner(todos, id); err != nil {
+		return nil, err
+	}
+	ancestorIds := ancestorIdsInner(todos, id)
+	output := make([]Todo, len(ancestorIds))
+	for i, ancestorId := range ancestorIds {
+		td, err := getTodoInner(todos, ancestorId)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get todo")
+		}
+		output[i] = *td
+	}
+	return output, nil
+}
+
+// todoFieldInner returns the string value at the path within the todo, or an empty string if it is missing.
+func todoFieldInner(todos *automerge.Map, id string, path ...string) string {
+	v, _ := todos.Get(id)
+	for _, key := range path {
+		if v.Kind() != automerge.KindMap {
+			return ""
+		}
+		v, _ = v.Map().Get(key)
+	}
+	if v.Kind() == automerge.KindStr {
+		return v.Str()
+	}
+	return ""
+}
+
+// parentIdInner returns the parent id of the todo or an empty string.
+func parentIdInner(todos *automerge.Map, id string) string {
+	return todoFieldInner(todos, id, "annotations", AurelianParentAnnotation)
+}
+
+// ancestorIdsInner walks up the parent references. Concurrent edits may produce a cycle even though each edit was
+// valid on its own, so the walk stops if it revisits a todo.
+func ancestorIdsInner(todos *automerge.Map, id string) []string {
+	output := make([]string, 0)
+	seen := map[string]bool{id: true}
+	for current := parentIdInner(todos, id); current != "" && !seen[current]; current = parentIdInner(todos, current) {
+		if v, _ := todos.Get(current); v.Kind() != automerge.KindMap {
+			break
+		}
+		seen[current] = true
+		output = append(output, current)
+	}
+	return output
+}
+
+func childIdsInner(todos *automerge.Map, id string) []string {
+	output := make([]string, 0)
+	keys, _ := todos.Keys()
+	for _, key := range keys {
+		if key != id && parentIdInner(todos, key) == id {
+			output = append(output, key)
+		}
+	}
+	return output
+}
+
+// validateParentInner checks that the parent exists and that making it the parent of the todo would not create a
+// cycle. The id may be empty when the todo is being created.
+func validateParentInner(todos *automerge.Map, id, parentId string) error {
+	if v, _ := todos.Get(parentId); v.Kind() != automerge.KindMap {
+		return errors.Errorf("parent todo with id '%s' does not exist", parentId)
+	} else if parentId == id || slices.Contains(ancestorIdsInner(todos, parentId), id) {
+		return errors.Errorf("cannot set parent of todo '%s' to '%s' since it would create a cycle", id, parentId)
+	}
+	return nil
+}
+
+// reparentChildrenInner moves the children of the todo to its own parent, or to the top level if it has none. This is
+// used when the todo is deleted.
+func reparentChildrenInner(todos *automerge.Map, id, updatedBy string) error {
+	grandparentId := parentIdInner(todos, id)
+	updatedAt := time.Now().UTC().Truncate(time.Second)
+	for _, childId := range childIdsInner(todos, id) {
+		child, _ := todos.Get(childId)
+		annotations, _ := child.Map().Get("annotations")
+		if grandparentId == "" {
+			if err := annotations.Map().Delete(AurelianParentAnnotation); err != nil {
+				return errors.Wrap(err, "failed to delete parent annotation")
+			}
+		} else if err := annotations.Map().Set(AurelianParentAnnotation, grandparentId); err != nil {
+			return errors.Wrap(err, "failed to set parent annotation")
+		}
+		if err := child.Map().Set("updated_at", updatedAt); err != nil {
+			return errors.Wrap(err, "failed to set updated_at")
+		} else if err := child.Map().Set("updated_by", updatedBy); err != nil {
+			return errors.Wrap(err, "failed to set updated_by")
+		}
+	}
+	return nil
+}
+
+// openChildCountInner returns the number of direct children of the todo which are still open.
+func openChildCountInner(todos *automerge.Map, id string) int {
+	count := 0
+	for _, childId := range childIdsInner(todos, id) {
+		if todoFieldInner(todos, childId, "status") == "open" {
+			count++
+		}
+	}
+	return count
+}
@@ -0,0 +1,58 @@
+package au
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aurelian-one/au/internal"
+)
+
+func TestTodoHierarchy(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, true)
+	author := "Example <email@me.com>"
+
+	_, err := wsp.CreateTodo(context.Background(), CreateTodoParams{
+		Title: "Orphan", Annotations: map[string]string{AurelianParentAnnotation: "unknown"}, CreatedBy: author,
+	})
+	assert.EqualError(t, err, "parent todo with id 'unknown' does not exist")
+
+	root, err := wsp.CreateTodo(context.Background(), CreateTodoParams{Title: "Root", CreatedBy: author})
+	assert.NoError(t, err)
+	middle, err := wsp.CreateTodo(context.Background(), CreateTodoParams{
+		Title: "Middle", Annotations: map[string]string{AurelianParentAnnotation: root.Id}, CreatedBy: author,
+	})
+	assert.NoError(t, err)
+	leaf, err := wsp.CreateTodo(context.Background(), CreateTodoParams{
+		Title: "Leaf", Annotations: map[string]string{AurelianParentAnnotation: middle.Id}, CreatedBy: author,
+	})
+	assert.NoError(t, err)
+
+	ancestors, err := wsp.ListTodoAncestors(context.Background(), leaf.Id)
+	assert.NoError(t, err)
+	if assert.Len(t, ancestors, 2) {
+		assert.Equal(t, middle.Id, ancestors[0].Id)
+		assert.Equal(t, root.Id, ancestors[1].Id)
+	}
+
+	_, err = wsp.EditTodo(context.Background(), root.Id, EditTodoParams{Annotations: map[string]string{AurelianParentAnnotation: leaf.Id}, UpdatedBy: author})
+	assert.EqualError(t, err, "cannot set parent of todo '"+root.Id+"' to '"+leaf.Id+"' since it would create a cycle")
+
+	_, err = wsp.EditTodo(context.Background(), middle.Id, EditTodoParams{Status: internal.Ref("closed"), UpdatedBy: author})
+	assert.EqualError(t, err, "cannot close todo '"+middle.Id+"' since it has 1 open children")
+
+	assert.NoError(t, wsp.DeleteTodo(context.Background(), middle.Id, DeleteTodoParams{DeletedBy: author}))
+	children, err := wsp.ListTodoChildren(context.Background(), root.Id)
+	assert.NoError(t, err)
+	if assert.Len(t, children, 1) {
+		assert.Equal(t, leaf.Id, children[0].Id)
+	}
+
+	assert.NoError(t, wsp.DeleteTodo(context.Background(), root.Id, DeleteTodoParams{DeletedBy: author}))
+	td, err := wsp.GetTodo(context.Background(), leaf.Id)
+	assert.NoError(t, err)
+	assert.NotContains(t, td.Annotations, AurelianParentAnnotation)
+}
@@ -0,0 +1,282 @@
+package au
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+// HistoryEntry is a single Automerge change that modified a Todo or Comment.
+type HistoryEntry struct {
+	Hash    string
+	At      time.Time
+	Author  string
+	Message string
+	Changes []FieldChange
+}
+
+// FieldChange is the before and after value of a single field within a HistoryEntry. A nil Before indicates that the
+// field was added, and a nil After indicates that it was removed.
+type FieldChange struct {
+	Field  string
+	Before *string
+	After  *string
+}
+
+// docLeaf is a scalar or text value in the document along with the path to it relative to some root.
+type docLeaf struct {
+	Path  []string
+	Value *automerge.Value
+}
+
+func (p *inMemoryWorkspaceProvider) GetTodoHistory(ctx context.Context, todoId string) ([]HistoryEntry, error) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	output, err := getHistoryInner(p.Doc, todoId, "todos", todoId)
+	if err != nil {
+		return nil, err
+	} else if len(output) == 0 {
+		return nil, errors.Errorf("todo with id '%s' does not exist", todoId)
+	}
+	return output, nil
+}
+
+func (p *inMemoryWorkspaceProvider) GetCommentHistory(ctx context.Context, todoId, commentId string) ([]HistoryEntry, error) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	output, err := getHistoryInner(p.Doc, commentId, "todos", todoId, "comments", commentId)
+	if err != nil {
+		return nil, err
+	} else if len(output) == 0 {
+		return nil, errors.Errorf("comment with id '%s' does not exist", commentId)
+	}
+	return output, nil
+}
+
+// singleObjectMessagePattern matches the commit messages of changes which only modify the single todo or comment they
+// name. Other changes, such as deletes or reverts, may cascade to objects not mentioned in the message.
+var singleObjectMessagePattern = regexp.MustCompile(`> ((created|edited|imported) todo|edited checklist of todo|tracked time on todo|(created|edited|deleted) comment \S+ in todo|resolved conflict on \S+ in todo) \S+$`)
+
+// getHistoryInner walks every change in the document and compares the object at the given path before and after the
+// change. Changes that only modify some other named object are skipped to avoid forking the document for every change.
+func getHistoryInner(doc *automerge.Doc, id string, path ...string) ([]HistoryEntry, error) {
+	changes, err := doc.Changes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get changes")
+	}
+	output := make([]HistoryEntry, 0)
+	for _, change := range changes {
+		if m := change.Message(); !strings.Contains(m, id) && singleObjectMessagePattern.MatchString(m) {
+			continue
+		} else if isSignatureChange(change) {
+			continue
+		}
+		before, after, err := forkAroundChange(doc, change)
+		if err != nil {
+			return nil, err
+		}
+		fieldChanges := diffLeaves(flattenPath(before, path...), flattenPath(after, path...))
+		if len(fieldChanges) == 0 {
+			continue
+		}
+		output = append(output, HistoryEntry{
+			Hash:    change.Hash().String(),
+			At:      change.Timestamp().In(time.UTC),
+			Author:  commitAuthor(change.Message()),
+			Message: change.Message(),
+			Changes: fieldChanges,
+		})
+	}
+	return output, nil
+}
+
+// forkAroundChange returns a copy of the document as it was just before the change was applied and just after.
+func forkAroundChange(doc *automerge.Doc, change *automerge.Change) (*automerge.Doc, *automerge.Doc, error) {
+	before := automerge.New()
+	if deps := change.Dependencies(); len(deps) > 0 {
+		var err error
+		if before, err = doc.Fork(deps...); err != nil {
+			return nil, nil, errors.Wrap(err, "failed to fork before change")
+		}
+	}
+	after, err := doc.Fork(change.Hash())
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "failed to fork after change")
+	}
+	return before, after, nil
+}
+
+// commitAuthor extracts the 'Name <email>' prefix that this library adds to every commit message.
+func commitAuthor(message string) string {
+	if i := strings.Index(message, "> "); i > 0 {
+		if candidate := message[:i+1]; ValidatedAuthor(candidate) == nil {
+			return candidate
+		}
+	}
+	return ""
+}
+
+func leafKey(path []string) string {
+	return strings.Join(path, "\x00")
+}
+
+// flattenPath returns all the leaf values under the given path in the document keyed by their relative path.
+func flattenPath(doc *automerge.Doc, path ...string) map[string]docLeaf {
+	out := make(map[string]docLeaf)
+	anyPath := make([]any, len(path))
+	for i, s := range path {
+		anyPath[i] = s
+	}
+	if v, err := doc.Path(anyPath...).Get(); err == nil {
+		flattenValue(v, []string{}, out)
+	}
+	return out
+}
+
+func flattenValue(v *automerge.Value, path []string, out map[string]docLeaf) {
+	switch v.Kind() {
+	case automerge.KindVoid:
+	case automerge.KindMap:
+		keys, _ := v.Map().Keys()
+		for _, k := range keys {
+			if x, err := v.Map().Get(k); err == nil {
+				flattenValue(x, append(path[:len(path):len(path)], k), out)
+			}
+		}
+	case automerge.KindList:
+		for i := 0; i < v.List().Len(); i++ {
+			if x, err := v.List().Get(i); err == nil {
+				flattenValue(x, append(path[:len(path):len(path)], strconv.Itoa(i)), out)
+			}
+		}
+	default:
+		out[leafKey(path)] = docLeaf{Path: path, Value: v}
+	}
+}
+
+// leafString renders a leaf value in a human-readable way for history output.
+func leafString(v *automerge.Value) string {
+	switch v.Kind() {
+	case automerge.KindStr:
+		return v.Str()
+	case automerge.KindText:
+		s, _ := v.Text().Get()
+		return s
+	case automerge.KindTime:
+		return v.Time().In(time.UTC).Format(time.RFC3339)
+	case automerge.KindBytes:
+		if utf8.Valid(v.Bytes()) {
+			return string(v.Bytes())
+		}
+		return fmt.Sprintf("<%d bytes>", len(v.Bytes()))
+	case automerge.KindCounter:
+		c, _ := v.Counter().Get()
+		return strconv.FormatInt(c, 10)
+	case automerge.KindNull:
+		return "null"
+	default:
+		return fmt.Sprint(v.Interface())
+	}
+}
+
+func diffLeaves(before, after map[string]docLeaf) []FieldChange {
+	output := make([]FieldChange, 0)
+	for k, b := range before {
+		bs := leafString(b.Value)
+		if a, ok := after[k]; !ok {
+			output = append(output, FieldChange{Field: strings.Join(b.Path, "/"), Before: &bs})
+		} else if as := leafString(a.Value); as != bs || a.Value.Kind() != b.Value.Kind() {
+			output = append(output, FieldChange{Field: strings.Join(b.Path, "/"), Before: &bs, After: &as})
+		}
+	}
+	for k, a := range after {
+		if _, ok := before[k]; !ok {
+			as := leafString(a.Value)
+			output = append(output, FieldChange{Field: strings.Join(a.Path, "/"), After: &as})
+		}
+	}
+	slices.SortFunc(output, func(a, b FieldChange) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+	return output
+}
+
+// ResolveHeads converts a user-provided point in history into the set of change hashes that represent the document at
+// that point. The input may be a full change hash, a unique prefix of a change hash, an RFC3339 timestamp, or a
+// 2006-01-02 date (which is interpreted as midnight at the start of that day in local time).
+func ResolveHeads(doc *automerge.Doc, input string) ([]automerge.ChangeHash, error) {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return nil, errors.New("empty point in history")
+	}
+	if t, err := ParseTimeInput(input); err == nil {
+		return HeadsAtTime(doc, t)
+	}
+	hash, err := ResolveChangeHash(doc, input)
+	if err != nil {
+		return nil, err
+	}
+	return []automerge.ChangeHash{hash}, nil
+}
+
+// ResolveChangeHash finds the change with the given hash or unique hash prefix.
+func ResolveChangeHash(doc *automerge.Doc, input string) (automerge.ChangeHash, error) {
+	input = strings.ToLower(strings.TrimSpace(input))
+	if h, err := automerge.NewChangeHash(input); err == nil {
+		if _, err := doc.Change(h); err != nil {
+			return automerge.ChangeHash{}, errors.Errorf("change '%s' does not exist", input)
+		}
+		return h, nil
+	}
+	changes, err := doc.Changes()
+	if err != nil {
+		return automerge.ChangeHash{}, errors.Wrap(err, "failed to get changes")
+	}
+	matches := make([]automerge.ChangeHash, 0)
+	for _, change := range changes {
+		if strings.HasPrefix(change.Hash().String(), input) {
+			matches = append(matches, change.Hash())
+		}
+	}
+	if len(matches) == 0 {
+		return automerge.ChangeHash{}, errors.Errorf("change '%s' does not exist", input)
+	} else if len(matches) > 1 {
+		return automerge.ChangeHash{}, errors.Errorf("change prefix '%s' is ambiguous, it matches %d changes", input, len(matches))
+	}
+	return matches[0], nil
+}
+
+// HeadsAtTime returns the heads of the history made up of all changes with a timestamp at or before the given time.
+func HeadsAtTime(doc *automerge.Doc, at time.Time) ([]automerge.ChangeHash, error) {
+	changes, err := doc.Changes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get changes")
+	}
+	included, superseded := make(map[automerge.ChangeHash]bool), make(map[automerge.ChangeHash]bool)
+	for _, change := range changes {
+		if !change.Timestamp().After(at) {
+			included[change.Hash()] = true
+			for _, dep := range change.Dependencies() {
+				superseded[dep] = true
+			}
+		}
+	}
+	output := make([]automerge.ChangeHash, 0)
+	for _, change := range changes {
+		if included[change.Hash()] && !superseded[change.Hash()] {
+			output = append(output, change.Hash())
+		}
+	}
+	if len(output) == 0 {
+		return nil, errors.Errorf("the workspace has no changes at or before %s", at.Format(time.RFC3339))
+	}
+	return output, nil
+}
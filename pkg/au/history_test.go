@@ -0,0 +1,127 @@
+package au
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aurelian-one/au/internal"
+)
+
+func TestGetTodoHistory_missing(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, false)
+	_, err := wsp.GetTodoHistory(context.Background(), "thing")
+	assert.EqualError(t, err, "todo with id 'thing' does not exist")
+}
+
+func TestGetTodoHistory_success(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, true)
+	td, err := wsp.CreateTodo(context.Background(), CreateTodoParams{
+		Title:       "Do the thing",
+		Description: "Much longer text about doing the thing",
+		CreatedBy:   "Example <email@me.com>",
+	})
+	assert.NoError(t, err)
+	_, err = wsp.CreateTodo(context.Background(), CreateTodoParams{Title: "Other thing", CreatedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+	_, err = wsp.EditTodo(context.Background(), td.Id, EditTodoParams{
+		Description: internal.Ref(""),
+		Status:      internal.Ref("closed"),
+		Annotations: map[string]string{"about:blank#thing": "true"},
+		UpdatedBy:   "Other <other@me.com>",
+	})
+	assert.NoError(t, err)
+	c, err := wsp.CreateComment(context.Background(), td.Id, CreateCommentParams{
+		MediaType: DefaultCommentMediaType, Content: []byte("hello"), CreatedBy: "Example <email@me.com>",
+	})
+	assert.NoError(t, err)
+
+	history, err := wsp.GetTodoHistory(context.Background(), td.Id)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 3) {
+		assert.Equal(t, "Example <email@me.com>", history[0].Author)
+		assert.Equal(t, "Example <email@me.com> created todo "+td.Id, history[0].Message)
+		assert.Contains(t, history[0].Changes, FieldChange{Field: "title", After: internal.Ref("Do the thing")})
+
+		assert.Equal(t, "Other <other@me.com>", history[1].Author)
+		fields := make([]string, 0)
+		for _, fc := range history[1].Changes {
+			fields = append(fields, fc.Field)
+		}
+		assert.Equal(t, []string{"annotations/about:blank#thing", "description", "status", "updated_at", "updated_by"}, fields)
+		assert.Equal(t, FieldChange{
+			Field: "description", Before: internal.Ref("Much longer text about doing the thing"), After: internal.Ref(""),
+		}, history[1].Changes[1])
+		assert.Equal(t, FieldChange{Field: "status", Before: internal.Ref("open"), After: internal.Ref("closed")}, history[1].Changes[2])
+
+		assert.Contains(t, history[2].Changes, FieldChange{Field: "comments/" + c.Id + "/content", After: internal.Ref("hello")})
+	}
+
+	history, err = wsp.GetCommentHistory(context.Background(), td.Id, c.Id)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 1) {
+		assert.Contains(t, history[0].Changes, FieldChange{Field: "content", After: internal.Ref("hello")})
+	}
+
+	assert.NoError(t, wsp.DeleteTodo(context.Background(), td.Id, DeleteTodoParams{DeletedBy: "Example <email@me.com>"}))
+	history, err = wsp.GetTodoHistory(context.Background(), td.Id)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 4) {
+		assert.Contains(t, history[3].Changes, FieldChange{Field: "title", Before: internal.Ref("Do the thing")})
+	}
+}
+
+func TestOpenWorkspaceAt(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, true)
+	td, err := wsp.CreateTodo(context.Background(), CreateTodoParams{Title: "Do the thing", CreatedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+	firstHeads := wsp.(DocProvider).GetDoc().Heads()
+	_, err = wsp.EditTodo(context.Background(), td.Id, EditTodoParams{Title: internal.Ref("Do the other thing"), UpdatedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+	assert.NoError(t, wsp.Flush())
+	assert.NoError(t, wsp.Close())
+
+	t.Run("by hash prefix", func(t *testing.T) {
+		wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, false)
+		heads, err := ResolveHeads(wsp.(DocProvider).GetDoc(), firstHeads[0].String()[:10])
+		assert.NoError(t, err)
+		assert.Equal(t, firstHeads, heads)
+
+		past, err := s.OpenWorkspaceAt(context.Background(), ws.Id, heads)
+		assert.NoError(t, err)
+		pastTd, err := past.GetTodo(context.Background(), td.Id)
+		assert.NoError(t, err)
+		assert.Equal(t, "Do the thing", pastTd.Title)
+		assert.EqualError(t, past.Flush(), "workspace is not locked for writing")
+	})
+
+	t.Run("by time", func(t *testing.T) {
+		wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, false)
+		// the initial workspace change is saved without a timestamp, so it always exists
+		heads, err := ResolveHeads(wsp.(DocProvider).GetDoc(), "2000-01-01")
+		assert.NoError(t, err)
+		past, err := s.OpenWorkspaceAt(context.Background(), ws.Id, heads)
+		assert.NoError(t, err)
+		todos, err := past.ListTodos(context.Background())
+		assert.NoError(t, err)
+		assert.Empty(t, todos)
+
+		heads, err = ResolveHeads(wsp.(DocProvider).GetDoc(), time.Now().Add(time.Hour).Format(time.RFC3339))
+		assert.NoError(t, err)
+		assert.Equal(t, wsp.(DocProvider).GetDoc().Heads(), heads)
+	})
+
+	t.Run("unknown", func(t *testing.T) {
+		wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, false)
+		_, err := ResolveHeads(wsp.(DocProvider).GetDoc(), "zzzz")
+		assert.EqualError(t, err, "change 'zzzz' does not exist")
+	})
+}
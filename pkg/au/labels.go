@@ -0,0 +1,150 @@
+package au
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+const MaximumLabelLength = 100
+
+type RenameLabelParams struct {
+	From      string
+	To        string
+	RenamedBy string
+}
+
+func ValidateLabel(input string) (string, error) {
+	if pl, err := ValidateAndCleanUnicode(input, false); err != nil {
+		return "", errors.Wrap(err, "invalid label")
+	} else if pl = strings.TrimSpace(pl); pl == "" {
+		return "", errors.New("label cannot be empty")
+	} else if d := MaximumLabelLength; len(pl) > d {
+		return "", errors.Errorf("label is too long, it should be at most %d characters", d)
+	} else {
+		return pl, nil
+	}
+}
+
+// labelSet holds the labels of a todo.
+const labelSet = annotationSet(AurelianLabelAnnotation)
+
+// LabelAnnotationKey returns the annotation key for the label, with the label url-encoded in the fragment.
+func LabelAnnotationKey(label string) string {
+	return labelSet.key(label)
+}
+
+// TodoLabels returns the sorted labels of the todo.
+func TodoLabels(todo *Todo) []string {
+	return labelSet.values(todo)
+}
+
+// FilterTodosByLabels returns the todos that have all the given labels.
+func FilterTodosByLabels(todos []Todo, labels ...string) []Todo {
+	output := make([]Todo, 0, len(todos))
+	for _, t := range todos {
+		if v := TodoLabels(&t); !slices.ContainsFunc(labels, func(l string) bool { return !slices.Contains(v, l) }) {
+			output = append(output, t)
+		}
+	}
+	return output
+}
+
+// AddTodoLabels adds the labels to the todo in a single edit.
+func (p *inMemoryWorkspaceProvider) AddTodoLabels(ctx context.Context, id string, labels []string, updatedBy string) (*Todo, error) {
+	annotations := make(map[string]string, len(labels))
+	for _, l := range labels {
+		cleaned, err := ValidateLabel(l)
+		if err != nil {
+			return nil, err
+		}
+		annotations[LabelAnnotationKey(cleaned)] = "true"
+	}
+	return p.EditTodo(ctx, id, EditTodoParams{Annotations: annotations, UpdatedBy: updatedBy})
+}
+
+// RemoveTodoLabels removes the labels from the todo in a single edit. Labels which are not present are ignored.
+func (p *inMemoryWorkspaceProvider) RemoveTodoLabels(ctx context.Context, id string, labels []string, updatedBy string) (*Todo, error) {
+	return p.removeFromAnnotationSet(labelSet, id, labels, updatedBy)
+}
+
+// ListLabels returns every label in use in the workspace along with the number of todos that have it.
+func (p *inMemoryWorkspaceProvider) ListLabels(ctx context.Context) (map[string]int, error) {
+	todos, err := p.ListTodos(ctx)
+	if err != nil {
+		return nil, err
+	}
+	output := make(map[string]int)
+	for _, t := range todos {
+		for _, l := range TodoLabels(&t) {
+			output[l]++
+		}
+	}
+	return output, nil
+}
+
+// RenameLabel replaces the label on every todo that has it in a single change and returns the number of todos modified.
+func (p *inMemoryWorkspaceProvider) RenameLabel(ctx context.Context, params RenameLabelParams) (int, error) {
+	if err := ValidatedAuthor(params.RenamedBy); err != nil {
+		return 0, err
+	}
+	to, err := ValidateLabel(params.To)
+	if err != nil {
+		return 0, err
+	}
+	from, toKey := strings.TrimSpace(params.From), LabelAnnotationKey(to)
+	if from == to {
+		return 0, errors.New("cannot rename a label to itself")
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	todos := p.Doc.Path("todos").Map()
+	todoIds, _ := todos.Keys()
+	updatedAt := time.Now().UTC().Truncate(time.Second)
+	count := 0
+	for _, id := range todoIds {
+		todoValue, _ := todos.Get(id)
+		if todoValue.Kind() != automerge.KindMap {
+			continue
+		}
+		annotationsValue, _ := todoValue.Map().Get("annotations")
+		if annotationsValue.Kind() != automerge.KindMap {
+			continue
+		}
+		keys, _ := annotationsValue.Map().Keys()
+		matched := false
+		for _, k := range keys {
+			if label, ok := labelSet.value(k); ok && label == from {
+				if err := annotationsValue.Map().Delete(k); err != nil {
+					return 0, errors.Wrap(err, "failed to delete annotation")
+				}
+				matched = true
+			}
+		}
+		if !matched {
+			continue
+		}
+		if err := annotationsValue.Map().Set(toKey, "true"); err != nil {
+			return 0, errors.Wrap(err, "failed to set annotation")
+		} else if err := todoValue.Map().Set("updated_at", updatedAt); err != nil {
+			return 0, errors.Wrap(err, "failed to set updated_at")
+		} else if err := todoValue.Map().Set("updated_by", params.RenamedBy); err != nil {
+			return 0, errors.Wrap(err, "failed to set updated_by")
+		}
+		count++
+	}
+	if count == 0 {
+		return 0, errors.Errorf("no todos have the label '%s'", params.From)
+	}
+
+	if _, err := p.commit(params.RenamedBy + " renamed label " + params.From + " to " + to); err != nil {
+		return 0, errors.Wrap(err, "failed to commit")
+	}
+	return count, nil
+}
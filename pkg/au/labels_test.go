@@ -0,0 +1,64 @@
+package au
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLabelAnnotationKey(t *testing.T) {
+	key := LabelAnnotationKey("needs review/ü#1")
+	assert.Equal(t, "https://aurelian.one/annotations/label#needs%20review/%C3%BC%231", key)
+	assert.NoError(t, ValidateTodoAnnotationKey(key))
+	assert.Equal(t, []string{"needs review/ü#1"}, TodoLabels(&Todo{Annotations: map[string]string{key: "true"}}))
+}
+
+func TestLabels(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, true)
+	author := "Example <email@me.com>"
+
+	a, err := wsp.CreateTodo(context.Background(), CreateTodoParams{Title: "First", CreatedBy: author})
+	assert.NoError(t, err)
+	b, err := wsp.CreateTodo(context.Background(), CreateTodoParams{
+		Title: "Second", Annotations: map[string]string{"https://aurelian.one/annotations/label#bug": "true"}, CreatedBy: author,
+	})
+	assert.NoError(t, err)
+
+	a, err = wsp.AddTodoLabels(context.Background(), a.Id, []string{"bug", " big feature "}, author)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"big feature", "bug"}, TodoLabels(a))
+
+	_, err = wsp.AddTodoLabels(context.Background(), a.Id, []string{" "}, author)
+	assert.EqualError(t, err, "label cannot be empty")
+
+	labels, err := wsp.ListLabels(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]int{"bug": 2, "big feature": 1}, labels)
+
+	todos, _ := wsp.ListTodos(context.Background())
+	assert.Len(t, FilterTodosByLabels(todos, "bug"), 2)
+	assert.Len(t, FilterTodosByLabels(todos, "bug", "big feature"), 1)
+
+	count, err := wsp.RenameLabel(context.Background(), RenameLabelParams{From: "bug", To: "defect", RenamedBy: author})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, count)
+	_, err = wsp.RenameLabel(context.Background(), RenameLabelParams{From: "bug", To: "defect", RenamedBy: author})
+	assert.EqualError(t, err, "no todos have the label 'bug'")
+
+	history, err := wsp.GetTodoHistory(context.Background(), b.Id)
+	assert.NoError(t, err)
+	assert.Len(t, history, 2)
+
+	b, err = wsp.RemoveTodoLabels(context.Background(), b.Id, []string{"defect"}, author)
+	assert.NoError(t, err)
+	assert.Empty(t, TodoLabels(b))
+
+	// removing labels is a single change in the history of the todo
+	history, err = wsp.GetTodoHistory(context.Background(), b.Id)
+	assert.NoError(t, err)
+	assert.Len(t, history, 3)
+	assert.Equal(t, author+" edited todo "+b.Id, history[2].Message)
+}
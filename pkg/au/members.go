@@ -0,0 +1,317 @@
+package au
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+const MaximumMemberAliasLength = 200
+
+// The roles that a Member of the workspace can have.
+const (
+	MemberRoleAdmin       = "admin"
+	MemberRoleEditor      = "editor"
+	MemberRoleContributor = "contributor"
+	MemberRoleCommenter   = "commenter"
+	MemberRoleReader      = "reader"
+)
+
+var memberRoles = []string{MemberRoleAdmin, MemberRoleEditor, MemberRoleContributor, MemberRoleCommenter, MemberRoleReader}
+
+// Member is a known author of the workspace. The email identifies the member, while the aliases are the other emails,
+// 'Name <email>' strings, or handles that the same person has used or may be referred to by.
+type Member struct {
+	Email   string
+	Name    string
+	Role    string
+	Aliases []string
+}
+
+// Author returns the canonical 'Name <email>' of the member.
+func (m Member) Author() string {
+	return m.Name + " <" + m.Email + ">"
+}
+
+type AddMemberParams struct {
+	// Author is the canonical 'Name <email>' of the new member.
+	Author  string
+	Role    string
+	Aliases []string
+	AddedBy string
+}
+
+type MergeMembersParams struct {
+	// Into is the member that the sources are merged into.
+	Into string
+	// Sources are other members, whose entries are removed, or any other emails, authors, or handles of the member.
+	Sources  []string
+	MergedBy string
+}
+
+var authorPartsPattern = regexp.MustCompile(`^(.+) <(\S+@\S+)>$`)
+
+// ParseAuthor splits a 'Name <email>' author into its name and email.
+func ParseAuthor(author string) (string, string, error) {
+	if err := ValidatedAuthor(author); err != nil {
+		return "", "", err
+	}
+	parts := authorPartsPattern.FindStringSubmatch(author)
+	return parts[1], parts[2], nil
+}
+
+func ValidateMemberRole(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if !slices.Contains(memberRoles, input) {
+		return "", errors.Errorf("role must be one of %s", strings.Join(memberRoles, ", "))
+	}
+	return input, nil
+}
+
+func ValidateMemberAlias(input string) (string, error) {
+	input, err := ValidateAndCleanUnicode(strings.TrimSpace(input), false)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid alias")
+	} else if input == "" {
+		return "", errors.New("alias cannot be empty")
+	} else if d := MaximumMemberAliasLength; len(input) > d {
+		return "", errors.Errorf("alias is too long, it should be at most %d characters", d)
+	}
+	return input, nil
+}
+
+// ResolveMember finds the member that the input refers to. The input may be the canonical author of the member, an
+// author with the email of the member, the email itself, or one of the aliases of the member. Emails and aliases are
+// compared ignoring case.
+func ResolveMember(members []Member, input string) *Member {
+	input = strings.TrimSpace(input)
+	candidates := []string{input}
+	if _, email, err := ParseAuthor(input); err == nil {
+		candidates = append(candidates, email)
+	}
+	for i, m := range members {
+		if m.Author() == input {
+			return &members[i]
+		}
+	}
+	for i, m := range members {
+		for _, c := range candidates {
+			if strings.EqualFold(m.Email, c) || slices.ContainsFunc(m.Aliases, func(a string) bool { return strings.EqualFold(a, c) }) {
+				return &members[i]
+			}
+		}
+	}
+	return nil
+}
+
+func (p *inMemoryWorkspaceProvider) ListMembers(ctx context.Context) ([]Member, error) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	return listMembersInner(p.Doc), nil
+}
+
+// AddMember adds a new member to the workspace. The email and aliases must not already belong to another member. When
+// the workspace has no members yet and was created by an author, the creator is added as an admin along with the new
+// member so that adding the first member does not lock the creator out of the workspace.
+func (p *inMemoryWorkspaceProvider) AddMember(ctx context.Context, params AddMemberParams) (*Member, error) {
+	if err := ValidatedAuthor(params.AddedBy); err != nil {
+		return nil, err
+	}
+	name, email, err := ParseAuthor(strings.TrimSpace(params.Author))
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid member")
+	}
+	member := Member{Email: strings.ToLower(email), Name: name, Aliases: make([]string, 0, len(params.Aliases))}
+	if params.Role == "" {
+		params.Role = MemberRoleEditor
+	}
+	if member.Role, err = ValidateMemberRole(params.Role); err != nil {
+		return nil, err
+	}
+	for _, a := range params.Aliases {
+		if a, err = ValidateMemberAlias(a); err != nil {
+			return nil, err
+		} else if !strings.EqualFold(a, member.Email) && !slices.ContainsFunc(member.Aliases, func(x string) bool { return strings.EqualFold(x, a) }) {
+			member.Aliases = append(member.Aliases, a)
+		}
+	}
+	slices.Sort(member.Aliases)
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	existing := listMembersInner(p.Doc)
+	for _, identity := range append([]string{member.Email}, member.Aliases...) {
+		if other := ResolveMember(existing, identity); other != nil {
+			return nil, errors.Errorf("'%s' already belongs to member '%s'", identity, other.Author())
+		}
+	}
+	members, err := settingsMapInner(p.Doc, "members")
+	if err != nil {
+		return nil, err
+	}
+	if len(existing) == 0 {
+		if creator, err := workspaceCreatorInner(p.Doc); err != nil {
+			return nil, err
+		} else if creator != nil && creator.Email != member.Email {
+			if err := setMemberInner(members, *creator); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if err := setMemberInner(members, member); err != nil {
+		return nil, err
+	}
+
+	if _, err := p.commit(params.AddedBy + " added member " + member.Email); err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
+	return &member, nil
+}
+
+// MergeMembers combines other identities of the same person into one member. Source members are removed and their
+// emails, canonical authors, and aliases become aliases of the target member. Any other source becomes an alias too.
+func (p *inMemoryWorkspaceProvider) MergeMembers(ctx context.Context, params MergeMembersParams) (*Member, error) {
+	if err := ValidatedAuthor(params.MergedBy); err != nil {
+		return nil, err
+	} else if len(params.Sources) == 0 {
+		return nil, errors.New("nothing to merge")
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	existing := listMembersInner(p.Doc)
+	into := ResolveMember(existing, params.Into)
+	if into == nil {
+		return nil, errors.Errorf("member '%s' does not exist", params.Into)
+	}
+	merged := *into
+	merged.Aliases = slices.Clone(into.Aliases)
+	addAlias := func(a string) {
+		if !strings.EqualFold(a, merged.Email) && a != merged.Author() && !slices.ContainsFunc(merged.Aliases, func(x string) bool { return strings.EqualFold(x, a) }) {
+			merged.Aliases = append(merged.Aliases, a)
+		}
+	}
+	removed := make([]string, 0)
+	for _, s := range params.Sources {
+		s, err := ValidateMemberAlias(s)
+		if err != nil {
+			return nil, err
+		}
+		if other := ResolveMember(existing, s); other != nil && other.Email == merged.Email {
+			addAlias(s)
+		} else if other != nil {
+			removed = append(removed, other.Email)
+			addAlias(other.Email)
+			addAlias(other.Author())
+			for _, a := range other.Aliases {
+				addAlias(a)
+			}
+		} else {
+			addAlias(s)
+		}
+	}
+	slices.Sort(merged.Aliases)
+
+	members, err := settingsMapInner(p.Doc, "members")
+	if err != nil {
+		return nil, err
+	}
+	for _, email := range removed {
+		if v, _ := members.Get(email); v.Kind() == automerge.KindMap {
+			if err := members.Delete(email); err != nil {
+				return nil, errors.Wrap(err, "failed to delete member")
+			}
+		}
+	}
+	if err := setMemberInner(members, merged); err != nil {
+		return nil, err
+	}
+
+	if _, err := p.commit(params.MergedBy + " merged members into " + merged.Email); err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
+	return &merged, nil
+}
+
+// workspaceCreatorInner returns the author of the change that created the workspace as an admin, or nil if the
+// workspace was created without an author.
+func workspaceCreatorInner(doc *automerge.Doc) (*Member, error) {
+	changes, err := doc.Changes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get changes")
+	}
+	genesis := workspaceGenesis(changes)
+	if genesis == nil {
+		return nil, nil
+	}
+	name, email, err := ParseAuthor(commitAuthor(genesis.Message()))
+	if err != nil {
+		return nil, nil
+	}
+	return &Member{Email: strings.ToLower(email), Name: name, Role: MemberRoleAdmin, Aliases: make([]string, 0)}, nil
+}
+
+// setMemberInner replaces the whole member entry so that removed aliases do not linger.
+func setMemberInner(members *automerge.Map, m Member) error {
+	newMember, aliases := automerge.NewMap(), automerge.NewMap()
+	if err := members.Set(m.Email, newMember); err != nil {
+		return errors.Wrap(err, "failed to set member entry")
+	} else if err := newMember.Set("name", m.Name); err != nil {
+		return errors.Wrap(err, "failed to set name")
+	} else if err := newMember.Set("role", m.Role); err != nil {
+		return errors.Wrap(err, "failed to set role")
+	} else if err := newMember.Set("aliases", aliases); err != nil {
+		return errors.Wrap(err, "failed to set aliases")
+	}
+	for _, a := range m.Aliases {
+		if err := aliases.Set(a, true); err != nil {
+			return errors.Wrap(err, "failed to set alias")
+		}
+	}
+	return nil
+}
+
+// listMembersInner returns the valid members sorted by email. Entries with an invalid name or an unknown role are
+// ignored.
+func listMembersInner(doc *automerge.Doc) []Member {
+	output := make([]Member, 0)
+	members, err := settingsMapInner(doc, "members")
+	if err != nil {
+		return output
+	}
+	emails, _ := members.Keys()
+	for _, email := range emails {
+		v, _ := members.Get(email)
+		if v.Kind() != automerge.KindMap {
+			continue
+		}
+		m := Member{Email: email, Aliases: make([]string, 0)}
+		if n, _ := v.Map().Get("name"); n.Kind() == automerge.KindStr {
+			m.Name = n.Str()
+		}
+		if r, _ := v.Map().Get("role"); r.Kind() == automerge.KindStr {
+			m.Role = r.Str()
+		}
+		if a, _ := v.Map().Get("aliases"); a.Kind() == automerge.KindMap {
+			aliases, _ := a.Map().Keys()
+			m.Aliases = append(m.Aliases, aliases...)
+		}
+		if ValidatedAuthor(m.Author()) != nil {
+			continue
+		} else if _, err := ValidateMemberRole(m.Role); err != nil {
+			continue
+		}
+		slices.Sort(m.Aliases)
+		output = append(output, m)
+	}
+	slices.SortFunc(output, func(a, b Member) int {
+		return strings.Compare(a.Email, b.Email)
+	})
+	return output
+}
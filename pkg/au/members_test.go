@@ -0,0 +1,56 @@
+package au
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveMember(t *testing.T) {
+	members := []Member{
+		{Email: "alice@example.com", Name: "Alice", Role: MemberRoleEditor, Aliases: []string{"alice@old.com", "Ally <ally@home.com>"}},
+		{Email: "bob@example.com", Name: "Bob", Role: MemberRoleReader},
+	}
+	for input, expected := range map[string]string{
+		"Alice <alice@example.com>":    "alice@example.com",
+		"A. Smith <ALICE@example.com>": "alice@example.com",
+		"alice@old.com":                "alice@example.com",
+		"Someone <alice@old.com>":      "alice@example.com",
+		"ally <ally@home.com>":         "alice@example.com",
+		"bob@example.com":              "bob@example.com",
+		"Carol <carol@example.com>":    "",
+	} {
+		m := ResolveMember(members, input)
+		if expected == "" {
+			assert.Nil(t, m, input)
+		} else if assert.NotNil(t, m, input) {
+			assert.Equal(t, expected, m.Email, input)
+		}
+	}
+}
+
+func TestMembers(t *testing.T) {
+	ws := newTestWorkspace(t)
+	alice := "Alice <alice@me.com>"
+	ctx := context.Background()
+
+	m, err := ws.AddMember(ctx, AddMemberParams{Author: "Bob <Bob@Me.com>", Aliases: []string{"bob", "BOB", "bob@me.com"}, AddedBy: alice})
+	assert.NoError(t, err)
+	assert.Equal(t, &Member{Email: "bob@me.com", Name: "Bob", Role: MemberRoleEditor, Aliases: []string{"bob"}}, m)
+	_, err = ws.AddMember(ctx, AddMemberParams{Author: "Robert <bob@me.com>", AddedBy: alice})
+	assert.EqualError(t, err, "'bob@me.com' already belongs to member 'Bob <bob@me.com>'")
+	_, err = ws.AddMember(ctx, AddMemberParams{Author: "bob", AddedBy: alice})
+	assert.EqualError(t, err, "invalid member: invalid author string, expected 'Name <email>'")
+	_, err = ws.AddMember(ctx, AddMemberParams{Author: "Robert <robert@me.com>", Role: MemberRoleReader, AddedBy: alice})
+	assert.NoError(t, err)
+
+	merged, err := ws.MergeMembers(ctx, MergeMembersParams{Into: "bob", Sources: []string{"Robert <robert@me.com>", "bobby@laptop"}, MergedBy: alice})
+	assert.NoError(t, err)
+	assert.Equal(t, &Member{Email: "bob@me.com", Name: "Bob", Role: MemberRoleEditor, Aliases: []string{"Robert <robert@me.com>", "bob", "bobby@laptop", "robert@me.com"}}, merged)
+	members, err := ws.ListMembers(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []Member{*merged}, members)
+	_, err = ws.MergeMembers(ctx, MergeMembersParams{Into: "bob", MergedBy: alice})
+	assert.EqualError(t, err, "nothing to merge")
+}
@@ -2,8 +2,10 @@ package au
 
 import (
 	"context"
+	"maps"
 	"mime"
 	"slices"
+	"strings"
 	"sync"
 	"time"
 
@@ -18,6 +20,8 @@ type inMemoryWorkspaceProvider struct {
 	CurrentMetadata WorkspaceMeta
 	Doc             *automerge.Doc
 	Lock            sync.Mutex
+	// SigningKeys provides the keys that sign the changes made by each author, changes are unsigned when it is nil.
+	SigningKeys SigningKeyLookup
 }
 
 func NewInMemoryWorkspaceProvider(doc *automerge.Doc) *inMemoryWorkspaceProvider {
@@ -93,6 +97,9 @@ func getTodoInner(todos *automerge.Map, id string) (*Todo, error) {
 	} else if commentsValue.Kind() == automerge.KindMap {
 		output.CommentCount = commentsValue.Map().Len()
 	}
+	output.Checklist = checklistInner(item.Map())
+	output.TimeSpent = timeSpentInner(item.Map())
+	output.Timers = timersInner(item.Map())
 
 	return output, nil
 }
@@ -122,22 +129,18 @@ func (p *inMemoryWorkspaceProvider) CreateTodo(ctx context.Context, params Creat
 		return nil, err
 	}
 
-	status := "open"
-	if params.Status != nil {
-		status, err = ValidateTodoStatus(*params.Status)
-		if err != nil {
-			return nil, err
-		}
-	}
-
 	if params.Annotations != nil {
 		for k, v := range params.Annotations {
 			if err := ValidateTodoAnnotationKey(k); err != nil {
 				return nil, errors.Wrapf(err, "invalid annotation key '%s'", k)
 			} else if v == "" {
 				return nil, errors.Errorf("annotation '%s' has an empty value", k)
+			} else if err := ValidateTodoAnnotationValue(k, v); err != nil {
+				return nil, errors.Wrapf(err, "invalid annotation value for '%s'", k)
 			}
 		}
+		// the annotations are copied since the workflow status may be added, and callers may reuse the params
+		params.Annotations = maps.Clone(params.Annotations)
 	} else {
 		params.Annotations = make(map[string]string)
 	}
@@ -146,6 +149,27 @@ func (p *inMemoryWorkspaceProvider) CreateTodo(ctx context.Context, params Creat
 	defer p.Lock.Unlock()
 
 	todos := p.Doc.Path("todos").Map()
+	if parentId := params.Annotations[AurelianParentAnnotation]; parentId != "" {
+		if err := validateParentInner(todos, "", parentId); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateBlockersInner(todos, "", params.Annotations); err != nil {
+		return nil, err
+	}
+	if err := validateDeclaredAnnotationsInner(p.Doc, params.Annotations); err != nil {
+		return nil, err
+	}
+	requestedStatus := defaultStatusInner(p.Doc)
+	if params.Status != nil {
+		requestedStatus = strings.TrimSpace(*params.Status)
+	}
+	status, workflowStatus, err := resolveStatusInner(p.Doc, nil, requestedStatus)
+	if err != nil {
+		return nil, err
+	} else if workflowStatus != "" {
+		params.Annotations[AurelianWorkflowStatusAnnotation] = workflowStatus
+	}
 	todoId := ulid.Make().String()
 	// TODO: check for conflict
 
@@ -169,6 +193,9 @@ func (p *inMemoryWorkspaceProvider) CreateTodo(ctx context.Context, params Creat
 	if err := newTodo.Set("created_by", params.CreatedBy); err != nil {
 		return nil, errors.Wrap(err, "failed to set created_by")
 	}
+	if _, err := initTimeTrackingInner(newTodo); err != nil {
+		return nil, err
+	}
 
 	newAnnotations := automerge.NewMap()
 	_ = newTodo.Set("annotations", newAnnotations)
@@ -176,77 +203,156 @@ func (p *inMemoryWorkspaceProvider) CreateTodo(ctx context.Context, params Creat
 		_ = newAnnotations.Set(k, v)
 	}
 
-	if _, err := p.Doc.Commit(params.CreatedBy + " created todo " + todoId); err != nil {
+	if _, err := p.commit(params.CreatedBy + " created todo " + todoId); err != nil {
 		return nil, errors.Wrap(err, "failed to commit")
 	}
 	return getTodoInner(todos, todoId)
 }
 
 func (p *inMemoryWorkspaceProvider) EditTodo(ctx context.Context, id string, params EditTodoParams) (*Todo, error) {
+	params, err := validateEditTodoParams(params)
+	if err != nil {
+		return nil, err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	return p.editTodoInner(id, params)
+}
+
+// editTodoInner applies and commits an edit which has been validated by validateEditTodoParams while the lock is held.
+func (p *inMemoryWorkspaceProvider) editTodoInner(id string, params EditTodoParams) (*Todo, error) {
+	todos := p.Doc.Path("todos").Map()
+	td, prepared, err := prepareEditTodoInner(p.Doc, todos, id, params)
+	if err != nil {
+		return nil, err
+	}
+	closing := prepared.Status != nil && *prepared.Status == "closed" && td.Status != "closed"
+	if closing {
+		if n := openChildCountInner(todos, id); n > 0 {
+			return nil, errors.Errorf("cannot close todo '%s' since it has %d open children", id, n)
+		}
+	}
+	updatedAt := time.Now().UTC().Truncate(time.Second)
+	if err := applyEditTodoInner(todos, id, prepared, updatedAt); err != nil {
+		return nil, err
+	}
+
+	message := params.UpdatedBy + " edited todo " + id
+	if closing {
+		// the spawned occurrence is named in the message so that the change shows up in its history too
+		if nextId, err := spawnNextOccurrenceInner(p.Doc, todos, id, params.UpdatedBy, updatedAt); err != nil {
+			return nil, err
+		} else if nextId != "" {
+			message += " and created todo " + nextId
+		}
+	}
+
+	if _, err := p.commit(message, automerge.CommitOptions{AllowEmpty: true}); err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
+	return getTodoInner(todos, id)
+}
+
+// validateEditTodoParams validates and cleans the parts of the edit which do not depend on the todo being edited.
+func validateEditTodoParams(params EditTodoParams) (EditTodoParams, error) {
 	if params.Title != nil {
 		o, err := ValidateTodoTitle(*params.Title)
 		if err != nil {
-			return nil, err
+			return params, err
 		}
 		params.Title = &o
 	}
 	if params.Description != nil {
 		o, err := ValidateTodoDescription(*params.Description)
 		if err != nil {
-			return nil, err
+			return params, err
 		}
 		params.Description = &o
 	}
-	if params.Status != nil {
-		o, err := ValidateTodoStatus(*params.Status)
-		if err != nil {
-			return nil, err
-		}
-		params.Status = &o
-	}
 	if err := ValidatedAuthor(params.UpdatedBy); err != nil {
-		return nil, err
+		return params, err
 	}
-
-	if params.Annotations != nil {
-		for k, v := range params.Annotations {
-			if err := ValidateTodoAnnotationKey(k); v != "" && err != nil {
-				return nil, errors.Wrapf(err, "invalid annotation key '%s'", k)
-			}
+	for k, v := range params.Annotations {
+		if err := ValidateTodoAnnotationKey(k); v != "" && err != nil {
+			return params, errors.Wrapf(err, "invalid annotation key '%s'", k)
+		} else if err := ValidateTodoAnnotationValue(k, v); v != "" && err != nil {
+			return params, errors.Wrapf(err, "invalid annotation value for '%s'", k)
 		}
-	} else {
-		params.Annotations = make(map[string]string)
 	}
+	return params, nil
+}
 
-	p.Lock.Lock()
-	defer p.Lock.Unlock()
-
-	todos := p.Doc.Path("todos").Map()
+// prepareEditTodoInner checks that the edit can be applied to the todo and returns the todo along with the edit
+// resolved for it, such as the workflow status annotations which change along with the status. The document is not
+// modified, so a failure leaves nothing to undo.
+func prepareEditTodoInner(doc *automerge.Doc, todos *automerge.Map, id string, params EditTodoParams) (*Todo, EditTodoParams, error) {
 	td, err := getTodoInner(todos, id)
 	if err != nil {
-		return nil, err
+		return nil, params, err
+	}
+	annotations := make(map[string]string, len(params.Annotations)+2)
+	for k, v := range params.Annotations {
+		annotations[k] = v
+	}
+	params.Annotations = annotations
+	if parentId := params.Annotations[AurelianParentAnnotation]; parentId != "" {
+		if err := validateParentInner(todos, id, parentId); err != nil {
+			return nil, params, err
+		}
+	}
+	if err := validateBlockersInner(todos, id, params.Annotations); err != nil {
+		return nil, params, err
+	}
+	if err := validateDeclaredAnnotationsInner(doc, params.Annotations); err != nil {
+		return nil, params, err
+	} else if err := validateReadOnlyAnnotationsInner(doc, td, params.Annotations); err != nil {
+		return nil, params, err
+	}
+	if params.Status != nil {
+		status, workflowStatus, err := resolveStatusInner(doc, td, strings.TrimSpace(*params.Status))
+		if err != nil {
+			return nil, params, err
+		}
+		if _, ok := td.Annotations[AurelianWorkflowStatusAnnotation]; ok || workflowStatus != "" {
+			params.Annotations[AurelianWorkflowStatusAnnotation] = workflowStatus
+		}
+		// a reason describes the previous status, so it is cleared unless a new one is given along with the change
+		if _, ok := params.Annotations[AurelianStatusReasonAnnotation]; !ok && td.Annotations[AurelianStatusReasonAnnotation] != "" {
+			if workflowStatus == "" {
+				workflowStatus = status
+			}
+			if workflowStatus != TodoWorkflowStatus(td, listWorkflowStatusesInner(doc)) {
+				params.Annotations[AurelianStatusReasonAnnotation] = ""
+			}
+		}
+		params.Status = &status
 	}
-	todoValue, err := p.Doc.Path("todos").Map().Get(id)
+	return td, params, nil
+}
+
+// applyEditTodoInner writes an edit returned by prepareEditTodoInner to the todo without committing it.
+func applyEditTodoInner(todos *automerge.Map, id string, params EditTodoParams, updatedAt time.Time) error {
+	todoValue, err := todos.Get(id)
 	if err != nil {
-		return nil, err
+		return err
 	}
 	if params.Title != nil {
 		existingTitleValue, _ := todoValue.Map().Get("title")
-		if td.Description, err = spliceTextNode(existingTitleValue.Text(), *params.Title); err != nil {
-			return nil, err
+		if _, err = spliceTextNode(existingTitleValue.Text(), *params.Title); err != nil {
+			return err
 		}
 	}
 	if params.Description != nil {
 		existingDescriptionValue, _ := todoValue.Map().Get("description")
-		if td.Description, err = spliceTextNode(existingDescriptionValue.Text(), *params.Description); err != nil {
-			return nil, err
+		if _, err = spliceTextNode(existingDescriptionValue.Text(), *params.Description); err != nil {
+			return err
 		}
 	}
 	if params.Status != nil {
 		if err := todoValue.Map().Set("status", *params.Status); err != nil {
-			return nil, errors.Wrap(err, "failed to set status on existing todo")
+			return errors.Wrap(err, "failed to set status on existing todo")
 		}
-		td.Status = *params.Status
 	}
 
 	annotationsValue, _ := todoValue.Map().Get("annotations")
@@ -258,27 +364,22 @@ func (p *inMemoryWorkspaceProvider) EditTodo(ctx context.Context, id string, par
 	for k, v := range params.Annotations {
 		if v == "" {
 			if err = annotationsValue.Map().Delete(k); err != nil {
-				return nil, errors.Wrap(err, "failed to delete annotation")
+				return errors.Wrap(err, "failed to delete annotation")
 			}
 		} else {
 			if err = annotationsValue.Map().Set(k, v); err != nil {
-				return nil, errors.Wrap(err, "failed to set annotation")
+				return errors.Wrap(err, "failed to set annotation")
 			}
 		}
 	}
 
-	updatedAt := time.Now().UTC().Truncate(time.Second)
 	if err := todoValue.Map().Set("updated_at", updatedAt); err != nil {
-		return nil, errors.Wrap(err, "failed to set updated_at")
+		return errors.Wrap(err, "failed to set updated_at")
 	}
 	if err := todoValue.Map().Set("updated_by", params.UpdatedBy); err != nil {
-		return nil, errors.Wrap(err, "failed to set updated_by")
+		return errors.Wrap(err, "failed to set updated_by")
 	}
-
-	if _, err := p.Doc.Commit(params.UpdatedBy+" edited todo "+id, automerge.CommitOptions{AllowEmpty: true}); err != nil {
-		return nil, errors.Wrap(err, "failed to commit")
-	}
-	return getTodoInner(todos, id)
+	return nil
 }
 
 func spliceTextNode(node *automerge.Text, newValue string) (string, error) {
@@ -354,10 +455,16 @@ func (p *inMemoryWorkspaceProvider) DeleteTodo(ctx context.Context, id string, p
 	if err != nil {
 		return err
 	}
+	if err := reparentChildrenInner(todos, id, params.DeletedBy); err != nil {
+		return err
+	}
+	if err := unblockDependentsInner(todos, id, params.DeletedBy); err != nil {
+		return err
+	}
 	if err := todos.Delete(id); err != nil {
 		return err
 	}
-	if _, err := p.Doc.Commit(params.DeletedBy + " deleted todo " + id); err != nil {
+	if _, err := p.commit(params.DeletedBy + " deleted todo " + id); err != nil {
 		return errors.Wrap(err, "failed to commit")
 	}
 	return nil
@@ -422,6 +529,10 @@ func getCommentInner(comments *automerge.Map, id string) (*Comment, error) {
 	if updatedByValue, _ := item.Map().Get("updated_by"); updatedByValue.Kind() == automerge.KindStr {
 		output.UpdatedBy = internal.Ref(updatedByValue.Str())
 	}
+	if replyToValue, _ := item.Map().Get("reply_to"); replyToValue.Kind() == automerge.KindStr {
+		output.ReplyTo = internal.Ref(replyToValue.Str())
+	}
+	output.Attachment = getAttachmentInner(item.Map())
 	return output, nil
 }
 
@@ -449,17 +560,28 @@ func (p *inMemoryWorkspaceProvider) CreateComment(ctx context.Context, todoId st
 		return nil, errors.Wrap(err, "invalid mime type")
 	}
 
+	if params.Attachment != nil {
+		a, err := ValidateAttachment(*params.Attachment)
+		if err != nil {
+			return nil, err
+		}
+		params.Attachment = &a
+	}
+
 	if params.MediaType == DefaultCommentMediaType {
 		if c, err := ValidateAndCleanUnicode(string(params.Content), true); err != nil {
 			return nil, err
-		} else if len(c) == 0 {
+		} else if len(c) == 0 && params.Attachment == nil {
 			return nil, errors.New("content is empty")
 		} else {
 			params.Content = []byte(c)
 		}
-	} else if len(params.Content) == 0 {
+	} else if len(params.Content) == 0 && params.Attachment == nil {
 		return nil, errors.New("content is empty")
 	}
+	if d := MaximumCommentContentSize; len(params.Content) > d {
+		return nil, errors.Errorf("content is too large, it should be at most %d bytes, use an attachment instead", d)
+	}
 
 	if err := ValidatedAuthor(params.CreatedBy); err != nil {
 		return nil, err
@@ -481,6 +603,11 @@ func (p *inMemoryWorkspaceProvider) CreateComment(ctx context.Context, todoId st
 		_ = p.Doc.Path("todos", todoId, "comments").Set(automerge.NewMap())
 		commentsValue, _ = p.Doc.Path("todos", todoId, "comments").Get()
 	}
+	if params.ReplyTo != "" {
+		if _, err := getCommentInner(commentsValue.Map(), params.ReplyTo); err != nil {
+			return nil, errors.Wrap(err, "invalid reply to")
+		}
+	}
 
 	newComment := automerge.NewMap()
 	newCommentId := ulid.Make().String()
@@ -498,8 +625,18 @@ func (p *inMemoryWorkspaceProvider) CreateComment(ctx context.Context, todoId st
 	if err := newComment.Set("created_by", params.CreatedBy); err != nil {
 		return nil, errors.Wrap(err, "failed to set created_by")
 	}
+	if params.Attachment != nil {
+		if err := setAttachmentInner(newComment, *params.Attachment); err != nil {
+			return nil, err
+		}
+	}
+	if params.ReplyTo != "" {
+		if err := newComment.Set("reply_to", params.ReplyTo); err != nil {
+			return nil, errors.Wrap(err, "failed to set reply_to")
+		}
+	}
 
-	if _, err := p.Doc.Commit(params.CreatedBy + " created comment " + newCommentId + " in todo " + todoId); err != nil {
+	if _, err := p.commit(params.CreatedBy + " created comment " + newCommentId + " in todo " + todoId); err != nil {
 		return nil, errors.Wrap(err, "failed to commit")
 	}
 	return getCommentInner(commentsValue.Map(), newCommentId)
@@ -538,19 +675,22 @@ func (p *inMemoryWorkspaceProvider) EditComment(ctx context.Context, todoId, com
 	} else if mediaTypeValue.Kind() != automerge.KindStr {
 		return nil, errors.Wrap(err, "media type is not a string")
 	} else {
-		mediaType := mediaTypeValue.Str()
+		mediaType, hasAttachment := mediaTypeValue.Str(), getAttachmentInner(commentValue.Map()) != nil
 		if mediaType == DefaultCommentMediaType {
 			if c, err := ValidateAndCleanUnicode(string(params.Content), true); err != nil {
 				return nil, err
-			} else if len(c) == 0 {
+			} else if len(c) == 0 && !hasAttachment {
 				return nil, errors.New("content is empty")
 			} else {
 				params.Content = []byte(c)
 			}
-		} else if len(params.Content) == 0 {
+		} else if len(params.Content) == 0 && !hasAttachment {
 			return nil, errors.New("content is empty")
 		}
 	}
+	if d := MaximumCommentContentSize; len(params.Content) > d {
+		return nil, errors.Errorf("content is too large, it should be at most %d bytes, use an attachment instead", d)
+	}
 
 	if err = commentValue.Map().Set("content", params.Content); err != nil {
 		return nil, errors.Wrap(err, "failed to set content")
@@ -564,7 +704,7 @@ func (p *inMemoryWorkspaceProvider) EditComment(ctx context.Context, todoId, com
 		return nil, errors.Wrap(err, "failed to set updated_by")
 	}
 
-	if _, err := p.Doc.Commit(params.UpdatedBy + " edited comment " + commentId + " in todo " + todoId); err != nil {
+	if _, err := p.commit(params.UpdatedBy + " edited comment " + commentId + " in todo " + todoId); err != nil {
 		return nil, errors.Wrap(err, "failed to commit")
 	}
 	return getCommentInner(commentsValue.Map(), commentId)
@@ -589,15 +729,41 @@ func (p *inMemoryWorkspaceProvider) DeleteComment(ctx context.Context, todoId, c
 		return errors.Wrap(err, "failed to get comments in todos")
 	} else if commentsValue.Kind() != automerge.KindMap {
 		return errors.Errorf("comment with id '%s' does not exist", commentId)
+	}
+	deleted, err := getCommentInner(commentsValue.Map(), commentId)
+	if err != nil {
+		return err
+	} else if err := reparentRepliesInner(commentsValue.Map(), commentId, deleted.ReplyTo); err != nil {
+		return err
 	} else if err = commentsValue.Map().Delete(commentId); err != nil {
 		return errors.New("failed to delete comment")
 	}
-	if _, err := p.Doc.Commit(params.DeletedBy + " deleted comment " + commentId + " in todo " + todoId); err != nil {
+	if _, err := p.commit(params.DeletedBy + " deleted comment " + commentId + " in todo " + todoId); err != nil {
 		return errors.Wrap(err, "failed to commit")
 	}
 	return nil
 }
 
+// reparentRepliesInner moves the direct replies of a deleted comment up to the parent of the deleted comment, or to the
+// top level if it had no parent, so that deleting a comment never hides the rest of the thread.
+func reparentRepliesInner(comments *automerge.Map, commentId string, parent *string) error {
+	commentIds, _ := comments.Keys()
+	for _, id := range commentIds {
+		if c, err := getCommentInner(comments, id); err != nil || c.ReplyTo == nil || *c.ReplyTo != commentId {
+			continue
+		}
+		commentValue, _ := comments.Get(id)
+		if parent != nil {
+			if err := commentValue.Map().Set("reply_to", *parent); err != nil {
+				return errors.Wrap(err, "failed to set reply_to")
+			}
+		} else if err := commentValue.Map().Delete("reply_to"); err != nil {
+			return errors.Wrap(err, "failed to delete reply_to")
+		}
+	}
+	return nil
+}
+
 func (p *inMemoryWorkspaceProvider) Flush() error {
 	return nil
 }
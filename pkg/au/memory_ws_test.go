@@ -181,7 +181,7 @@ func TestEditTodo_check_efficient_description(t *testing.T) {
 		if h := wsp.(DocProvider).GetDoc().Heads(); assert.Len(t, h, 1) {
 			c, _ := wsp.(DocProvider).GetDoc().Change(h[0])
 			assert.Equal(t, "Example <email@me.com> edited todo "+td.Id, c.Message())
-			assert.Len(t, automerge.SaveChanges([]*automerge.Change{c}), 214)
+			assert.Len(t, automerge.SaveChanges([]*automerge.Change{c}), 215)
 		}
 	})
 }
@@ -210,6 +210,41 @@ func TestDeleteTodo_success(t *testing.T) {
 	}
 }
 
+func TestCreateComment_reply(t *testing.T) {
+	wsp := newTestWorkspace(t)
+	author := "Example <email@me.com>"
+	ctx := context.Background()
+
+	td, err := wsp.CreateTodo(ctx, CreateTodoParams{Title: "Do the thing", CreatedBy: author})
+	assert.NoError(t, err)
+	other, err := wsp.CreateTodo(ctx, CreateTodoParams{Title: "Do the other thing", CreatedBy: author})
+	assert.NoError(t, err)
+	root, err := wsp.CreateComment(ctx, td.Id, CreateCommentParams{MediaType: DefaultCommentMediaType, Content: []byte("root"), CreatedBy: author})
+	assert.NoError(t, err)
+	assert.Nil(t, root.ReplyTo)
+
+	_, err = wsp.CreateComment(ctx, other.Id, CreateCommentParams{MediaType: DefaultCommentMediaType, Content: []byte("x"), ReplyTo: root.Id, CreatedBy: author})
+	assert.EqualError(t, err, "invalid reply to: comment with id '"+root.Id+"' does not exist")
+
+	reply, err := wsp.CreateComment(ctx, td.Id, CreateCommentParams{MediaType: DefaultCommentMediaType, Content: []byte("reply"), ReplyTo: root.Id, CreatedBy: author})
+	assert.NoError(t, err)
+	assert.Equal(t, &root.Id, reply.ReplyTo)
+	nested, err := wsp.CreateComment(ctx, td.Id, CreateCommentParams{MediaType: DefaultCommentMediaType, Content: []byte("nested"), ReplyTo: reply.Id, CreatedBy: author})
+	assert.NoError(t, err)
+
+	// deleting a comment in the middle of a thread moves its replies up to its parent
+	assert.NoError(t, wsp.DeleteComment(ctx, td.Id, reply.Id, DeleteCommentParams{DeletedBy: author}))
+	nested, _ = wsp.GetComment(ctx, td.Id, nested.Id)
+	assert.Equal(t, &root.Id, nested.ReplyTo)
+
+	// deleting the root makes its replies top level comments
+	assert.NoError(t, wsp.DeleteComment(ctx, td.Id, root.Id, DeleteCommentParams{DeletedBy: author}))
+	nested, _ = wsp.GetComment(ctx, td.Id, nested.Id)
+	assert.Nil(t, nested.ReplyTo)
+
+	assert.EqualError(t, wsp.DeleteComment(ctx, td.Id, root.Id, DeleteCommentParams{DeletedBy: author}), "comment with id '"+root.Id+"' does not exist")
+}
+
 func TestStringBreak(t *testing.T) {
 	for _, tc := range []string{
 		"     ",
@@ -0,0 +1,200 @@
+package au
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/automerge/automerge-go"
+)
+
+// Mention is a todo description or markdown comment which mentions an author.
+type Mention struct {
+	TodoId    string
+	TodoTitle string
+	// CommentId is empty when the mention is in the title or description of the todo.
+	CommentId string
+	// MentionedBy and At are the author and time of the change which introduced the mention, which may be older than
+	// the last edit of the todo or comment.
+	MentionedBy string
+	At          time.Time
+}
+
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@.])@(\w[\w.+-]*(?:@\w[\w.-]*)?)`)
+var fencedCodePattern = regexp.MustCompile("(?ms)^```.*?(^```|\\z)")
+var inlineCodePattern = regexp.MustCompile("`[^`\n]*`")
+
+// ParseMentions returns the unique lower-cased handles mentioned as @handle in the markdown text in order of first
+// appearance. Mentions inside code spans and fenced code blocks are ignored.
+func ParseMentions(text string) []string {
+	text = fencedCodePattern.ReplaceAllString(text, "")
+	text = inlineCodePattern.ReplaceAllString(text, "")
+	output := make([]string, 0)
+	for _, m := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		handle := strings.ToLower(strings.TrimRight(m[1], ".-"))
+		if !slices.Contains(output, handle) {
+			output = append(output, handle)
+		}
+	}
+	return output
+}
+
+// AuthorHandles returns the lower-cased handles that mention the "Username <email>" author: the full email, the local
+// part of the email, and the username without whitespace.
+func AuthorHandles(author string) []string {
+	name, email, ok := strings.Cut(strings.TrimSuffix(author, ">"), " <")
+	if !ok {
+		return nil
+	}
+	email = strings.ToLower(email)
+	local, _, _ := strings.Cut(email, "@")
+	output := []string{email, local}
+	if n := strings.ToLower(strings.Join(strings.Fields(name), "")); !slices.Contains(output, n) {
+		output = append(output, n)
+	}
+	return output
+}
+
+// ResolveMentions returns the sorted authors matched by any of the handles. A handle that matches more than one author
+// mentions all of them.
+func ResolveMentions(handles []string, authors []string) []string {
+	output := make([]string, 0)
+	for _, a := range authors {
+		if slices.ContainsFunc(AuthorHandles(a), func(h string) bool { return slices.Contains(handles, h) }) {
+			output = append(output, a)
+		}
+	}
+	slices.Sort(output)
+	return output
+}
+
+// ListAuthors returns the sorted authors who have created or edited any todo or comment in the workspace.
+func (p *inMemoryWorkspaceProvider) ListAuthors(ctx context.Context) ([]string, error) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	return listAuthorsInner(p.Doc), nil
+}
+
+func listAuthorsInner(doc *automerge.Doc) []string {
+	output := make([]string, 0)
+	add := func(author string, updatedBy *string) {
+		for _, a := range []*string{&author, updatedBy} {
+			if a != nil && *a != "" && !slices.Contains(output, *a) {
+				output = append(output, *a)
+			}
+		}
+	}
+	todos := doc.Path("todos").Map()
+	todoIds, _ := todos.Keys()
+	for _, todoId := range todoIds {
+		td, err := getTodoInner(todos, todoId)
+		if err != nil {
+			continue
+		}
+		add(td.CreatedBy, td.UpdatedBy)
+		commentsValue, _ := doc.Path("todos", todoId, "comments").Get()
+		if commentsValue.Kind() != automerge.KindMap {
+			continue
+		}
+		commentIds, _ := commentsValue.Map().Keys()
+		for _, commentId := range commentIds {
+			if c, err := getCommentInner(commentsValue.Map(), commentId); err == nil {
+				add(c.CreatedBy, c.UpdatedBy)
+			}
+		}
+	}
+	slices.Sort(output)
+	return output
+}
+
+// ListMentions returns the todos and comments mentioning the author where the mention was introduced at or after the
+// given time, newest first. Mentions written by the author themselves are excluded.
+func (p *inMemoryWorkspaceProvider) ListMentions(ctx context.Context, author string, since time.Time) ([]Mention, error) {
+	if err := ValidatedAuthor(author); err != nil {
+		return nil, err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	authors := listAuthorsInner(p.Doc)
+	if !slices.Contains(authors, author) {
+		authors = append(authors, author)
+	}
+	mentions := func(text string) bool {
+		return slices.Contains(ResolveMentions(ParseMentions(text), authors), author)
+	}
+
+	output := make([]Mention, 0)
+	todos := p.Doc.Path("todos").Map()
+	todoIds, _ := todos.Keys()
+	for _, todoId := range todoIds {
+		td, err := getTodoInner(todos, todoId)
+		if err != nil {
+			continue
+		}
+		if mentions(td.Title + "\n" + td.Description) {
+			at, by := mentionIntroductionInner(p.Doc, mentions, []string{"title", "description"}, todoId, "todos", todoId)
+			if by != author && !at.Before(since) {
+				output = append(output, Mention{TodoId: td.Id, TodoTitle: td.Title, MentionedBy: by, At: at})
+			}
+		}
+		commentsValue, _ := p.Doc.Path("todos", todoId, "comments").Get()
+		if commentsValue.Kind() != automerge.KindMap {
+			continue
+		}
+		commentIds, _ := commentsValue.Map().Keys()
+		for _, commentId := range commentIds {
+			c, err := getCommentInner(commentsValue.Map(), commentId)
+			if err != nil || c.MediaType != DefaultCommentMediaType {
+				continue
+			}
+			if mentions(string(c.Content)) {
+				at, by := mentionIntroductionInner(p.Doc, mentions, []string{"content"}, commentId, "todos", todoId, "comments", commentId)
+				if by != author && !at.Before(since) {
+					output = append(output, Mention{TodoId: td.Id, TodoTitle: td.Title, CommentId: c.Id, MentionedBy: by, At: at})
+				}
+			}
+		}
+	}
+	slices.SortStableFunc(output, func(a, b Mention) int {
+		return b.At.Compare(a.At)
+	})
+	return output, nil
+}
+
+// mentionIntroductionInner returns the time and author of the latest change to the object at the path after which the
+// given text fields of the object mention the author while they did not before. Later edits that keep the mention,
+// such as fixing a typo or changing other fields, do not move the mention to their author.
+func mentionIntroductionInner(doc *automerge.Doc, mentions func(string) bool, fields []string, id string, path ...string) (time.Time, string) {
+	history, _ := getHistoryInner(doc, id, path...)
+	values := make(map[string]string, len(fields))
+	texts := make([]string, len(fields))
+	var at time.Time
+	var by string
+	mentioned := false
+	for _, entry := range history {
+		for _, fc := range entry.Changes {
+			if !slices.Contains(fields, fc.Field) {
+				continue
+			} else if fc.After != nil {
+				values[fc.Field] = *fc.After
+			} else {
+				delete(values, fc.Field)
+			}
+		}
+		for i, f := range fields {
+			texts[i] = values[f]
+		}
+		if now := mentions(strings.Join(texts, "\n")); now && !mentioned {
+			// change timestamps have millisecond precision while the times of todos and comments are whole seconds
+			at, by = entry.At.Truncate(time.Second), entry.Author
+			mentioned = true
+		} else {
+			mentioned = now
+		}
+	}
+	return at, by
+}
@@ -0,0 +1,90 @@
+package au
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aurelian-one/au/internal"
+)
+
+func TestParseMentions(t *testing.T) {
+	for _, tc := range []struct {
+		input    string
+		expected []string
+	}{
+		{"", []string{}},
+		{"hey @alice, can you and @Bob.Smith look?", []string{"alice", "bob.smith"}},
+		{"cc @alice@example.com.", []string{"alice@example.com"}},
+		{"mail bob@example.com or @alice @alice", []string{"alice"}},
+		{"ignore `@code` and\n```\n@fenced\n```\nbut not @real", []string{"real"}},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			assert.Equal(t, tc.expected, ParseMentions(tc.input))
+		})
+	}
+}
+
+func TestResolveMentions(t *testing.T) {
+	authors := []string{"Alice Smith <alice@example.com>", "Bob <bob@example.com>", "Alice Jones <alice@other.com>"}
+	assert.Equal(t, []string{"Alice Smith <alice@example.com>"}, ResolveMentions([]string{"alicesmith"}, authors))
+	assert.Equal(t, []string{"Alice Jones <alice@other.com>", "Alice Smith <alice@example.com>"}, ResolveMentions([]string{"alice"}, authors))
+	assert.Equal(t, []string{"Bob <bob@example.com>"}, ResolveMentions([]string{"bob@example.com", "nobody"}, authors))
+}
+
+func TestListMentions(t *testing.T) {
+	ws := newTestWorkspace(t)
+	alice, bob := "Alice <alice@example.com>", "Bob <bob@example.com>"
+	ctx := context.Background()
+	start := time.Now().UTC().Truncate(time.Second)
+
+	mentions, err := ws.ListMentions(ctx, alice, time.Time{})
+	assert.NoError(t, err)
+	assert.Empty(t, mentions)
+
+	td, err := ws.CreateTodo(ctx, CreateTodoParams{Title: "Review", Description: "for @alice", CreatedBy: bob})
+	assert.NoError(t, err)
+	_, err = ws.CreateTodo(ctx, CreateTodoParams{Title: "Self", Description: "note to @alice", CreatedBy: alice})
+	assert.NoError(t, err)
+	c, err := ws.CreateComment(ctx, td.Id, CreateCommentParams{MediaType: DefaultCommentMediaType, Content: []byte("@Alice ping"), CreatedBy: bob})
+	assert.NoError(t, err)
+	_, err = ws.CreateComment(ctx, td.Id, CreateCommentParams{MediaType: DefaultCommentMediaType, Content: []byte("@bob done"), CreatedBy: alice})
+	assert.NoError(t, err)
+
+	authors, err := ws.ListAuthors(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{alice, bob}, authors)
+
+	mentions, err = ws.ListMentions(ctx, alice, start)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, []Mention{
+		{TodoId: td.Id, TodoTitle: "Review", MentionedBy: bob, At: td.CreatedAt},
+		{TodoId: td.Id, TodoTitle: "Review", CommentId: c.Id, MentionedBy: bob, At: c.CreatedAt},
+	}, mentions)
+
+	mentions, err = ws.ListMentions(ctx, alice, start.Add(time.Hour))
+	assert.NoError(t, err)
+	assert.Empty(t, mentions)
+
+	// later edits which keep the mention, even by the mentioned author, do not change who mentioned them
+	_, err = ws.EditTodo(ctx, td.Id, EditTodoParams{Title: internal.Ref("Review it"), UpdatedBy: "Carol <carol@example.com>"})
+	assert.NoError(t, err)
+	_, err = ws.EditTodo(ctx, td.Id, EditTodoParams{Description: internal.Ref("for @alice!"), UpdatedBy: alice})
+	assert.NoError(t, err)
+	mentions, err = ws.ListMentions(ctx, alice, start)
+	assert.NoError(t, err)
+	assert.Len(t, mentions, 2)
+	for _, m := range mentions {
+		assert.Equal(t, bob, m.MentionedBy)
+	}
+
+	// removing the mention from the description removes it from the inbox
+	_, err = ws.EditTodo(ctx, td.Id, EditTodoParams{Description: internal.Ref("for nobody"), UpdatedBy: bob})
+	assert.NoError(t, err)
+	mentions, err = ws.ListMentions(ctx, alice, start)
+	assert.NoError(t, err)
+	assert.Len(t, mentions, 1)
+	assert.Equal(t, c.Id, mentions[0].CommentId)
+}
@@ -0,0 +1,246 @@
+package au
+
+import (
+	"context"
+	"math"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+// MoveTodoParams describes where to move a todo in the ranked order. Exactly one of Before, After, Top, or Bottom must
+// be set. Before places the todo directly above the other todo in the list while After places it directly below.
+type MoveTodoParams struct {
+	Before  string
+	After   string
+	Top     bool
+	Bottom  bool
+	MovedBy string
+}
+
+type RebalanceRanksParams struct {
+	RebalancedBy string
+}
+
+// ParseRank parses a rank annotation value. Ranks are decimal numbers and higher ranks are listed first, integers from
+// older versions remain valid.
+func ParseRank(input string) (float64, error) {
+	r, err := strconv.ParseFloat(strings.TrimSpace(input), 64)
+	if err != nil || math.IsNaN(r) || math.IsInf(r, 0) {
+		return 0, errors.Errorf("rank '%s' must be a finite decimal number", input)
+	}
+	return r, nil
+}
+
+func FormatRank(rank float64) string {
+	return strconv.FormatFloat(rank, 'f', -1, 64)
+}
+
+// TodoRank returns the rank of the todo, todos without a valid rank have a rank of 0.
+func TodoRank(todo *Todo) float64 {
+	r, _ := ParseRank(todo.Annotations[AurelianRankAnnotation])
+	return r
+}
+
+// SortTodosByRank sorts the todos by descending rank. Equal ranks, which may be produced by concurrent moves on
+// different peers, are ordered by creation time and then id so that every peer shows the same order.
+func SortTodosByRank(todos []Todo) {
+	slices.SortStableFunc(todos, func(a, b Todo) int {
+		if ra, rb := TodoRank(&a), TodoRank(&b); ra != rb {
+			if ra > rb {
+				return -1
+			}
+			return 1
+		} else if c := a.CreatedAt.Compare(b.CreatedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Id, b.Id)
+	})
+}
+
+// RankBetween returns a rank that sorts between the rank of the todo above and the todo below. A nil bound means there
+// is no todo on that side. The second return value is false if there is no room between the bounds.
+func RankBetween(above, below *float64) (float64, bool) {
+	switch {
+	case above == nil && below == nil:
+		return 0, true
+	case above == nil:
+		return math.Floor(*below) + 1, true
+	case below == nil:
+		return math.Ceil(*above) - 1, true
+	}
+	mid := *below + (*above-*below)/2
+	return mid, *above > *below && mid < *above && mid > *below
+}
+
+func (p *inMemoryWorkspaceProvider) MoveTodo(ctx context.Context, id string, params MoveTodoParams) (*Todo, error) {
+	if err := ValidatedAuthor(params.MovedBy); err != nil {
+		return nil, err
+	}
+	set := 0
+	for _, b := range []bool{params.Before != "", params.After != "", params.Top, params.Bottom} {
+		if b {
+			set++
+		}
+	}
+	if set != 1 {
+		return nil, errors.New("exactly one of before, after, top, or bottom must be set")
+	} else if params.Before == id || params.After == id {
+		return nil, errors.New("cannot move a todo relative to itself")
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	todos := p.Doc.Path("todos").Map()
+	if _, err := getTodoInner(todos, id); err != nil {
+		return nil, err
+	}
+	others, err := rankedTodosInner(todos, id)
+	if err != nil {
+		return nil, err
+	}
+
+	position := func() (int, error) {
+		switch {
+		case params.Top:
+			return 0, nil
+		case params.Bottom:
+			return len(others), nil
+		}
+		target := params.Before
+		if target == "" {
+			target = params.After
+		}
+		i := slices.IndexFunc(others, func(t Todo) bool { return t.Id == target })
+		if i < 0 {
+			return 0, errors.Errorf("todo with id '%s' does not exist", target)
+		} else if params.After != "" {
+			i++
+		}
+		return i, nil
+	}
+	i, err := position()
+	if err != nil {
+		return nil, err
+	}
+
+	updatedAt := time.Now().UTC().Truncate(time.Second)
+	rank, ok := rankAtPosition(others, i)
+	if !ok {
+		// there is no room between the neighbours, so spread all the ranks out again and retry
+		if err := rebalanceRanksInner(todos, others, updatedAt, params.MovedBy); err != nil {
+			return nil, err
+		}
+		if others, err = rankedTodosInner(todos, id); err != nil {
+			return nil, err
+		}
+		rank, _ = rankAtPosition(others, i)
+	}
+
+	todoValue, _ := todos.Get(id)
+	annotationsValue, _ := todoValue.Map().Get("annotations")
+	if annotationsValue.Kind() != automerge.KindMap {
+		_ = todoValue.Map().Set("annotations", automerge.NewMap())
+		annotationsValue, _ = todoValue.Map().Get("annotations")
+	}
+	if err := annotationsValue.Map().Set(AurelianRankAnnotation, FormatRank(rank)); err != nil {
+		return nil, errors.Wrap(err, "failed to set rank")
+	}
+	if err := todoValue.Map().Set("updated_at", updatedAt); err != nil {
+		return nil, errors.Wrap(err, "failed to set updated_at")
+	} else if err := todoValue.Map().Set("updated_by", params.MovedBy); err != nil {
+		return nil, errors.Wrap(err, "failed to set updated_by")
+	}
+
+	if _, err := p.commit(params.MovedBy + " moved todo " + id); err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
+	return getTodoInner(todos, id)
+}
+
+// RebalanceRanks rewrites the ranks of all todos as evenly spaced integers while preserving the current order. This is
+// needed when repeated moves into the same gap exhaust the precision of the ranks. It returns the number of todos.
+func (p *inMemoryWorkspaceProvider) RebalanceRanks(ctx context.Context, params RebalanceRanksParams) (int, error) {
+	if err := ValidatedAuthor(params.RebalancedBy); err != nil {
+		return 0, err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	todos := p.Doc.Path("todos").Map()
+	ranked, err := rankedTodosInner(todos, "")
+	if err != nil {
+		return 0, err
+	}
+	if err := rebalanceRanksInner(todos, ranked, time.Now().UTC().Truncate(time.Second), params.RebalancedBy); err != nil {
+		return 0, err
+	}
+	if _, err := p.commit(params.RebalancedBy+" rebalanced ranks", automerge.CommitOptions{AllowEmpty: true}); err != nil {
+		return 0, errors.Wrap(err, "failed to commit")
+	}
+	return len(ranked), nil
+}
+
+// rankedTodosInner returns all todos except the excluded one, sorted by rank.
+func rankedTodosInner(todos *automerge.Map, excludeId string) ([]Todo, error) {
+	ids, _ := todos.Keys()
+	output := make([]Todo, 0, len(ids))
+	for _, todoId := range ids {
+		if todoId == excludeId {
+			continue
+		}
+		td, err := getTodoInner(todos, todoId)
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to get todo")
+		}
+		output = append(output, *td)
+	}
+	SortTodosByRank(output)
+	return output, nil
+}
+
+// rankAtPosition returns a rank that places a todo at index i of the ranked todos.
+func rankAtPosition(ranked []Todo, i int) (float64, bool) {
+	var above, below *float64
+	if i > 0 {
+		r := TodoRank(&ranked[i-1])
+		above = &r
+	}
+	if i < len(ranked) {
+		r := TodoRank(&ranked[i])
+		below = &r
+	}
+	return RankBetween(above, below)
+}
+
+// rebalanceRanksInner sets the ranks of the sorted todos to len(ranked) down to 1. Ranks that already have the right
+// value are not rewritten, the others are stamped as updated by the given author.
+func rebalanceRanksInner(todos *automerge.Map, ranked []Todo, updatedAt time.Time, updatedBy string) error {
+	for i, t := range ranked {
+		rank := FormatRank(float64(len(ranked) - i))
+		if t.Annotations[AurelianRankAnnotation] == rank {
+			continue
+		}
+		todoValue, _ := todos.Get(t.Id)
+		annotationsValue, _ := todoValue.Map().Get("annotations")
+		if annotationsValue.Kind() != automerge.KindMap {
+			_ = todoValue.Map().Set("annotations", automerge.NewMap())
+			annotationsValue, _ = todoValue.Map().Get("annotations")
+		}
+		if err := annotationsValue.Map().Set(AurelianRankAnnotation, rank); err != nil {
+			return errors.Wrap(err, "failed to set rank")
+		}
+		if err := todoValue.Map().Set("updated_at", updatedAt); err != nil {
+			return errors.Wrap(err, "failed to set updated_at")
+		} else if err := todoValue.Map().Set("updated_by", updatedBy); err != nil {
+			return errors.Wrap(err, "failed to set updated_by")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,113 @@
+package au
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRankBetween(t *testing.T) {
+	ref := func(f float64) *float64 { return &f }
+	for _, tc := range []struct {
+		Above, Below *float64
+		Expected     float64
+		Ok           bool
+	}{
+		{nil, nil, 0, true},
+		{nil, ref(2.5), 3, true},
+		{ref(-0.5), nil, -1, true},
+		{ref(2), ref(1), 1.5, true},
+		{ref(1), ref(1), 1, false},
+		{ref(1), ref(2), 1.5, false},
+	} {
+		r, ok := RankBetween(tc.Above, tc.Below)
+		assert.Equal(t, tc.Expected, r)
+		assert.Equal(t, tc.Ok, ok)
+	}
+}
+
+func TestCreateTodo_invalid_rank(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, true)
+	_, err := wsp.CreateTodo(context.Background(), CreateTodoParams{
+		Title: "Do the thing", Annotations: map[string]string{AurelianRankAnnotation: "high"}, CreatedBy: "Example <email@me.com>",
+	})
+	assert.EqualError(t, err, "invalid annotation value for 'https://aurelian.one/annotations/rank': rank 'high' must be a finite decimal number")
+	_, err = wsp.CreateTodo(context.Background(), CreateTodoParams{
+		Title: "Do the thing", Annotations: map[string]string{AurelianRankAnnotation: "1.25"}, CreatedBy: "Example <email@me.com>",
+	})
+	assert.NoError(t, err)
+}
+
+func TestMoveTodo(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, true)
+	author := "Example <email@me.com>"
+
+	ids := make([]string, 0)
+	for _, title := range []string{"First", "Second", "Third"} {
+		td, err := wsp.CreateTodo(context.Background(), CreateTodoParams{Title: title, CreatedBy: author})
+		assert.NoError(t, err)
+		ids = append(ids, td.Id)
+	}
+	order := func() []string {
+		todos, _ := wsp.ListTodos(context.Background())
+		SortTodosByRank(todos)
+		output := make([]string, len(todos))
+		for i, t := range todos {
+			output[i] = t.Title
+		}
+		return output
+	}
+	assert.Equal(t, []string{"First", "Second", "Third"}, order())
+
+	_, err := wsp.MoveTodo(context.Background(), ids[2], MoveTodoParams{Top: true, Bottom: true, MovedBy: author})
+	assert.EqualError(t, err, "exactly one of before, after, top, or bottom must be set")
+
+	// all ranks are equal so this requires a rebalance
+	_, err = wsp.MoveTodo(context.Background(), ids[2], MoveTodoParams{Before: ids[1], MovedBy: author})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"First", "Third", "Second"}, order())
+
+	_, err = wsp.MoveTodo(context.Background(), ids[1], MoveTodoParams{Top: true, MovedBy: author})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Second", "First", "Third"}, order())
+
+	_, err = wsp.MoveTodo(context.Background(), ids[1], MoveTodoParams{After: ids[0], MovedBy: author})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"First", "Second", "Third"}, order())
+
+	_, err = wsp.MoveTodo(context.Background(), ids[0], MoveTodoParams{Bottom: true, MovedBy: author})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"Second", "Third", "First"}, order())
+
+	// repeatedly moving into the same gap eventually exhausts the precision and rebalances
+	for i := 0; i < 100; i++ {
+		_, err = wsp.MoveTodo(context.Background(), ids[i%2], MoveTodoParams{Before: ids[2], MovedBy: author})
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "Third", order()[2])
+
+	count, err := wsp.RebalanceRanks(context.Background(), RebalanceRanksParams{RebalancedBy: author})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, count)
+	todos, _ := wsp.ListTodos(context.Background())
+	SortTodosByRank(todos)
+	assert.Equal(t, []float64{3, 2, 1}, []float64{TodoRank(&todos[0]), TodoRank(&todos[1]), TodoRank(&todos[2])})
+
+	// only the todos whose rank is rewritten are stamped as updated by the rebalance
+	other := "Other <other@me.com>"
+	_, err = wsp.MoveTodo(context.Background(), todos[2].Id, MoveTodoParams{Before: todos[1].Id, MovedBy: author})
+	assert.NoError(t, err)
+	_, err = wsp.RebalanceRanks(context.Background(), RebalanceRanksParams{RebalancedBy: other})
+	assert.NoError(t, err)
+	updatedBy := make([]string, 3)
+	for i, td := range todos {
+		edited, _ := wsp.GetTodo(context.Background(), td.Id)
+		updatedBy[i] = *edited.UpdatedBy
+	}
+	assert.Equal(t, []string{author, other, other}, updatedBy)
+}
@@ -0,0 +1,201 @@
+package au
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/automerge/automerge-go"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+)
+
+// Recurrence is a subset of an iCalendar RRULE describing how often a todo repeats.
+type Recurrence struct {
+	// Frequency is one of DAILY, WEEKLY, MONTHLY, or YEARLY.
+	Frequency string
+	Interval  int
+}
+
+// ParseRecurrence parses an RRULE-style value such as FREQ=WEEKLY;INTERVAL=2. The shorthand daily, weekly, monthly, and
+// yearly values are also accepted.
+func ParseRecurrence(value string) (Recurrence, error) {
+	output := Recurrence{Interval: 1}
+	value = strings.TrimPrefix(strings.ToUpper(strings.TrimSpace(value)), "RRULE:")
+	switch value {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+		output.Frequency = value
+		return output, nil
+	}
+	for _, part := range strings.Split(value, ";") {
+		k, v, _ := strings.Cut(part, "=")
+		switch k {
+		case "FREQ":
+			switch v {
+			case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+				output.Frequency = v
+			default:
+				return Recurrence{}, errors.Errorf("recurrence frequency '%s' must be DAILY, WEEKLY, MONTHLY, or YEARLY", v)
+			}
+		case "INTERVAL":
+			i, err := strconv.Atoi(v)
+			if err != nil || i < 1 {
+				return Recurrence{}, errors.Errorf("recurrence interval '%s' must be a positive integer", v)
+			}
+			output.Interval = i
+		default:
+			return Recurrence{}, errors.Errorf("recurrence rule part '%s' is not supported", k)
+		}
+	}
+	if output.Frequency == "" {
+		return Recurrence{}, errors.Errorf("recurrence '%s' must have a FREQ", value)
+	}
+	return output, nil
+}
+
+func (r Recurrence) String() string {
+	if r.Interval > 1 {
+		return "FREQ=" + r.Frequency + ";INTERVAL=" + strconv.Itoa(r.Interval)
+	}
+	return "FREQ=" + r.Frequency
+}
+
+// Next returns the time one interval after t.
+func (r Recurrence) Next(t time.Time) time.Time {
+	switch r.Frequency {
+	case "DAILY":
+		return t.AddDate(0, 0, r.Interval)
+	case "WEEKLY":
+		return t.AddDate(0, 0, 7*r.Interval)
+	case "MONTHLY":
+		return t.AddDate(0, r.Interval, 0)
+	default:
+		return t.AddDate(r.Interval, 0, 0)
+	}
+}
+
+// TodoRecurrence returns the recurrence of the todo, or nil if it does not repeat.
+func TodoRecurrence(todo *Todo) *Recurrence {
+	if r, err := ParseRecurrence(todo.Annotations[AurelianRecurrenceAnnotation]); err == nil {
+		return &r
+	}
+	return nil
+}
+
+// isOccurrenceAnnotation returns whether the annotation is user data which is copied to the next occurrence of a
+// recurring todo. Bookkeeping annotations such as the rank, origin, assignees, and dependencies belong to the closed
+// todo only.
+func isOccurrenceAnnotation(key string) bool {
+	switch key {
+	case AurelianRecurrenceAnnotation, AurelianParentAnnotation:
+		return true
+	}
+	if _, ok := labelSet.value(key); ok {
+		return true
+	}
+	u, err := url.Parse(key)
+	return err == nil && u.Hostname() != ReservedAnnotationHostname
+}
+
+// NextOccurrenceId returns the id of the todo generated when the recurring todo is closed. The id is derived from the id
+// of the previous todo so that peers closing the same todo concurrently create the same todo rather than duplicates.
+func NextOccurrenceId(previousId string) (string, error) {
+	previous, err := ulid.ParseStrict(previousId)
+	if err != nil {
+		return "", errors.Wrap(err, "invalid todo id")
+	}
+	entropy := sha256.Sum256([]byte(previousId + "/next"))
+	next, err := ulid.New(previous.Time()+1, bytes.NewReader(entropy[:]))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to generate id")
+	}
+	return next.String(), nil
+}
+
+// spawnNextOccurrenceInner creates the next occurrence of a todo which has just been closed if it is recurring, and
+// records its id on the closed todo without committing. It returns the id of the occurrence if one was created so that
+// the commit message can name it. A todo which has already spawned its next occurrence does not spawn another one when
+// it is reopened and closed again, even if that occurrence has since been deleted.
+func spawnNextOccurrenceInner(doc *automerge.Doc, todos *automerge.Map, id string, updatedBy string, updatedAt time.Time) (string, error) {
+	closed, err := getTodoInner(todos, id)
+	if err != nil {
+		return "", err
+	}
+	r := TodoRecurrence(closed)
+	if r == nil || closed.Annotations[AurelianNextAnnotation] != "" {
+		return "", nil
+	}
+	nextId, err := NextOccurrenceId(id)
+	if err != nil {
+		return "", err
+	}
+	existing, _ := todos.Get(nextId)
+	nextId, err = createNextOccurrenceInner(doc, todos, closed, *r, updatedBy)
+	if err != nil {
+		return "", errors.Wrap(err, "failed to create next occurrence")
+	}
+	if err := applyEditTodoInner(todos, id, EditTodoParams{Annotations: map[string]string{AurelianNextAnnotation: nextId}, UpdatedBy: updatedBy}, updatedAt); err != nil {
+		return "", err
+	} else if existing.Kind() != automerge.KindVoid {
+		return "", nil
+	}
+	return nextId, nil
+}
+
+// createNextOccurrenceInner creates the next occurrence of a recurring todo which has just been closed without
+// committing it, and returns its id. Nothing is created if the occurrence already exists. The due and start times are
+// moved forward by one interval and the todo starts in the default status.
+func createNextOccurrenceInner(doc *automerge.Doc, todos *automerge.Map, previous *Todo, recurrence Recurrence, createdBy string) (string, error) {
+	todoId, err := NextOccurrenceId(previous.Id)
+	if err != nil {
+		return "", err
+	} else if v, _ := todos.Get(todoId); v.Kind() != automerge.KindVoid {
+		return todoId, nil
+	}
+
+	annotations := make(map[string]string, len(previous.Annotations)+1)
+	for k, v := range previous.Annotations {
+		switch {
+		case k == AurelianDueAnnotation || k == AurelianStartAnnotation:
+			if t, err := ParseTodoTime(v); err == nil {
+				annotations[k] = FormatTodoTime(recurrence.Next(t))
+			}
+		case isOccurrenceAnnotation(k):
+			annotations[k] = v
+		}
+	}
+	annotations[AurelianPreviousAnnotation] = previous.Id
+	status, workflowStatus, err := resolveStatusInner(doc, nil, defaultStatusInner(doc))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to resolve default status")
+	} else if workflowStatus != "" {
+		annotations[AurelianWorkflowStatusAnnotation] = workflowStatus
+	}
+
+	newTodo := automerge.NewMap()
+	if err := todos.Set(todoId, newTodo); err != nil {
+		return "", errors.Wrap(err, "failed to set todo entry")
+	}
+	if err := newTodo.Set("status", status); err != nil {
+		return "", errors.Wrap(err, "failed to set status")
+	} else if err := newTodo.Set("created_at", time.Now().UTC().Truncate(time.Second)); err != nil {
+		return "", errors.Wrap(err, "failed to set created_at")
+	} else if err := newTodo.Set("created_by", createdBy); err != nil {
+		return "", errors.Wrap(err, "failed to set created_by")
+	} else if err := newTodo.Set("title", automerge.NewText(previous.Title)); err != nil {
+		return "", errors.Wrap(err, "failed to set title")
+	} else if err := newTodo.Set("description", automerge.NewText(previous.Description)); err != nil {
+		return "", errors.Wrap(err, "failed to set description")
+	} else if _, err := initTimeTrackingInner(newTodo); err != nil {
+		return "", err
+	}
+	newAnnotations := automerge.NewMap()
+	_ = newTodo.Set("annotations", newAnnotations)
+	for k, v := range annotations {
+		_ = newAnnotations.Set(k, v)
+	}
+	return todoId, nil
+}
@@ -0,0 +1,122 @@
+package au
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aurelian-one/au/internal"
+)
+
+func TestParseRecurrence(t *testing.T) {
+	for _, tc := range []struct {
+		input    string
+		expected string
+		err      string
+	}{
+		{input: "weekly", expected: "FREQ=WEEKLY"},
+		{input: "RRULE:FREQ=MONTHLY;INTERVAL=2", expected: "FREQ=MONTHLY;INTERVAL=2"},
+		{input: "FREQ=DAILY;INTERVAL=1", expected: "FREQ=DAILY"},
+		{input: "FREQ=HOURLY", err: "recurrence frequency 'HOURLY' must be DAILY, WEEKLY, MONTHLY, or YEARLY"},
+		{input: "FREQ=DAILY;INTERVAL=0", err: "recurrence interval '0' must be a positive integer"},
+		{input: "FREQ=DAILY;BYDAY=MO", err: "recurrence rule part 'BYDAY' is not supported"},
+		{input: "INTERVAL=2", err: "recurrence 'INTERVAL=2' must have a FREQ"},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			r, err := ParseRecurrence(tc.input)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, r.String())
+			}
+		})
+	}
+
+	r, _ := ParseRecurrence("FREQ=MONTHLY")
+	assert.Equal(t, time.Date(2024, 2, 15, 9, 0, 0, 0, time.UTC), r.Next(time.Date(2024, 1, 15, 9, 0, 0, 0, time.UTC)))
+}
+
+func TestRecurringTodo(t *testing.T) {
+	wsA := newTestWorkspace(t)
+	doc := wsA.Doc
+	alice, bob := "Alice <alice@me.com>", "Bob <bob@me.com>"
+	ctx := context.Background()
+
+	_, err := wsA.CreateTodo(ctx, CreateTodoParams{Title: "Bad", CreatedBy: alice, Annotations: map[string]string{AurelianRecurrenceAnnotation: "sometimes"}})
+	assert.EqualError(t, err, "invalid annotation value for '"+AurelianRecurrenceAnnotation+"': recurrence rule part 'SOMETIMES' is not supported")
+
+	td, err := wsA.CreateTodo(ctx, CreateTodoParams{Title: "Water plants", Description: "All of them", CreatedBy: alice, Annotations: map[string]string{
+		AurelianRecurrenceAnnotation:   "FREQ=WEEKLY",
+		AurelianDueAnnotation:          "2024-01-01T09:00:00Z",
+		LabelAnnotationKey("home"):     "true",
+		AurelianStatusReasonAnnotation: "waiting for rain",
+		AssigneeAnnotationKey(bob):     "true",
+		AurelianRankAnnotation:         "5",
+		"https://example.com/plants":   "ferns",
+	}})
+	assert.NoError(t, err)
+	nextId, err := NextOccurrenceId(td.Id)
+	assert.NoError(t, err)
+
+	// two peers close the same todo concurrently
+	forked, err := doc.Fork()
+	assert.NoError(t, err)
+	wsB := NewInMemoryWorkspaceProvider(forked)
+	_, err = wsA.EditTodo(ctx, td.Id, EditTodoParams{Status: internal.Ref("closed"), UpdatedBy: alice})
+	assert.NoError(t, err)
+	_, err = wsB.EditTodo(ctx, td.Id, EditTodoParams{Status: internal.Ref("closed"), UpdatedBy: bob})
+	assert.NoError(t, err)
+	_, err = doc.Merge(forked)
+	assert.NoError(t, err)
+
+	todos, err := wsA.ListTodos(ctx)
+	assert.NoError(t, err)
+	assert.Len(t, todos, 2)
+	next, err := wsA.GetTodo(ctx, nextId)
+	assert.NoError(t, err)
+	assert.Equal(t, "open", next.Status)
+	assert.Equal(t, "Water plants", next.Title)
+	assert.Equal(t, "All of them", next.Description)
+	assert.Equal(t, map[string]string{
+		AurelianRecurrenceAnnotation: "FREQ=WEEKLY",
+		AurelianDueAnnotation:        "2024-01-08T09:00:00Z",
+		LabelAnnotationKey("home"):   "true",
+		AurelianPreviousAnnotation:   td.Id,
+		"https://example.com/plants": "ferns",
+	}, next.Annotations)
+	closed, err := wsA.GetTodo(ctx, td.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, nextId, closed.Annotations[AurelianNextAnnotation])
+
+	// reopening and closing again does not recreate the occurrence, even once it has been deleted
+	assert.NoError(t, wsA.DeleteTodo(ctx, nextId, DeleteTodoParams{DeletedBy: alice}))
+	_, err = wsA.EditTodo(ctx, td.Id, EditTodoParams{Status: internal.Ref("open"), UpdatedBy: alice})
+	assert.NoError(t, err)
+	_, err = wsA.EditTodo(ctx, td.Id, EditTodoParams{Status: internal.Ref("closed"), UpdatedBy: alice})
+	assert.NoError(t, err)
+	todos, _ = wsA.ListTodos(ctx)
+	assert.Len(t, todos, 1)
+
+	// closing a recurring todo creates the next occurrence in the same change
+	third, err := wsA.CreateTodo(ctx, CreateTodoParams{Title: "Feed cat", CreatedBy: alice, Annotations: map[string]string{AurelianRecurrenceAnnotation: "daily"}})
+	assert.NoError(t, err)
+	before, _ := doc.Changes()
+	_, err = wsA.EditTodo(ctx, third.Id, EditTodoParams{Status: internal.Ref("closed"), UpdatedBy: alice})
+	assert.NoError(t, err)
+	after, _ := doc.Changes()
+	fourthId, _ := NextOccurrenceId(third.Id)
+	if assert.Len(t, after, len(before)+1) {
+		assert.Equal(t, alice+" edited todo "+third.Id+" and created todo "+fourthId, after[len(after)-1].Message())
+	}
+	_, err = wsA.GetTodo(ctx, fourthId)
+	assert.NoError(t, err)
+
+	// the change that created the occurrence is in its history
+	history, err := wsA.GetTodoHistory(ctx, fourthId)
+	assert.NoError(t, err)
+	if assert.Len(t, history, 1) {
+		assert.Equal(t, after[len(after)-1].Hash().String(), history[0].Hash)
+	}
+}
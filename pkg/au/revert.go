@@ -0,0 +1,338 @@
+package au
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+const (
+	revertMessageInfix = " reverted change "
+	undoMessageInfix   = " undid change "
+)
+
+type RevertChangeParams struct {
+	RevertedBy string
+	// Undo marks the revert as an undo so that it is skipped by the undo stack of LatestChangeByAuthor.
+	Undo bool
+}
+
+// RevertChange applies a new change that restores the fields modified by the given change to their previous values.
+// Fields which have been modified again since the change are left alone so that the revert never clobbers newer work.
+// The returned HistoryEntry describes the new change.
+func (p *inMemoryWorkspaceProvider) RevertChange(ctx context.Context, hash string, params RevertChangeParams) (*HistoryEntry, error) {
+	if err := ValidatedAuthor(params.RevertedBy); err != nil {
+		return nil, err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	changeHash, err := ResolveChangeHash(p.Doc, hash)
+	if err != nil {
+		return nil, err
+	}
+	change, err := p.Doc.Change(changeHash)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get change")
+	} else if len(change.Dependencies()) == 0 {
+		return nil, errors.New("cannot revert the initial change of the workspace")
+	}
+	before, after, err := forkAroundChange(p.Doc, change)
+	if err != nil {
+		return nil, err
+	}
+
+	r := &reverter{touched: make(map[string][]string)}
+	if err := r.revertMap(p.Doc.RootMap(), before.Root(), after.Root(), p.Doc.Root(), []string{}); err != nil {
+		return nil, err
+	} else if len(r.changes) == 0 {
+		return nil, errors.Errorf("nothing to revert: the fields modified by change '%s' have been modified since", changeHash)
+	}
+
+	slices.SortFunc(r.changes, func(a, b FieldChange) int {
+		return strings.Compare(a.Field, b.Field)
+	})
+
+	// stamp the todos and comments we modified with the current author
+	updatedAt := time.Now().UTC().Truncate(time.Second)
+	for _, path := range r.touched {
+		anyPath := make([]any, len(path))
+		for i, s := range path {
+			anyPath[i] = s
+		}
+		if v, _ := p.Doc.Path(anyPath...).Get(); v.Kind() == automerge.KindMap {
+			if err := v.Map().Set("updated_at", updatedAt); err != nil {
+				return nil, errors.Wrap(err, "failed to set updated_at")
+			} else if err := v.Map().Set("updated_by", params.RevertedBy); err != nil {
+				return nil, errors.Wrap(err, "failed to set updated_by")
+			}
+		}
+	}
+
+	message := params.RevertedBy + revertMessageInfix + changeHash.String()
+	if params.Undo {
+		message = params.RevertedBy + undoMessageInfix + changeHash.String()
+	}
+	newHash, err := p.commit(message)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
+	return &HistoryEntry{
+		Hash:    newHash.String(),
+		At:      updatedAt,
+		Author:  params.RevertedBy,
+		Message: message,
+		Changes: r.changes,
+	}, nil
+}
+
+// LatestChangeByAuthor returns the hash of the most recent change made by the author which has not already been
+// undone and is not itself an undo. This is used to provide an undo stack. Explicit reverts are regular changes, so
+// undoing right after a revert undoes the revert.
+func (p *inMemoryWorkspaceProvider) LatestChangeByAuthor(ctx context.Context, author string) (string, error) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	changes, err := p.Doc.Changes()
+	if err != nil {
+		return "", errors.Wrap(err, "failed to get changes")
+	}
+	undone := make(map[string]bool)
+	for i := len(changes) - 1; i >= 0; i-- {
+		message, hash := changes[i].Message(), changes[i].Hash().String()
+		if _, target, ok := strings.Cut(message, undoMessageInfix); ok {
+			undone[target] = true
+		} else if !undone[hash] && commitAuthor(message) == author {
+			return hash, nil
+		}
+	}
+	return "", errors.Errorf("no changes by '%s' to undo", author)
+}
+
+type reverter struct {
+	changes []FieldChange
+	// touched is the set of todo or comment paths that were modified keyed by their joined path
+	touched map[string][]string
+}
+
+func (r *reverter) record(path []string, before, after *string) {
+	r.changes = append(r.changes, FieldChange{Field: strings.Join(path, "/"), Before: before, After: after})
+
+	// todos/<id> and todos/<id>/comments/<id> are the objects that carry updated_at and updated_by
+	if len(path) > 4 && path[0] == "todos" && path[2] == "comments" {
+		r.touched[leafKey(path[:4])] = path[:4]
+	} else if len(path) > 2 && path[0] == "todos" {
+		r.touched[leafKey(path[:2])] = path[:2]
+	}
+}
+
+func valueSummary(v *automerge.Value) *string {
+	var s string
+	switch v.Kind() {
+	case automerge.KindMap:
+		s = "<map>"
+	case automerge.KindList:
+		s = "<list>"
+	default:
+		s = leafString(v)
+	}
+	return &s
+}
+
+// revertMap reverts the changes between before and after on the current map. The key set is the union of all three.
+func (r *reverter) revertMap(dst *automerge.Map, before, after, current *automerge.Value, path []string) error {
+	keys := make(map[string]bool)
+	for _, v := range []*automerge.Value{before, after, current} {
+		if kindOf(v) == automerge.KindMap {
+			ks, _ := v.Map().Keys()
+			for _, k := range ks {
+				keys[k] = true
+			}
+		}
+	}
+	for k := range keys {
+		if k == "updated_at" || k == "updated_by" {
+			continue
+		}
+		b, a, c := mapChild(before, k), mapChild(after, k), mapChild(current, k)
+		if err := r.revertKey(dst, k, b, a, c, append(path[:len(path):len(path)], k)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// mapChild returns the value at the key if the value is a map, or nil.
+func mapChild(v *automerge.Value, key string) *automerge.Value {
+	if kindOf(v) == automerge.KindMap {
+		if x, err := v.Map().Get(key); err == nil {
+			return x
+		}
+	}
+	return nil
+}
+
+func kindOf(v *automerge.Value) automerge.Kind {
+	if v == nil {
+		return automerge.KindVoid
+	}
+	return v.Kind()
+}
+
+func (r *reverter) revertKey(dst *automerge.Map, key string, before, after, current *automerge.Value, path []string) error {
+	bk, ak, ck := kindOf(before), kindOf(after), kindOf(current)
+	switch {
+	case bk == automerge.KindVoid && ak == automerge.KindVoid:
+		return nil
+	case bk == automerge.KindVoid:
+		// the change added this key, remove it if it still exists
+		if ck == automerge.KindVoid {
+			return nil
+		}
+		if err := dst.Delete(key); err != nil {
+			return errors.Wrapf(err, "failed to delete %s", strings.Join(path, "/"))
+		}
+		r.record(path, valueSummary(current), nil)
+	case ak == automerge.KindVoid:
+		// the change removed this key, restore it if it has not been re-added
+		if ck != automerge.KindVoid {
+			return nil
+		}
+		if err := copyValueInto(dst, key, before); err != nil {
+			return errors.Wrapf(err, "failed to restore %s", strings.Join(path, "/"))
+		}
+		r.record(path, nil, valueSummary(before))
+	case bk == automerge.KindMap && ak == automerge.KindMap:
+		if ck != automerge.KindMap {
+			return nil
+		}
+		return r.revertMap(current.Map(), before, after, current, path)
+	case bk == automerge.KindList || ak == automerge.KindList:
+		// list items have no stable keys, so lists are restored as a whole if they have not been modified since
+		if sameValue(before, after) || !sameValue(current, after) {
+			return nil
+		}
+		previous := valueSummary(current)
+		if err := copyValueInto(dst, key, before); err != nil {
+			return errors.Wrapf(err, "failed to restore %s", strings.Join(path, "/"))
+		}
+		r.record(path, previous, valueSummary(before))
+	case bk == automerge.KindCounter && ak == automerge.KindCounter:
+		// counters are reverted by the difference so that increments made since the change, or concurrently with the
+		// revert, are kept rather than replaced along with the counter
+		if ck != automerge.KindCounter {
+			return nil
+		}
+		b, _ := before.Counter().Get()
+		a, _ := after.Counter().Get()
+		if b == a {
+			return nil
+		}
+		previous := valueSummary(current)
+		if err := current.Counter().Inc(b - a); err != nil {
+			return errors.Wrapf(err, "failed to restore %s", strings.Join(path, "/"))
+		}
+		restored, _ := dst.Get(key)
+		r.record(path, previous, valueSummary(restored))
+	default:
+		if bk == ak && bk != automerge.KindMap && bk != automerge.KindList && leafString(before) == leafString(after) {
+			return nil
+		} else if ck != ak || ck == automerge.KindMap || ck == automerge.KindList || leafString(current) != leafString(after) {
+			// modified since the change
+			return nil
+		}
+		previous := valueSummary(current)
+		if bk == automerge.KindText && ck == automerge.KindText {
+			s, _ := before.Text().Get()
+			if _, err := spliceTextNode(current.Text(), s); err != nil {
+				return err
+			}
+		} else if err := copyValueInto(dst, key, before); err != nil {
+			return errors.Wrapf(err, "failed to restore %s", strings.Join(path, "/"))
+		}
+		r.record(path, previous, valueSummary(before))
+	}
+	return nil
+}
+
+// sameValue compares two values including the contents of maps and lists.
+func sameValue(a, b *automerge.Value) bool {
+	if kindOf(a) != kindOf(b) {
+		return false
+	} else if kindOf(a) == automerge.KindVoid {
+		return true
+	}
+	aLeaves, bLeaves := make(map[string]docLeaf), make(map[string]docLeaf)
+	flattenValue(a, []string{}, aLeaves)
+	flattenValue(b, []string{}, bLeaves)
+	return len(diffLeaves(aLeaves, bLeaves)) == 0
+}
+
+// copyValueInto deep copies the value from another document into the map, preserving text, counter, and list types.
+func copyValueInto(dst *automerge.Map, key string, v *automerge.Value) error {
+	if err := dst.Set(key, shallowCopy(v)); err != nil {
+		return err
+	}
+	x, err := dst.Get(key)
+	if err != nil {
+		return err
+	}
+	return copyContents(x, v)
+}
+
+// copyValueAppend deep copies the value from another document onto the end of the list.
+func copyValueAppend(dst *automerge.List, v *automerge.Value) error {
+	if err := dst.Append(shallowCopy(v)); err != nil {
+		return err
+	}
+	x, err := dst.Get(dst.Len() - 1)
+	if err != nil {
+		return err
+	}
+	return copyContents(x, v)
+}
+
+// shallowCopy returns a copy of scalar values, or an empty object of the same type for maps and lists.
+func shallowCopy(v *automerge.Value) any {
+	switch v.Kind() {
+	case automerge.KindMap:
+		return automerge.NewMap()
+	case automerge.KindList:
+		return automerge.NewList()
+	case automerge.KindText:
+		s, _ := v.Text().Get()
+		return automerge.NewText(s)
+	case automerge.KindCounter:
+		c, _ := v.Counter().Get()
+		return automerge.NewCounter(c)
+	default:
+		return v.Interface()
+	}
+}
+
+// copyContents copies the entries of the source map or list into the empty destination created by shallowCopy.
+func copyContents(dst, v *automerge.Value) error {
+	switch v.Kind() {
+	case automerge.KindMap:
+		keys, _ := v.Map().Keys()
+		for _, k := range keys {
+			x, _ := v.Map().Get(k)
+			if err := copyValueInto(dst.Map(), k, x); err != nil {
+				return err
+			}
+		}
+	case automerge.KindList:
+		for i := 0; i < v.List().Len(); i++ {
+			x, _ := v.List().Get(i)
+			if err := copyValueAppend(dst.List(), x); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package au
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/automerge/automerge-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aurelian-one/au/internal"
+)
+
+func TestRevertChange_edit(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, true)
+	_, err := wsp.SetAnnotationSchema(context.Background(), SetAnnotationSchemaParams{Key: "about:blank", Type: AnnotationTypeString, UpdatedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+	td, err := wsp.CreateTodo(context.Background(), CreateTodoParams{
+		Title:       "Do the thing",
+		Description: "Much longer text about doing the thing",
+		Annotations: map[string]string{"about:blank#a": "1"},
+		CreatedBy:   "Example <email@me.com>",
+	})
+	assert.NoError(t, err)
+	_, err = wsp.EditTodo(context.Background(), td.Id, EditTodoParams{
+		Title:       internal.Ref("Do the other thing"),
+		Description: internal.Ref("oops"),
+		Annotations: map[string]string{"about:blank#a": "", "about:blank#b": "2"},
+		UpdatedBy:   "Example <email@me.com>",
+	})
+	assert.NoError(t, err)
+	editHash := wsp.(DocProvider).GetDoc().Heads()[0].String()
+
+	// a later edit to the title means that the title will not be reverted
+	_, err = wsp.EditTodo(context.Background(), td.Id, EditTodoParams{Title: internal.Ref("Newer title"), UpdatedBy: "Other <other@me.com>"})
+	assert.NoError(t, err)
+
+	entry, err := wsp.RevertChange(context.Background(), editHash[:12], RevertChangeParams{RevertedBy: "Other <other@me.com>"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Other <other@me.com> reverted change "+editHash, entry.Message)
+	assert.Equal(t, []FieldChange{
+		{Field: "todos/" + td.Id + "/annotations/about:blank#a", After: internal.Ref("1")},
+		{Field: "todos/" + td.Id + "/annotations/about:blank#b", Before: internal.Ref("2")},
+		{Field: "todos/" + td.Id + "/description", Before: internal.Ref("oops"), After: internal.Ref("Much longer text about doing the thing")},
+	}, entry.Changes)
+
+	td2, err := wsp.GetTodo(context.Background(), td.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Newer title", td2.Title)
+	assert.Equal(t, "Much longer text about doing the thing", td2.Description)
+	assert.Equal(t, map[string]string{"about:blank#a": "1"}, td2.Annotations)
+	assert.Equal(t, "Other <other@me.com>", *td2.UpdatedBy)
+
+	// the revert does not name the todo, but still shows up in its history
+	history, err := wsp.GetTodoHistory(context.Background(), td.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, entry.Message, history[len(history)-1].Message)
+
+	_, err = wsp.RevertChange(context.Background(), editHash, RevertChangeParams{RevertedBy: "Other <other@me.com>"})
+	assert.ErrorContains(t, err, "nothing to revert")
+}
+
+func TestRevertChange_delete_and_undo(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, true)
+	td, err := wsp.CreateTodo(context.Background(), CreateTodoParams{Title: "Do the thing", CreatedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+	_, err = wsp.CreateComment(context.Background(), td.Id, CreateCommentParams{
+		MediaType: DefaultCommentMediaType, Content: []byte("hello"), CreatedBy: "Example <email@me.com>",
+	})
+	assert.NoError(t, err)
+	assert.NoError(t, wsp.DeleteTodo(context.Background(), td.Id, DeleteTodoParams{DeletedBy: "Example <email@me.com>"}))
+
+	hash, err := wsp.LatestChangeByAuthor(context.Background(), "Example <email@me.com>")
+	assert.NoError(t, err)
+	_, err = wsp.RevertChange(context.Background(), hash, RevertChangeParams{RevertedBy: "Example <email@me.com>", Undo: true})
+	assert.NoError(t, err)
+
+	td2, err := wsp.GetTodo(context.Background(), td.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Do the thing", td2.Title)
+	assert.Equal(t, 1, td2.CommentCount)
+
+	// undo again reverts the comment creation, since the delete has already been reverted
+	hash, err = wsp.LatestChangeByAuthor(context.Background(), "Example <email@me.com>")
+	assert.NoError(t, err)
+	_, err = wsp.RevertChange(context.Background(), hash, RevertChangeParams{RevertedBy: "Example <email@me.com>", Undo: true})
+	assert.NoError(t, err)
+	td2, err = wsp.GetTodo(context.Background(), td.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, td2.CommentCount)
+
+	_, err = wsp.LatestChangeByAuthor(context.Background(), "Other <other@me.com>")
+	assert.EqualError(t, err, "no changes by 'Other <other@me.com>' to undo")
+}
+
+func TestRevertChange_undo_revert(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, true)
+	td, err := wsp.CreateTodo(context.Background(), CreateTodoParams{Title: "Do the thing", CreatedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+	_, err = wsp.EditTodo(context.Background(), td.Id, EditTodoParams{Title: internal.Ref("Do the other thing"), UpdatedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+	editHash := wsp.(DocProvider).GetDoc().Heads()[0].String()
+
+	revert, err := wsp.RevertChange(context.Background(), editHash, RevertChangeParams{RevertedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+
+	// undo right after a revert undoes the revert itself
+	hash, err := wsp.LatestChangeByAuthor(context.Background(), "Example <email@me.com>")
+	assert.NoError(t, err)
+	assert.Equal(t, revert.Hash, hash)
+	undo, err := wsp.RevertChange(context.Background(), hash, RevertChangeParams{RevertedBy: "Example <email@me.com>", Undo: true})
+	assert.NoError(t, err)
+	assert.Equal(t, "Example <email@me.com> undid change "+revert.Hash, undo.Message)
+	td2, err := wsp.GetTodo(context.Background(), td.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, "Do the other thing", td2.Title)
+
+	// the undo is skipped, so the next undo reverts the edit again
+	hash, err = wsp.LatestChangeByAuthor(context.Background(), "Example <email@me.com>")
+	assert.NoError(t, err)
+	assert.Equal(t, editHash, hash)
+}
+
+func TestRevertChange_list(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, true)
+	td, err := wsp.CreateTodo(context.Background(), CreateTodoParams{Title: "Do the thing", CreatedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+
+	// lists may be written by other clients of the document
+	doc := wsp.(DocProvider).GetDoc()
+	steps := func() *automerge.List {
+		v, _ := doc.Path("todos", td.Id, "steps").Get()
+		return v.List()
+	}
+	assert.NoError(t, doc.Path("todos", td.Id, "steps").Set(automerge.NewList()))
+	assert.NoError(t, steps().Append("one", "two"))
+	_, err = doc.Commit("Example <email@me.com> edited todo " + td.Id)
+	assert.NoError(t, err)
+	assert.NoError(t, steps().Delete(0))
+	assert.NoError(t, steps().Append(automerge.NewMap()))
+	assert.NoError(t, doc.Path("todos", td.Id, "steps", 1, "text").Set("three"))
+	editHash, err := doc.Commit("Example <email@me.com> edited todo " + td.Id)
+	assert.NoError(t, err)
+
+	entry, err := wsp.RevertChange(context.Background(), editHash.String(), RevertChangeParams{RevertedBy: "Example <email@me.com>"})
+	assert.NoError(t, err)
+	assert.Equal(t, []FieldChange{{Field: "todos/" + td.Id + "/steps", Before: internal.Ref("<list>"), After: internal.Ref("<list>")}}, entry.Changes)
+	values, err := automerge.As[[]string](doc.Path("todos", td.Id, "steps").Get())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"one", "two"}, values)
+}
+
+func TestRevertChange_counter(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, true)
+	author := "Example <email@me.com>"
+	td, err := wsp.CreateTodo(context.Background(), CreateTodoParams{Title: "Do the thing", CreatedBy: author})
+	assert.NoError(t, err)
+	_, err = wsp.LogTime(context.Background(), td.Id, LogTimeParams{Duration: time.Hour, LoggedBy: author})
+	assert.NoError(t, err)
+	doc := wsp.(DocProvider).GetDoc()
+	logHash := doc.Heads()[0]
+
+	// time logged by a peer concurrently with the revert is kept when the changes merge
+	peer, err := doc.Fork()
+	assert.NoError(t, err)
+	_, err = NewInMemoryWorkspaceProvider(peer).LogTime(context.Background(), td.Id, LogTimeParams{Duration: 30 * time.Minute, LoggedBy: author})
+	assert.NoError(t, err)
+
+	entry, err := wsp.RevertChange(context.Background(), logHash.String(), RevertChangeParams{RevertedBy: author})
+	assert.NoError(t, err)
+	if assert.Len(t, entry.Changes, 2) {
+		assert.Equal(t, FieldChange{Field: "todos/" + td.Id + "/time_spent", Before: internal.Ref("3600"), After: internal.Ref("0")}, entry.Changes[1])
+	}
+
+	_, err = doc.Merge(peer)
+	assert.NoError(t, err)
+	td2, err := wsp.GetTodo(context.Background(), td.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Minute, td2.TimeSpent)
+}
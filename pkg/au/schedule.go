@@ -0,0 +1,97 @@
+package au
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// ParseTodoTime parses the value of a due, start, or hide-until annotation.
+func ParseTodoTime(value string) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, errors.Errorf("'%s' must be an RFC3339 timestamp", value)
+	}
+	return t, nil
+}
+
+// FormatTodoTime formats a time for storage in a due, start, or hide-until annotation.
+func FormatTodoTime(t time.Time) string {
+	return t.UTC().Truncate(time.Second).Format(time.RFC3339)
+}
+
+// ParseTimeInput parses a user-provided point in time which may be an RFC3339 timestamp or a 2006-01-02 date, which is
+// interpreted as midnight at the start of that day in local time.
+func ParseTimeInput(input string) (time.Time, error) {
+	input = strings.TrimSpace(input)
+	if t, err := time.Parse(time.RFC3339, input); err == nil {
+		return t, nil
+	} else if t, err := time.ParseInLocation(time.DateOnly, input, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, errors.Errorf("invalid time '%s', expected an RFC3339 timestamp or YYYY-MM-DD date", input)
+}
+
+var durationDaysPattern = regexp.MustCompile(`^(\d+)([dw])$`)
+
+// ParseDurationInput parses a duration using the time.ParseDuration syntax with additional support for whole days (3d)
+// and weeks (2w).
+func ParseDurationInput(input string) (time.Duration, error) {
+	input = strings.TrimSpace(input)
+	if m := durationDaysPattern.FindStringSubmatch(input); m != nil {
+		n, _ := strconv.Atoi(m[1])
+		if m[2] == "w" {
+			n *= 7
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(input)
+	if err != nil {
+		return 0, errors.Errorf("invalid duration '%s', expected a duration like 90m, 4h, 3d, or 2w", input)
+	}
+	return d, nil
+}
+
+func todoTimeAnnotation(todo *Todo, key string) *time.Time {
+	if v, ok := todo.Annotations[key]; ok {
+		if t, err := ParseTodoTime(v); err == nil {
+			return &t
+		}
+	}
+	return nil
+}
+
+// TodoDue returns the due time of the todo or nil.
+func TodoDue(todo *Todo) *time.Time {
+	return todoTimeAnnotation(todo, AurelianDueAnnotation)
+}
+
+// TodoStart returns the start time of the todo or nil.
+func TodoStart(todo *Todo) *time.Time {
+	return todoTimeAnnotation(todo, AurelianStartAnnotation)
+}
+
+// TodoHiddenUntil returns the time until which the todo is hidden or nil.
+func TodoHiddenUntil(todo *Todo) *time.Time {
+	return todoTimeAnnotation(todo, AurelianHideUntilAnnotation)
+}
+
+// IsTodoHidden returns whether the todo should be hidden from default listings because it has not started yet or has
+// been snoozed.
+func IsTodoHidden(todo *Todo, now time.Time) bool {
+	if t := TodoHiddenUntil(todo); t != nil && t.After(now) {
+		return true
+	} else if t := TodoStart(todo); t != nil && t.After(now) {
+		return true
+	}
+	return false
+}
+
+// IsTodoOverdue returns whether the todo is open and past its due time.
+func IsTodoOverdue(todo *Todo, now time.Time) bool {
+	t := TodoDue(todo)
+	return todo.Status == "open" && t != nil && t.Before(now)
+}
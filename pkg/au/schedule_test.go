@@ -0,0 +1,56 @@
+package au
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDurationInput(t *testing.T) {
+	for input, expected := range map[string]time.Duration{
+		"90m": 90 * time.Minute,
+		"4h":  4 * time.Hour,
+		"3d":  72 * time.Hour,
+		"2w":  14 * 24 * time.Hour,
+	} {
+		d, err := ParseDurationInput(input)
+		assert.NoError(t, err)
+		assert.Equal(t, expected, d)
+	}
+	_, err := ParseDurationInput("tomorrow")
+	assert.EqualError(t, err, "invalid duration 'tomorrow', expected a duration like 90m, 4h, 3d, or 2w")
+}
+
+func TestTodoSchedule(t *testing.T) {
+	now := time.Date(2024, 1, 10, 12, 0, 0, 0, time.UTC)
+	todo := &Todo{Status: "open", Annotations: map[string]string{
+		AurelianDueAnnotation:       "2024-01-09T00:00:00Z",
+		AurelianHideUntilAnnotation: "2024-01-11T00:00:00Z",
+	}}
+	assert.True(t, IsTodoOverdue(todo, now))
+	assert.True(t, IsTodoHidden(todo, now))
+	assert.False(t, IsTodoHidden(todo, now.Add(24*time.Hour)))
+
+	todo.Status = "closed"
+	assert.False(t, IsTodoOverdue(todo, now))
+
+	todo = &Todo{Status: "open", Annotations: map[string]string{AurelianStartAnnotation: "2024-01-10T13:00:00Z"}}
+	assert.True(t, IsTodoHidden(todo, now))
+	assert.False(t, IsTodoOverdue(todo, now))
+}
+
+func TestCreateTodo_invalid_due(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, true)
+	_, err := wsp.CreateTodo(context.Background(), CreateTodoParams{
+		Title: "Do the thing", Annotations: map[string]string{AurelianDueAnnotation: "2024-01-01"}, CreatedBy: "Example <email@me.com>",
+	})
+	assert.EqualError(t, err, "invalid annotation value for 'https://aurelian.one/annotations/due': '2024-01-01' must be an RFC3339 timestamp")
+	_, err = wsp.CreateTodo(context.Background(), CreateTodoParams{
+		Title: "Do the thing", Annotations: map[string]string{AurelianDueAnnotation + "#x": "2024-01-01T00:00:00Z"}, CreatedBy: "Example <email@me.com>",
+	})
+	assert.EqualError(t, err, "invalid annotation key 'https://aurelian.one/annotations/due#x': 'aurelian.one 'due' annotation cannot have a fragment")
+}
@@ -0,0 +1,319 @@
+package au
+
+import (
+	"context"
+	"math"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+const MaximumAnnotationSchemaDescriptionLength = 200
+
+// The value types that an AnnotationSchema can declare.
+const (
+	AnnotationTypeString  = "string"
+	AnnotationTypeInteger = "integer"
+	AnnotationTypeNumber  = "number"
+	AnnotationTypeBool    = "bool"
+	AnnotationTypeDate    = "date"
+	AnnotationTypeEnum    = "enum"
+	AnnotationTypeUrl     = "url"
+)
+
+var annotationTypes = []string{
+	AnnotationTypeString, AnnotationTypeInteger, AnnotationTypeNumber, AnnotationTypeBool, AnnotationTypeDate,
+	AnnotationTypeEnum, AnnotationTypeUrl,
+}
+
+// AnnotationValidator checks the value of an annotation before it is added or modified.
+type AnnotationValidator func(value string) error
+
+// AnnotationSchema describes the values of an annotation key. Schemas may be registered by applications with
+// RegisterAnnotationSchema or declared inside the workspace with SetAnnotationSchema.
+type AnnotationSchema struct {
+	Key  string
+	Type string
+	// Values lists the allowed values of an enum annotation.
+	Values      []string
+	Description string
+}
+
+type SetAnnotationSchemaParams struct {
+	Key         string
+	Type        string
+	Values      []string
+	Description string
+	UpdatedBy   string
+}
+
+type DeleteAnnotationSchemaParams struct {
+	DeletedBy string
+}
+
+// ValidateAnnotationSchema checks and cleans the schema. Reserved annotations already have their values validated and
+// cannot be given a schema.
+func ValidateAnnotationSchema(schema AnnotationSchema) (AnnotationSchema, error) {
+	schema.Key = strings.TrimSpace(schema.Key)
+	if err := ValidateTodoAnnotationKey(schema.Key); err != nil {
+		return schema, errors.Wrapf(err, "invalid annotation key '%s'", schema.Key)
+	} else if u, _ := url.Parse(schema.Key); u.Hostname() == ReservedAnnotationHostname {
+		return schema, errors.Errorf("'%s' annotations cannot have a schema", u.Hostname())
+	} else if u.Fragment != "" || u.RawFragment != "" {
+		return schema, errors.New("annotation schema key cannot have a fragment, it applies to all fragments of the key")
+	}
+	schema.Type = strings.TrimSpace(schema.Type)
+	if !slices.Contains(annotationTypes, schema.Type) {
+		return schema, errors.Errorf("annotation type must be one of %s", strings.Join(annotationTypes, ", "))
+	}
+	values := make([]string, 0, len(schema.Values))
+	for _, v := range schema.Values {
+		if v = strings.TrimSpace(v); v == "" {
+			return schema, errors.New("enum values cannot be empty")
+		}
+		values = append(values, v)
+	}
+	slices.Sort(values)
+	schema.Values = slices.Compact(values)
+	if schema.Type == AnnotationTypeEnum && len(schema.Values) == 0 {
+		return schema, errors.New("enum annotations require at least one value")
+	} else if schema.Type != AnnotationTypeEnum && len(schema.Values) > 0 {
+		return schema, errors.Errorf("only enum annotations can have values, not %s", schema.Type)
+	}
+	var err error
+	if schema.Description, err = ValidateAndCleanUnicode(schema.Description, false); err != nil {
+		return schema, errors.Wrap(err, "invalid description")
+	} else if d := MaximumAnnotationSchemaDescriptionLength; len(schema.Description) > d {
+		return schema, errors.Errorf("description is too long, it should be at most %d characters", d)
+	}
+	return schema, nil
+}
+
+// Validate checks that the value matches the type of the schema.
+func (s AnnotationSchema) Validate(value string) error {
+	switch s.Type {
+	case AnnotationTypeInteger:
+		if _, err := strconv.ParseInt(value, 10, 64); err != nil {
+			return errors.Errorf("'%s' is not an integer", value)
+		}
+	case AnnotationTypeNumber:
+		if f, err := strconv.ParseFloat(value, 64); err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return errors.Errorf("'%s' is not a number", value)
+		}
+	case AnnotationTypeBool:
+		if value != "true" && value != "false" {
+			return errors.Errorf("'%s' is not true or false", value)
+		}
+	case AnnotationTypeDate:
+		if _, err := time.Parse(time.DateOnly, value); err != nil {
+			if _, err := ParseTodoTime(value); err != nil {
+				return errors.Errorf("'%s' is not a YYYY-MM-DD date or RFC3339 timestamp", value)
+			}
+		}
+	case AnnotationTypeEnum:
+		if !slices.Contains(s.Values, value) {
+			return errors.Errorf("'%s' is not one of %s", value, strings.Join(s.Values, ", "))
+		}
+	case AnnotationTypeUrl:
+		if u, err := url.ParseRequestURI(value); err != nil || u.Scheme == "" || u.Host == "" {
+			return errors.Errorf("'%s' is not an absolute url", value)
+		}
+	}
+	return nil
+}
+
+// annotationValidators holds the registered validators by annotation key. It is only written to by init functions, so it
+// is never modified once workspaces are in use.
+var annotationValidators = make(map[string]AnnotationValidator)
+
+// RegisterAnnotationValidator registers a process-wide validator for the values of the annotation key. A key without a
+// fragment also applies to every fragment of the key. It must only be called from init functions, and it panics if the
+// key is invalid or already has a validator.
+func RegisterAnnotationValidator(key string, validator AnnotationValidator) {
+	if err := ValidateTodoAnnotationKey(key); err != nil {
+		panic(errors.Wrapf(err, "invalid annotation key '%s'", key))
+	} else if u, _ := url.Parse(key); u.Hostname() == ReservedAnnotationHostname {
+		panic(errors.Errorf("'%s' annotations cannot have a validator", u.Hostname()))
+	} else if validator == nil {
+		panic(errors.New("validator cannot be nil"))
+	} else if _, ok := annotationValidators[key]; ok {
+		panic(errors.Errorf("annotation '%s' already has a validator", key))
+	}
+	annotationValidators[key] = validator
+}
+
+// RegisterAnnotationSchema registers a process-wide validator for the values described by the schema. Like
+// RegisterAnnotationValidator, it must only be called from init functions.
+func RegisterAnnotationSchema(schema AnnotationSchema) {
+	schema, err := ValidateAnnotationSchema(schema)
+	if err != nil {
+		panic(err)
+	}
+	RegisterAnnotationValidator(schema.Key, schema.Validate)
+}
+
+// registeredAnnotationValidator returns the validator for the key, falling back to the validator of the key without
+// its fragment.
+func registeredAnnotationValidator(key string) AnnotationValidator {
+	if v, ok := annotationValidators[key]; ok {
+		return v
+	} else if base, _, ok := strings.Cut(key, "#"); ok {
+		return annotationValidators[base]
+	}
+	return nil
+}
+
+// annotationUnderstoodInner returns whether this client understands the annotation key, either because it is a
+// supported reserved key or because it has a registered validator or a schema declared in the workspace.
+func annotationUnderstoodInner(doc *automerge.Doc, key string) bool {
+	if u, err := url.Parse(key); err == nil && u.Hostname() == ReservedAnnotationHostname {
+		return ValidateTodoAnnotationKey(key) == nil
+	} else if registeredAnnotationValidator(key) != nil {
+		return true
+	}
+	base, _, _ := strings.Cut(key, "#")
+	return slices.ContainsFunc(listAnnotationSchemasInner(doc), func(s AnnotationSchema) bool { return s.Key == key || s.Key == base })
+}
+
+// validateReadOnlyAnnotationsInner refuses to change or remove the existing annotations of the todo which this client
+// does not understand, since it cannot tell whether the new value is valid. New annotations may still be added.
+func validateReadOnlyAnnotationsInner(doc *automerge.Doc, todo *Todo, annotations map[string]string) error {
+	for k, v := range annotations {
+		if existing := todo.Annotations[k]; existing != "" && existing != v && !annotationUnderstoodInner(doc, k) {
+			return errors.Errorf("annotation '%s' is read-only since it is not understood by this client", k)
+		}
+	}
+	return nil
+}
+
+func (p *inMemoryWorkspaceProvider) ListAnnotationSchemas(ctx context.Context) ([]AnnotationSchema, error) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	return listAnnotationSchemasInner(p.Doc), nil
+}
+
+// SetAnnotationSchema declares or replaces the schema of an annotation key in the workspace. Existing annotation
+// values are not checked, the schema only applies when annotations are added or modified.
+func (p *inMemoryWorkspaceProvider) SetAnnotationSchema(ctx context.Context, params SetAnnotationSchemaParams) (*AnnotationSchema, error) {
+	if err := ValidatedAuthor(params.UpdatedBy); err != nil {
+		return nil, err
+	}
+	schema, err := ValidateAnnotationSchema(AnnotationSchema{Key: params.Key, Type: params.Type, Values: params.Values, Description: params.Description})
+	if err != nil {
+		return nil, err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	schemas, err := settingsMapInner(p.Doc, "annotations")
+	if err != nil {
+		return nil, err
+	}
+	newSchema, values := automerge.NewMap(), automerge.NewMap()
+	if err := schemas.Set(schema.Key, newSchema); err != nil {
+		return nil, errors.Wrap(err, "failed to set annotation schema entry")
+	} else if err := newSchema.Set("type", schema.Type); err != nil {
+		return nil, errors.Wrap(err, "failed to set type")
+	} else if err := newSchema.Set("values", values); err != nil {
+		return nil, errors.Wrap(err, "failed to set values")
+	} else if err := newSchema.Set("description", schema.Description); err != nil {
+		return nil, errors.Wrap(err, "failed to set description")
+	}
+	for _, v := range schema.Values {
+		if err := values.Set(v, true); err != nil {
+			return nil, errors.Wrap(err, "failed to set value")
+		}
+	}
+
+	if _, err := p.commit(params.UpdatedBy + " set annotation schema " + schema.Key); err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
+	return &schema, nil
+}
+
+// DeleteAnnotationSchema removes the schema of the annotation key from the workspace. Annotations with the key keep
+// their values.
+func (p *inMemoryWorkspaceProvider) DeleteAnnotationSchema(ctx context.Context, key string, params DeleteAnnotationSchemaParams) error {
+	if err := ValidatedAuthor(params.DeletedBy); err != nil {
+		return err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	schemas, err := settingsMapInner(p.Doc, "annotations")
+	if err != nil {
+		return errors.Errorf("annotation schema '%s' does not exist", key)
+	} else if v, _ := schemas.Get(key); v.Kind() != automerge.KindMap {
+		return errors.Errorf("annotation schema '%s' does not exist", key)
+	} else if err := schemas.Delete(key); err != nil {
+		return errors.Wrap(err, "failed to delete annotation schema")
+	}
+	if _, err := p.commit(params.DeletedBy + " deleted annotation schema " + key); err != nil {
+		return errors.Wrap(err, "failed to commit")
+	}
+	return nil
+}
+
+// listAnnotationSchemasInner returns the valid annotation schemas of the workspace sorted by key. Entries that are not
+// valid, for example with a type that this client does not know, are ignored.
+func listAnnotationSchemasInner(doc *automerge.Doc) []AnnotationSchema {
+	output := make([]AnnotationSchema, 0)
+	schemas, err := settingsMapInner(doc, "annotations")
+	if err != nil {
+		return output
+	}
+	keys, _ := schemas.Keys()
+	for _, key := range keys {
+		v, _ := schemas.Get(key)
+		if v.Kind() != automerge.KindMap {
+			continue
+		}
+		s := AnnotationSchema{Key: key}
+		if t, _ := v.Map().Get("type"); t.Kind() == automerge.KindStr {
+			s.Type = t.Str()
+		}
+		if d, _ := v.Map().Get("description"); d.Kind() == automerge.KindStr {
+			s.Description = d.Str()
+		}
+		if vs, _ := v.Map().Get("values"); vs.Kind() == automerge.KindMap {
+			s.Values, _ = vs.Map().Keys()
+		}
+		if s, err = ValidateAnnotationSchema(s); err != nil {
+			continue
+		}
+		output = append(output, s)
+	}
+	slices.SortFunc(output, func(a, b AnnotationSchema) int {
+		return strings.Compare(a.Key, b.Key)
+	})
+	return output
+}
+
+// validateDeclaredAnnotationsInner checks the annotations being added or modified against the schemas declared in the
+// workspace. Empty values remove the annotation and are not checked.
+func validateDeclaredAnnotationsInner(doc *automerge.Doc, annotations map[string]string) error {
+	schemas := listAnnotationSchemasInner(doc)
+	if len(schemas) == 0 {
+		return nil
+	}
+	for k, v := range annotations {
+		if v == "" {
+			continue
+		}
+		base, _, _ := strings.Cut(k, "#")
+		i := slices.IndexFunc(schemas, func(s AnnotationSchema) bool { return s.Key == k || s.Key == base })
+		if i < 0 {
+			continue
+		} else if err := schemas[i].Validate(v); err != nil {
+			return errors.Wrapf(err, "invalid annotation value for '%s'", k)
+		}
+	}
+	return nil
+}
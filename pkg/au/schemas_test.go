@@ -0,0 +1,150 @@
+package au
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aurelian-one/au/internal"
+)
+
+func TestAnnotationSchema_Validate(t *testing.T) {
+	for _, tc := range []struct {
+		schema AnnotationSchema
+		value  string
+		err    string
+	}{
+		{AnnotationSchema{Type: AnnotationTypeString}, "anything", ""},
+		{AnnotationSchema{Type: AnnotationTypeInteger}, "-42", ""},
+		{AnnotationSchema{Type: AnnotationTypeInteger}, "4.2", "'4.2' is not an integer"},
+		{AnnotationSchema{Type: AnnotationTypeNumber}, "4.2", ""},
+		{AnnotationSchema{Type: AnnotationTypeNumber}, "NaN", "'NaN' is not a number"},
+		{AnnotationSchema{Type: AnnotationTypeBool}, "false", ""},
+		{AnnotationSchema{Type: AnnotationTypeBool}, "yes", "'yes' is not true or false"},
+		{AnnotationSchema{Type: AnnotationTypeDate}, "2025-01-31", ""},
+		{AnnotationSchema{Type: AnnotationTypeDate}, "2025-01-31T10:00:00Z", ""},
+		{AnnotationSchema{Type: AnnotationTypeDate}, "31/01/2025", "'31/01/2025' is not a YYYY-MM-DD date or RFC3339 timestamp"},
+		{AnnotationSchema{Type: AnnotationTypeEnum, Values: []string{"a", "b"}}, "b", ""},
+		{AnnotationSchema{Type: AnnotationTypeEnum, Values: []string{"a", "b"}}, "c", "'c' is not one of a, b"},
+		{AnnotationSchema{Type: AnnotationTypeUrl}, "https://example.com/x", ""},
+		{AnnotationSchema{Type: AnnotationTypeUrl}, "example.com", "'example.com' is not an absolute url"},
+	} {
+		t.Run(tc.schema.Type+"/"+tc.value, func(t *testing.T) {
+			if err := tc.schema.Validate(tc.value); tc.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.EqualError(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestValidateAnnotationSchema(t *testing.T) {
+	s, err := ValidateAnnotationSchema(AnnotationSchema{Key: " https://example.com/size ", Type: "enum", Values: []string{"s", " m", "s"}})
+	assert.NoError(t, err)
+	assert.Equal(t, AnnotationSchema{Key: "https://example.com/size", Type: "enum", Values: []string{"m", "s"}}, s)
+
+	_, err = ValidateAnnotationSchema(AnnotationSchema{Key: "https://example.com/size#x", Type: "string"})
+	assert.EqualError(t, err, "annotation schema key cannot have a fragment, it applies to all fragments of the key")
+	_, err = ValidateAnnotationSchema(AnnotationSchema{Key: "https://example.com/size", Type: "colour"})
+	assert.EqualError(t, err, "annotation type must be one of string, integer, number, bool, date, enum, url")
+	_, err = ValidateAnnotationSchema(AnnotationSchema{Key: "https://example.com/size", Type: "integer", Values: []string{"1"}})
+	assert.EqualError(t, err, "only enum annotations can have values, not integer")
+	_, err = ValidateAnnotationSchema(AnnotationSchema{Key: "https://example.com/size", Type: "string", Description: strings.Repeat("x", 201)})
+	assert.EqualError(t, err, "description is too long, it should be at most 200 characters")
+}
+
+const registeredTestAnnotation = "https://example.com/estimate"
+
+func init() {
+	RegisterAnnotationSchema(AnnotationSchema{Key: registeredTestAnnotation, Type: AnnotationTypeInteger})
+}
+
+func TestRegisterAnnotationValidator(t *testing.T) {
+	key := registeredTestAnnotation
+	assert.PanicsWithError(t, "'aurelian.one' annotations cannot have a validator", func() {
+		RegisterAnnotationValidator(AurelianRankAnnotation, func(string) error { return nil })
+	})
+	assert.PanicsWithError(t, "annotation '"+key+"' already has a validator", func() {
+		RegisterAnnotationValidator(key, func(string) error { return nil })
+	})
+
+	assert.NoError(t, ValidateTodoAnnotationValue(key, "3"))
+	assert.EqualError(t, ValidateTodoAnnotationValue(key, "three"), "'three' is not an integer")
+	assert.EqualError(t, ValidateTodoAnnotationValue(key+"#backend", "three"), "'three' is not an integer")
+	assert.NoError(t, ValidateTodoAnnotationValue("https://example.com/other", "three"))
+
+	ws := newTestWorkspace(t)
+	_, err := ws.CreateTodo(context.Background(), CreateTodoParams{Title: "Todo", CreatedBy: "Alice <alice@me.com>", Annotations: map[string]string{key: "three"}})
+	assert.EqualError(t, err, "invalid annotation value for '"+key+"': 'three' is not an integer")
+}
+
+func TestUnknownAnnotationsAreReadOnly(t *testing.T) {
+	ws := newTestWorkspace(t)
+	alice := "Alice <alice@me.com>"
+	ctx := context.Background()
+	unknown := "https://example.com/unknown"
+
+	todo, err := ws.CreateTodo(ctx, CreateTodoParams{Title: "Todo", CreatedBy: alice, Annotations: map[string]string{unknown: "a", registeredTestAnnotation: "1"}})
+	assert.NoError(t, err)
+
+	_, err = ws.EditTodo(ctx, todo.Id, EditTodoParams{Annotations: map[string]string{unknown: "b"}, UpdatedBy: alice})
+	assert.EqualError(t, err, "annotation '"+unknown+"' is read-only since it is not understood by this client")
+	_, err = ws.EditTodo(ctx, todo.Id, EditTodoParams{Annotations: map[string]string{unknown: ""}, UpdatedBy: alice})
+	assert.EqualError(t, err, "annotation '"+unknown+"' is read-only since it is not understood by this client")
+
+	// setting the same value, adding new unknown annotations, and changing understood annotations are allowed
+	edited, err := ws.EditTodo(ctx, todo.Id, EditTodoParams{Annotations: map[string]string{
+		unknown:                    "a",
+		unknown + "#other":         "c",
+		registeredTestAnnotation:   "2",
+		LabelAnnotationKey("home"): "true",
+	}, UpdatedBy: alice})
+	assert.NoError(t, err)
+	assert.Equal(t, "a", edited.Annotations[unknown])
+	assert.Equal(t, "2", edited.Annotations[registeredTestAnnotation])
+
+	// declaring a schema makes the annotation understood
+	_, err = ws.SetAnnotationSchema(ctx, SetAnnotationSchemaParams{Key: unknown, Type: AnnotationTypeString, UpdatedBy: alice})
+	assert.NoError(t, err)
+	edited, err = ws.EditTodo(ctx, todo.Id, EditTodoParams{Annotations: map[string]string{unknown: "b", unknown + "#other": ""}, UpdatedBy: alice})
+	assert.NoError(t, err)
+	assert.Equal(t, "b", edited.Annotations[unknown])
+	assert.NotContains(t, edited.Annotations, unknown+"#other")
+}
+
+func TestDeclaredAnnotationSchemas(t *testing.T) {
+	ws := newTestWorkspace(t)
+	alice := "Alice <alice@me.com>"
+	ctx := context.Background()
+	key := "https://example.com/reviewer"
+
+	todo, err := ws.CreateTodo(ctx, CreateTodoParams{Title: "Todo", CreatedBy: alice, Annotations: map[string]string{key + "#code": "not a url"}})
+	assert.NoError(t, err)
+
+	schema, err := ws.SetAnnotationSchema(ctx, SetAnnotationSchemaParams{Key: key, Type: AnnotationTypeUrl, UpdatedBy: alice})
+	assert.NoError(t, err)
+	assert.Equal(t, &AnnotationSchema{Key: key, Type: AnnotationTypeUrl, Values: []string{}}, schema)
+	schemas, err := ws.ListAnnotationSchemas(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []AnnotationSchema{*schema}, schemas)
+
+	// existing values are left as they are unless they are modified
+	_, err = ws.EditTodo(ctx, todo.Id, EditTodoParams{Title: internal.Ref("Renamed"), UpdatedBy: alice})
+	assert.NoError(t, err)
+	_, err = ws.EditTodo(ctx, todo.Id, EditTodoParams{Annotations: map[string]string{key + "#code": "still not a url"}, UpdatedBy: alice})
+	assert.EqualError(t, err, "invalid annotation value for '"+key+"#code': 'still not a url' is not an absolute url")
+	edited, err := ws.EditTodo(ctx, todo.Id, EditTodoParams{Annotations: map[string]string{key + "#code": ""}, UpdatedBy: alice})
+	assert.NoError(t, err)
+	assert.NotContains(t, edited.Annotations, key+"#code")
+
+	_, err = ws.AddTodoTemplate(ctx, AddTodoTemplateParams{Name: "review", Title: "Review", Annotations: map[string]string{key: "nope"}, CreatedBy: alice})
+	assert.EqualError(t, err, "invalid annotation value for '"+key+"': 'nope' is not an absolute url")
+
+	assert.NoError(t, ws.DeleteAnnotationSchema(ctx, key, DeleteAnnotationSchemaParams{DeletedBy: alice}))
+	assert.EqualError(t, ws.DeleteAnnotationSchema(ctx, key, DeleteAnnotationSchemaParams{DeletedBy: alice}), "annotation schema '"+key+"' does not exist")
+	_, err = ws.EditTodo(ctx, todo.Id, EditTodoParams{Annotations: map[string]string{key: "anything"}, UpdatedBy: alice})
+	assert.NoError(t, err)
+}
@@ -0,0 +1,40 @@
+package au
+
+import (
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+// settingsMaps are the maps within the settings of the workspace. They are created along with the workspace, or when an
+// older workspace is next opened for writing, rather than when they are first written to. Peers that each create the
+// same map concurrently create different objects, and all the entries of the losing object disappear when they merge.
+var settingsMaps = []string{"statuses", "templates", "annotations", "members"}
+
+// migrateSettingsInner creates the settings and any settings maps that do not exist yet without committing them. It
+// returns whether anything was created.
+func migrateSettingsInner(doc *automerge.Doc) (bool, error) {
+	changed := false
+	if v, _ := doc.Path("settings").Get(); v.Kind() != automerge.KindMap {
+		if err := doc.Path("settings").Set(automerge.NewMap()); err != nil {
+			return false, errors.Wrap(err, "failed to set settings")
+		}
+		changed = true
+	}
+	for _, name := range settingsMaps {
+		if v, _ := doc.Path("settings", name).Get(); v.Kind() != automerge.KindMap {
+			if err := doc.Path("settings", name).Set(automerge.NewMap()); err != nil {
+				return false, errors.Wrapf(err, "failed to set %s", name)
+			}
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// settingsMapInner returns the named settings map.
+func settingsMapInner(doc *automerge.Doc, name string) (*automerge.Map, error) {
+	if v, _ := doc.Path("settings", name).Get(); v.Kind() == automerge.KindMap {
+		return v.Map(), nil
+	}
+	return nil, errors.Errorf("workspace has no %s settings, it must be opened for writing to migrate it", name)
+}
@@ -0,0 +1,100 @@
+package au
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// MinimumShortIdLength is the shortest prefix or suffix accepted in place of a full id. ULIDs created close together
+// share their leading timestamp characters, so a suffix is usually the shortest unique form.
+const MinimumShortIdLength = 4
+
+// ResolveShortId returns the id from ids which is equal to the input or which has the input as a unique prefix or suffix.
+// If nothing matches, the input is returned unchanged so that the caller reports the missing id as usual. The kind
+// describes the ids in the error when the input is ambiguous.
+func ResolveShortId(kind string, input string, ids []string) (string, error) {
+	if slices.Contains(ids, input) {
+		return input, nil
+	}
+	normalised := strings.ToUpper(strings.TrimSpace(input))
+	if len(normalised) < MinimumShortIdLength {
+		return input, nil
+	}
+	candidates := make([]string, 0)
+	for _, id := range ids {
+		if id == normalised || strings.HasPrefix(id, normalised) || strings.HasSuffix(id, normalised) {
+			candidates = append(candidates, id)
+		}
+	}
+	switch len(candidates) {
+	case 0:
+		return input, nil
+	case 1:
+		return candidates[0], nil
+	default:
+		slices.Sort(candidates)
+		return "", errors.Errorf("%s id '%s' is ambiguous, it matches: %s", kind, input, strings.Join(candidates, ", "))
+	}
+}
+
+// ShortIds returns the shortest suffix of each id which ResolveShortId resolves to that id alone. Rather than comparing
+// every pair of ids, the ids are sorted both forwards and by their reversed characters, so that the ids sharing the
+// longest suffix are neighbours and the ids starting with a given suffix can be found with a binary search.
+func ShortIds(ids []string) map[string]string {
+	sorted := slices.Clone(ids)
+	slices.Sort(sorted)
+	sorted = slices.Compact(sorted)
+	reversed := make([]string, len(sorted))
+	for i, id := range sorted {
+		reversed[i] = reverseString(id)
+	}
+	slices.Sort(reversed)
+
+	output := make(map[string]string, len(sorted))
+	for i, r := range reversed {
+		id := reverseString(r)
+		// a suffix at most as long as the suffix shared with a neighbour is also a suffix of that neighbour
+		l := MinimumShortIdLength
+		if i > 0 {
+			l = max(l, commonPrefixLength(r, reversed[i-1])+1)
+		}
+		if i < len(reversed)-1 {
+			l = max(l, commonPrefixLength(r, reversed[i+1])+1)
+		}
+		output[id] = id
+		for ; l < len(id); l++ {
+			if !hasOtherWithPrefix(sorted, id, id[len(id)-l:]) {
+				output[id] = id[len(id)-l:]
+				break
+			}
+		}
+	}
+	return output
+}
+
+// hasOtherWithPrefix returns whether an id in the sorted ids other than the given one starts with the prefix.
+func hasOtherWithPrefix(sorted []string, id, prefix string) bool {
+	i, _ := slices.BinarySearch(sorted, prefix)
+	for ; i < len(sorted) && strings.HasPrefix(sorted[i], prefix); i++ {
+		if sorted[i] != id {
+			return true
+		}
+	}
+	return false
+}
+
+func commonPrefixLength(a, b string) int {
+	n := 0
+	for n < len(a) && n < len(b) && a[n] == b[n] {
+		n++
+	}
+	return n
+}
+
+func reverseString(input string) string {
+	b := []byte(input)
+	slices.Reverse(b)
+	return string(b)
+}
@@ -0,0 +1,70 @@
+package au
+
+import (
+	"testing"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestResolveShortId(t *testing.T) {
+	ids := []string{"01HQ8Z0000000000000000ABCD", "01HQ8Z0000000000000000WXYZ", "01JA00000000000000000AWXYZ"}
+	for _, tc := range []struct {
+		input    string
+		expected string
+		err      string
+	}{
+		{input: "01HQ8Z0000000000000000ABCD", expected: "01HQ8Z0000000000000000ABCD"},
+		{input: "abcd", expected: "01HQ8Z0000000000000000ABCD"},
+		{input: "00wxyz", expected: "01HQ8Z0000000000000000WXYZ"},
+		{input: "01JA", expected: "01JA00000000000000000AWXYZ"},
+		{input: "XYZ", expected: "XYZ"},
+		{input: "NOPE", expected: "NOPE"},
+		{input: "01HQ", err: "todo id '01HQ' is ambiguous, it matches: 01HQ8Z0000000000000000ABCD, 01HQ8Z0000000000000000WXYZ"},
+		{input: "wxyz", err: "todo id 'wxyz' is ambiguous, it matches: 01HQ8Z0000000000000000WXYZ, 01JA00000000000000000AWXYZ"},
+	} {
+		t.Run(tc.input, func(t *testing.T) {
+			id, err := ResolveShortId("todo", tc.input, ids)
+			if tc.err != "" {
+				assert.EqualError(t, err, tc.err)
+			} else if assert.NoError(t, err) {
+				assert.Equal(t, tc.expected, id)
+			}
+		})
+	}
+}
+
+func TestShortIds(t *testing.T) {
+	ids := []string{"01HQ8Z0000000000000000ABCD", "01HQ8Z000000000000000XWXYZ", "01JA00000000000000000YWXYZ"}
+	shortIds := ShortIds(ids)
+	assert.Equal(t, map[string]string{
+		"01HQ8Z0000000000000000ABCD": "ABCD",
+		"01HQ8Z000000000000000XWXYZ": "XWXYZ",
+		"01JA00000000000000000YWXYZ": "YWXYZ",
+	}, shortIds)
+	for id, short := range shortIds {
+		resolved, err := ResolveShortId("todo", short, ids)
+		assert.NoError(t, err)
+		assert.Equal(t, id, resolved)
+	}
+
+	// a suffix which is the prefix of another id is not unique
+	assert.Equal(t, map[string]string{"AAAA1234": "A1234", "1234BBBB": "BBBB"}, ShortIds([]string{"AAAA1234", "1234BBBB"}))
+
+	// ids created in the same millisecond only differ in their random suffix
+	ids = make([]string, 2000)
+	for i := range ids {
+		ids[i] = ulid.Make().String()
+	}
+	shortIds = ShortIds(ids)
+	assert.Len(t, shortIds, len(ids))
+	for id, short := range shortIds {
+		resolved, err := ResolveShortId("todo", short, ids)
+		assert.NoError(t, err)
+		assert.Equal(t, id, resolved)
+		if len(short) > MinimumShortIdLength {
+			_, err := ResolveShortId("todo", short[1:], ids)
+			assert.Error(t, err, "%s is not the shortest suffix of %s", short, id)
+		}
+	}
+}
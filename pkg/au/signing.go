@@ -0,0 +1,346 @@
+package au
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/rand"
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+// signaturePrefix starts the message of the change that carries the signature of another change. Signature changes
+// have no operations and depend on the change they sign, with the message "au-signature: ed25519 <change hash> <public
+// key> <signature>" where the key and signature are in standard base64.
+const signaturePrefix = "au-signature: ed25519 "
+
+// signaturePayloadVersion is prefixed to the signed payload so that the payload format can change in the future.
+const signaturePayloadVersion = "au-signature-v1\n"
+
+// The results of verifying the signature of a change.
+const (
+	// SignatureStatusValid means the change was signed by a trusted key of the author it claims.
+	SignatureStatusValid = "valid"
+	// SignatureStatusUnsigned means the change has no signature.
+	SignatureStatusUnsigned = "unsigned"
+	// SignatureStatusUntrusted means the signature is intact but the key is not trusted for the author it claims.
+	SignatureStatusUntrusted = "untrusted"
+	// SignatureStatusInvalid means the signature does not match the change, so the change or signature was tampered with.
+	SignatureStatusInvalid = "invalid"
+	// SignatureStatusGenesis means the change is the unsigned change without an author that created the workspace.
+	SignatureStatusGenesis = "genesis"
+)
+
+// SigningKeyLookup returns the private key that signs the changes of the given 'Name <email>' author, or nil when the
+// author has no key and their changes are not signed.
+type SigningKeyLookup func(author string) (ed25519.PrivateKey, error)
+
+// AuthorKeys are the trusted public keys of each author keyed by their lower-case email.
+type AuthorKeys map[string][]ed25519.PublicKey
+
+// Lookup returns the trusted keys of the 'Name <email>' author.
+func (k AuthorKeys) Lookup(author string) []ed25519.PublicKey {
+	if _, email, err := ParseAuthor(author); err == nil {
+		return k[strings.ToLower(email)]
+	}
+	return nil
+}
+
+// ChangeVerification is the result of verifying the signature of a single change.
+type ChangeVerification struct {
+	Hash    string
+	At      time.Time
+	Author  string
+	Message string
+	Status  string
+	// PublicKey is the key that signed the change, if it is signed.
+	PublicKey string
+}
+
+// EncodePublicKey returns the text form of a public key used in key files and signatures.
+func EncodePublicKey(key ed25519.PublicKey) string {
+	return base64.StdEncoding.EncodeToString(key)
+}
+
+// ParsePublicKey parses the text form of a public key.
+func ParsePublicKey(input string) (ed25519.PublicKey, error) {
+	raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(input))
+	if err != nil || len(raw) != ed25519.PublicKeySize {
+		return nil, errors.New("invalid public key, expected a base64 ed25519 public key")
+	}
+	return raw, nil
+}
+
+// commit commits the pending operations with the message and, when the author of the message has a signing key,
+// signs the resulting change in a following signature change.
+func (p *inMemoryWorkspaceProvider) commit(message string, opts ...automerge.CommitOptions) (automerge.ChangeHash, error) {
+	var key ed25519.PrivateKey
+	if author := commitAuthor(message); author != "" && p.SigningKeys != nil {
+		var err error
+		if key, err = p.SigningKeys(author); err != nil {
+			return automerge.ChangeHash{}, errors.Wrap(err, "failed to get signing key")
+		}
+	}
+	hash, err := p.Doc.Commit(message, opts...)
+	if err != nil || key == nil {
+		return hash, err
+	}
+	signature := ed25519.Sign(key, signaturePayload(hash))
+	signatureMessage := signaturePrefix + hash.String() + " " + EncodePublicKey(key.Public().(ed25519.PublicKey)) + " " + base64.StdEncoding.EncodeToString(signature)
+	if _, err := p.Doc.Commit(signatureMessage, automerge.CommitOptions{AllowEmpty: true}); err != nil {
+		return hash, errors.Wrap(err, "failed to commit signature")
+	}
+	return hash, nil
+}
+
+// signaturePayload returns the payload signed for a change. The hash of a change is the SHA-256 of its encoded
+// operations, dependencies, author, time, and message, so it is the digest of the whole change.
+func signaturePayload(hash automerge.ChangeHash) []byte {
+	return []byte(signaturePayloadVersion + hash.String())
+}
+
+// changeSignature is a signature of a change read from a signature change. The key is nil if it could not be parsed.
+type changeSignature struct {
+	Key       ed25519.PublicKey
+	Signature []byte
+}
+
+// isSignatureChange returns whether the change only carries a signature. The change must have no operations so that
+// the signature changes, which are not themselves signed, cannot modify the document.
+func isSignatureChange(change *automerge.Change) bool {
+	if !strings.HasPrefix(change.Message(), signaturePrefix) {
+		return false
+	}
+	chunk, err := parseChangeChunk(change.Save())
+	if err != nil {
+		return false
+	}
+	ops, err := chunk.ops()
+	return err == nil && len(ops) == 0
+}
+
+// signatureIndex returns the signatures carried by the signature changes among the changes by the hash they sign.
+func signatureIndex(changes []*automerge.Change) map[automerge.ChangeHash][]changeSignature {
+	output := make(map[automerge.ChangeHash][]changeSignature)
+	for _, change := range changes {
+		if !isSignatureChange(change) {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(change.Message(), signaturePrefix))
+		if len(fields) != 3 {
+			continue
+		}
+		hash, err := automerge.NewChangeHash(fields[0])
+		if err != nil {
+			continue
+		}
+		signature := changeSignature{}
+		if key, err := ParsePublicKey(fields[1]); err == nil {
+			if raw, err := base64.StdEncoding.DecodeString(fields[2]); err == nil {
+				signature = changeSignature{Key: key, Signature: raw}
+			}
+		}
+		output[hash] = append(output[hash], signature)
+	}
+	return output
+}
+
+// signatureStatusRank orders the statuses so that the best signature of a change is reported.
+var signatureStatusRank = map[string]int{
+	SignatureStatusUnsigned: 0, SignatureStatusInvalid: 1, SignatureStatusUntrusted: 2, SignatureStatusValid: 3,
+}
+
+// verifyChange checks the signatures of the change against the trusted keys of the author it claims.
+func verifyChange(change *automerge.Change, signatures []changeSignature, keys AuthorKeys) ChangeVerification {
+	output := ChangeVerification{
+		Hash:    change.Hash().String(),
+		At:      change.Timestamp().In(time.UTC),
+		Author:  commitAuthor(change.Message()),
+		Message: change.Message(),
+		Status:  SignatureStatusUnsigned,
+	}
+	for _, s := range signatures {
+		status := SignatureStatusInvalid
+		if s.Key != nil && ed25519.Verify(s.Key, signaturePayload(change.Hash()), s.Signature) {
+			status = SignatureStatusUntrusted
+			if slices.ContainsFunc(keys.Lookup(output.Author), func(k ed25519.PublicKey) bool { return k.Equal(s.Key) }) {
+				status = SignatureStatusValid
+			}
+		}
+		if signatureStatusRank[status] > signatureStatusRank[output.Status] {
+			output.Status = status
+			output.PublicKey = ""
+			if s.Key != nil {
+				output.PublicKey = EncodePublicKey(s.Key)
+			}
+		}
+	}
+	return output
+}
+
+// VerifyChanges verifies every change in the document in causal order, except for the changes which only carry
+// signatures. The change without an author that created the workspace is accepted as the genesis when it is the only
+// change without dependencies, since workspaces may be created before any author is known.
+func VerifyChanges(doc *automerge.Doc, keys AuthorKeys) ([]ChangeVerification, error) {
+	changes, err := doc.Changes()
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get changes")
+	}
+	signatures := signatureIndex(changes)
+	genesis := workspaceGenesis(changes)
+	output := make([]ChangeVerification, 0, len(changes))
+	for _, change := range changes {
+		if isSignatureChange(change) {
+			continue
+		}
+		v := verifyChange(change, signatures[change.Hash()], keys)
+		if v.Status == SignatureStatusUnsigned && v.Author == "" && change == genesis {
+			v.Status = SignatureStatusGenesis
+		}
+		output = append(output, v)
+	}
+	return output, nil
+}
+
+// RequireSignatures returns a filter for incoming sync changes which rejects any change that is not signed by a
+// trusted key of the author it claims. A change and its signature change are always committed together, so the
+// signatures are read from the incoming changes.
+func RequireSignatures(keys AuthorKeys) func(doc *automerge.Doc, changes []*automerge.Change) error {
+	return func(doc *automerge.Doc, changes []*automerge.Change) error {
+		signatures := signatureIndex(changes)
+		for _, change := range changes {
+			if isSignatureChange(change) {
+				continue
+			} else if v := verifyChange(change, signatures[change.Hash()], keys); v.Status != SignatureStatusValid {
+				return errors.Errorf("change %s is %s", v.Hash[:8], v.Status)
+			}
+		}
+		return nil
+	}
+}
+
+// validateKeyEmail returns the lower-case email of the author which is used as the name of their key files.
+func validateKeyEmail(author string) (string, error) {
+	_, email, err := ParseAuthor(author)
+	if err != nil {
+		return "", err
+	}
+	email = strings.ToLower(email)
+	if strings.ContainsAny(email, `/\`) || strings.HasPrefix(email, ".") {
+		return "", errors.Errorf("email '%s' cannot be used as a key file name", email)
+	}
+	return email, nil
+}
+
+func (d *directoryStorage) keyPath(email string, ext string) string {
+	return filepath.Join(d.Path, "keys", email+ext)
+}
+
+// signingKey returns the private key of the author or nil if they have not generated one.
+func (d *directoryStorage) signingKey(author string) (ed25519.PrivateKey, error) {
+	email, err := validateKeyEmail(author)
+	if err != nil {
+		return nil, nil
+	}
+	raw, err := os.ReadFile(d.keyPath(email, ".key"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, errors.Wrap(err, "failed to read signing key")
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(raw)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, errors.Errorf("signing key of '%s' is corrupt", email)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// GenerateSigningKey creates a new key pair that signs all future changes by the author and trusts its public key. Any
+// previous public key stays trusted so that older changes still verify.
+func (d *directoryStorage) GenerateSigningKey(ctx context.Context, author string) (ed25519.PublicKey, error) {
+	email, err := validateKeyEmail(author)
+	if err != nil {
+		return nil, err
+	}
+	public, private, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to generate key")
+	}
+	if err := d.TrustPublicKey(ctx, author, public); err != nil {
+		return nil, err
+	}
+	path := d.keyPath(email, ".key")
+	tempPath := path + ".temp"
+	if err := os.WriteFile(tempPath, []byte(base64.StdEncoding.EncodeToString(private.Seed())), os.FileMode(0600)); err != nil {
+		return nil, errors.Wrap(err, "failed to write signing key")
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		return nil, errors.Wrap(err, "failed to move signing key to target")
+	}
+	return public, nil
+}
+
+// TrustPublicKey adds the public key to the trusted keys of the author.
+func (d *directoryStorage) TrustPublicKey(ctx context.Context, author string, key ed25519.PublicKey) error {
+	email, err := validateKeyEmail(author)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Join(d.Path, "keys"), os.FileMode(0700)); err != nil {
+		return errors.Wrap(err, "failed to create keys directory")
+	}
+	path := d.keyPath(email, ".pub")
+	raw, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return errors.Wrap(err, "failed to read public keys")
+	}
+	encoded := EncodePublicKey(key)
+	if slices.Contains(strings.Fields(string(raw)), encoded) {
+		return nil
+	}
+	raw = append(raw, []byte(encoded+"\n")...)
+	tempPath := path + ".temp"
+	if err := os.WriteFile(tempPath, raw, os.FileMode(0644)); err != nil {
+		return errors.Wrap(err, "failed to write public keys")
+	}
+	if err := os.Rename(tempPath, path); err != nil {
+		return errors.Wrap(err, "failed to move public keys to target")
+	}
+	return nil
+}
+
+// ListPublicKeys returns all the trusted public keys in the storage directory.
+func (d *directoryStorage) ListPublicKeys(ctx context.Context) (AuthorKeys, error) {
+	output := make(AuthorKeys)
+	entries, err := os.ReadDir(filepath.Join(d.Path, "keys"))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return output, nil
+		}
+		return nil, errors.Wrap(err, "failed to list keys")
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".pub" {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(d.Path, "keys", entry.Name()))
+		if err != nil {
+			return nil, errors.Wrap(err, "failed to read public keys")
+		}
+		email := strings.TrimSuffix(entry.Name(), ".pub")
+		for _, line := range bytes.Fields(raw) {
+			if key, err := ParsePublicKey(string(line)); err == nil {
+				output[email] = append(output[email], key)
+			}
+		}
+	}
+	return output, nil
+}
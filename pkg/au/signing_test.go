@@ -0,0 +1,157 @@
+package au
+
+import (
+	"context"
+	"crypto/ed25519"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/automerge/automerge-go"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aurelian-one/au/internal"
+)
+
+func TestSignedChanges(t *testing.T) {
+	ws := newTestWorkspace(t)
+	doc := ws.Doc
+	alice, bob := "Alice <alice@me.com>", "Bob <bob@me.com>"
+	public, private, _ := ed25519.GenerateKey(nil)
+	ws.SigningKeys = func(author string) (ed25519.PrivateKey, error) {
+		if author == alice {
+			return private, nil
+		}
+		return nil, nil
+	}
+	ctx := context.Background()
+
+	td, err := ws.CreateTodo(ctx, CreateTodoParams{Title: "Example", CreatedBy: alice})
+	assert.NoError(t, err)
+	_, err = ws.EditTodo(ctx, td.Id, EditTodoParams{Title: internal.Ref("Edited"), UpdatedBy: bob})
+	assert.NoError(t, err)
+
+	// the signature is not part of the history of the todo
+	history, err := ws.GetTodoHistory(ctx, td.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, alice+" created todo "+td.Id, history[0].Message)
+	assert.Equal(t, alice, history[0].Author)
+
+	keys := AuthorKeys{"alice@me.com": {public}}
+	verifications, err := VerifyChanges(doc, keys)
+	assert.NoError(t, err)
+	statuses := make([]string, len(verifications))
+	for i, v := range verifications {
+		statuses[i] = v.Status
+	}
+	assert.Equal(t, []string{SignatureStatusGenesis, SignatureStatusValid, SignatureStatusUnsigned}, statuses)
+	assert.Equal(t, EncodePublicKey(public), verifications[1].PublicKey)
+	assert.Equal(t, alice+" created todo "+td.Id, verifications[1].Message)
+
+	verifications, err = VerifyChanges(doc, AuthorKeys{"bob@me.com": {public}})
+	assert.NoError(t, err)
+	assert.Equal(t, SignatureStatusUntrusted, verifications[1].Status)
+
+	t.Run("a copied signature does not verify for a different change", func(t *testing.T) {
+		changes, _ := doc.Changes()
+		_, signature, _ := strings.Cut(strings.TrimPrefix(changes[2].Message(), signaturePrefix), " ")
+		forged, err := doc.Fork()
+		assert.NoError(t, err)
+		assert.NoError(t, forged.Path("todos", td.Id, "status").Set("closed"))
+		forgedHash, err := forged.Commit(changes[1].Message())
+		assert.NoError(t, err)
+		_, err = forged.Commit(signaturePrefix+forgedHash.String()+" "+signature, automerge.CommitOptions{AllowEmpty: true})
+		assert.NoError(t, err)
+		verifications, err := VerifyChanges(forged, keys)
+		assert.NoError(t, err)
+		assert.Equal(t, alice, verifications[3].Author)
+		assert.Equal(t, SignatureStatusInvalid, verifications[3].Status)
+
+		forgedChanges, _ := forged.Changes(doc.Heads()...)
+		assert.EqualError(t, RequireSignatures(keys)(forged, forgedChanges), "change "+forgedHash.String()[:8]+" is invalid")
+	})
+
+	t.Run("a signature change cannot modify the document", func(t *testing.T) {
+		changes, _ := doc.Changes()
+		forged, err := doc.Fork()
+		assert.NoError(t, err)
+		assert.NoError(t, forged.Path("todos", td.Id, "status").Set("closed"))
+		_, err = forged.Commit(changes[2].Message())
+		assert.NoError(t, err)
+		verifications, err := VerifyChanges(forged, keys)
+		assert.NoError(t, err)
+		if assert.Len(t, verifications, 4) {
+			assert.Equal(t, SignatureStatusUnsigned, verifications[3].Status)
+		}
+	})
+}
+
+func TestVerifyChanges_fresh_workspace(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ctx := context.Background()
+	alice := "Alice <alice@me.com>"
+	_, err := s.GenerateSigningKey(ctx, alice)
+	assert.NoError(t, err)
+	keys, err := s.ListPublicKeys(ctx)
+	assert.NoError(t, err)
+
+	for _, createdBy := range []string{"", alice} {
+		meta, err := s.CreateWorkspace(ctx, CreateWorkspaceParams{Alias: "fresh", CreatedBy: createdBy})
+		assert.NoError(t, err)
+		ws, err := s.OpenWorkspace(ctx, meta.Id, true)
+		assert.NoError(t, err)
+		verifications, err := VerifyChanges(ws.(DocProvider).GetDoc(), keys)
+		assert.NoError(t, err)
+		if assert.Len(t, verifications, 1) {
+			assert.Equal(t, createdBy, verifications[0].Author)
+			if createdBy == "" {
+				assert.Equal(t, SignatureStatusGenesis, verifications[0].Status)
+			} else {
+				assert.Equal(t, SignatureStatusValid, verifications[0].Status)
+			}
+		}
+		assert.NoError(t, ws.Close())
+	}
+}
+
+func TestDirectoryStorage_keys(t *testing.T) {
+	td, err := os.MkdirTemp(os.TempDir(), "au")
+	assert.NoError(t, err)
+	defer os.RemoveAll(td)
+	s, _ := NewDirectoryStorage(td)
+	ctx := context.Background()
+
+	keys, err := s.ListPublicKeys(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, keys)
+
+	first, err := s.GenerateSigningKey(ctx, "Alice <Alice@me.com>")
+	assert.NoError(t, err)
+	second, err := s.GenerateSigningKey(ctx, "Alice <alice@me.com>")
+	assert.NoError(t, err)
+	stat, err := os.Stat(filepath.Join(td, "keys", "alice@me.com.key"))
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0600), stat.Mode().Perm())
+	assert.NoError(t, s.TrustPublicKey(ctx, "Bob <bob@me.com>", first))
+	assert.NoError(t, s.TrustPublicKey(ctx, "Bob <bob@me.com>", first))
+	_, err = s.GenerateSigningKey(ctx, "Evil <../evil@me.com>")
+	assert.EqualError(t, err, "email '../evil@me.com' cannot be used as a key file name")
+
+	keys, err = s.ListPublicKeys(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, AuthorKeys{"alice@me.com": {first, second}, "bob@me.com": {first}}, keys)
+
+	// changes are signed with the latest key of the author
+	wsMeta, err := s.CreateWorkspace(ctx, CreateWorkspaceParams{Alias: "example"})
+	assert.NoError(t, err)
+	ws, err := s.OpenWorkspace(ctx, wsMeta.Id, true)
+	assert.NoError(t, err)
+	defer ws.Close()
+	_, err = ws.CreateTodo(ctx, CreateTodoParams{Title: "Example", CreatedBy: "Alice <alice@me.com>"})
+	assert.NoError(t, err)
+	verifications, err := VerifyChanges(ws.(DocProvider).GetDoc(), keys)
+	assert.NoError(t, err)
+	assert.Equal(t, SignatureStatusValid, verifications[1].Status)
+	assert.Equal(t, EncodePublicKey(second), verifications[1].PublicKey)
+}
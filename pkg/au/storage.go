@@ -2,6 +2,7 @@ package au
 
 import (
 	"context"
+	"crypto/ed25519"
 	"time"
 
 	"github.com/automerge/automerge-go"
@@ -19,6 +20,15 @@ type StorageProvider interface {
 	SetCurrentAuthor(ctx context.Context, author string) error
 
 	OpenWorkspace(ctx context.Context, id string, writeable bool) (WorkspaceProvider, error)
+	OpenWorkspaceAt(ctx context.Context, id string, heads []automerge.ChangeHash) (WorkspaceProvider, error)
+
+	PutBlob(ctx context.Context, data []byte) (string, error)
+	StatBlob(ctx context.Context, hash string) (int64, error)
+	GetBlob(ctx context.Context, hash string) ([]byte, error)
+
+	GenerateSigningKey(ctx context.Context, author string) (ed25519.PublicKey, error)
+	TrustPublicKey(ctx context.Context, author string, key ed25519.PublicKey) error
+	ListPublicKeys(ctx context.Context) (AuthorKeys, error)
 }
 
 type DocProvider interface {
@@ -35,6 +45,8 @@ type WorkspaceMeta struct {
 
 type CreateWorkspaceParams struct {
 	Alias string
+	// CreatedBy is the optional 'Name <email>' author of the workspace, whose key signs the change that creates it.
+	CreatedBy string
 }
 
 type WorkspaceProvider interface {
@@ -44,13 +56,66 @@ type WorkspaceProvider interface {
 	GetTodo(ctx context.Context, id string) (*Todo, error)
 	CreateTodo(ctx context.Context, params CreateTodoParams) (*Todo, error)
 	EditTodo(ctx context.Context, id string, params EditTodoParams) (*Todo, error)
+	BulkEditTodos(ctx context.Context, ids []string, params EditTodoParams) ([]Todo, error)
+	CheckBulkEditTodos(ctx context.Context, ids []string, params EditTodoParams) error
 	DeleteTodo(ctx context.Context, id string, params DeleteTodoParams) error
+	ImportTodo(ctx context.Context, params ImportTodoParams) (*Todo, error)
+	GetTodoHistory(ctx context.Context, id string) ([]HistoryEntry, error)
+	ListTodoConflicts(ctx context.Context, id string) ([]TodoConflict, error)
+	ResolveTodoConflict(ctx context.Context, id string, params ResolveTodoConflictParams) (*Todo, error)
+	ListTodoChildren(ctx context.Context, id string) ([]Todo, error)
+	ListTodoAncestors(ctx context.Context, id string) ([]Todo, error)
+	ListTodoBlockers(ctx context.Context, id string) ([]Todo, error)
 
 	ListComments(ctx context.Context, todoId string) ([]Comment, error)
 	GetComment(ctx context.Context, todoId, commentId string) (*Comment, error)
 	CreateComment(ctx context.Context, todoId string, params CreateCommentParams) (*Comment, error)
 	EditComment(ctx context.Context, todoId, commentId string, params EditCommentParams) (*Comment, error)
 	DeleteComment(ctx context.Context, todoId, commentId string, params DeleteCommentParams) error
+	GetCommentHistory(ctx context.Context, todoId, commentId string) ([]HistoryEntry, error)
+	ListAttachments(ctx context.Context) ([]Attachment, error)
+
+	AddChecklistItem(ctx context.Context, todoId string, params AddChecklistItemParams) (*ChecklistItem, error)
+	EditChecklistItem(ctx context.Context, todoId, itemId string, params EditChecklistItemParams) (*ChecklistItem, error)
+	DeleteChecklistItem(ctx context.Context, todoId, itemId string, params DeleteChecklistItemParams) error
+
+	ListTimeEntries(ctx context.Context, todoId string) ([]TimeEntry, error)
+	StartTimer(ctx context.Context, todoId string, params StartTimerParams) error
+	StopTimer(ctx context.Context, todoId string, params StopTimerParams) (*TimeEntry, error)
+	LogTime(ctx context.Context, todoId string, params LogTimeParams) (*TimeEntry, error)
+
+	MoveTodo(ctx context.Context, id string, params MoveTodoParams) (*Todo, error)
+	RebalanceRanks(ctx context.Context, params RebalanceRanksParams) (int, error)
+
+	AddTodoLabels(ctx context.Context, id string, labels []string, updatedBy string) (*Todo, error)
+	RemoveTodoLabels(ctx context.Context, id string, labels []string, updatedBy string) (*Todo, error)
+	ListLabels(ctx context.Context) (map[string]int, error)
+	RenameLabel(ctx context.Context, params RenameLabelParams) (int, error)
+	AssignTodo(ctx context.Context, id string, assignees []string, updatedBy string) (*Todo, error)
+	UnassignTodo(ctx context.Context, id string, assignees []string, updatedBy string) (*Todo, error)
+	ListAuthors(ctx context.Context) ([]string, error)
+	ListMentions(ctx context.Context, author string, since time.Time) ([]Mention, error)
+
+	ListWorkflowStatuses(ctx context.Context) ([]WorkflowStatus, error)
+	SetWorkflowStatus(ctx context.Context, params SetWorkflowStatusParams) (*WorkflowStatus, error)
+	DeleteWorkflowStatus(ctx context.Context, name string, params DeleteWorkflowStatusParams) error
+
+	ListTodoTemplates(ctx context.Context) ([]TodoTemplate, error)
+	GetTodoTemplate(ctx context.Context, name string) (*TodoTemplate, error)
+	AddTodoTemplate(ctx context.Context, params AddTodoTemplateParams) (*TodoTemplate, error)
+	EditTodoTemplate(ctx context.Context, name string, params EditTodoTemplateParams) (*TodoTemplate, error)
+	DeleteTodoTemplate(ctx context.Context, name string, params DeleteTodoTemplateParams) error
+
+	ListAnnotationSchemas(ctx context.Context) ([]AnnotationSchema, error)
+	SetAnnotationSchema(ctx context.Context, params SetAnnotationSchemaParams) (*AnnotationSchema, error)
+	DeleteAnnotationSchema(ctx context.Context, key string, params DeleteAnnotationSchemaParams) error
+
+	ListMembers(ctx context.Context) ([]Member, error)
+	AddMember(ctx context.Context, params AddMemberParams) (*Member, error)
+	MergeMembers(ctx context.Context, params MergeMembersParams) (*Member, error)
+
+	RevertChange(ctx context.Context, hash string, params RevertChangeParams) (*HistoryEntry, error)
+	LatestChangeByAuthor(ctx context.Context, author string) (string, error)
 
 	Flush() error
 	Close() error
@@ -63,6 +128,10 @@ type Todo struct {
 	UpdatedAt    *time.Time
 	UpdatedBy    *string
 	CommentCount int
+	Checklist    []ChecklistItem
+	// TimeSpent is the total of the time entries of the todo, and Timers are the running timers by author.
+	TimeSpent time.Duration
+	Timers    map[string]time.Time
 
 	Title       string
 	Description string
@@ -91,18 +160,27 @@ type DeleteTodoParams struct {
 }
 
 type Comment struct {
-	Id        string
-	CreatedAt time.Time
-	CreatedBy string
-	UpdatedAt *time.Time
-	UpdatedBy *string
-	MediaType string
-	Content   []byte
+	Id         string
+	CreatedAt  time.Time
+	CreatedBy  string
+	UpdatedAt  *time.Time
+	UpdatedBy  *string
+	MediaType  string
+	Content    []byte
+	Attachment *Attachment
+	// ReplyTo is the id of the comment this comment replies to. It may reference a comment which no longer exists if
+	// the parent was deleted concurrently, in which case the comment should be treated as a top level comment.
+	ReplyTo *string
 }
 
 type CreateCommentParams struct {
 	MediaType string
 	Content   []byte
+	// Attachment optionally references a blob which must already be stored with StorageProvider.PutBlob. The content
+	// may be empty when there is an attachment.
+	Attachment *Attachment
+	// ReplyTo optionally references an existing comment in the same todo to start or continue a thread.
+	ReplyTo   string
 	CreatedBy string
 }
 
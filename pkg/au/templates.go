@@ -0,0 +1,315 @@
+package au
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+const MaximumTemplateNameLength = 50
+
+// TodoTemplate is a workspace-defined blueprint for todos that are created repeatedly. The title, description, and
+// annotation values may contain {{variable}} placeholders which are replaced when a todo is created from the template.
+type TodoTemplate struct {
+	Name        string
+	Title       string
+	Description string
+	// Status is the status of todos created from the template. When empty the default status of the workspace is used.
+	Status      string
+	Annotations map[string]string
+}
+
+type AddTodoTemplateParams struct {
+	Name        string
+	Title       string
+	Description string
+	Status      string
+	Annotations map[string]string
+	CreatedBy   string
+}
+
+type EditTodoTemplateParams struct {
+	Title       *string
+	Description *string
+	Status      *string
+	// Annotations are merged into the existing annotations of the template. An empty value removes the annotation.
+	Annotations map[string]string
+	UpdatedBy   string
+}
+
+type DeleteTodoTemplateParams struct {
+	DeletedBy string
+}
+
+var validTemplateNamePattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+var templateVariablePattern = regexp.MustCompile(`\{\{\s*([a-z][a-z0-9_]*)\s*}}`)
+
+func ValidateTodoTemplateName(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if !validTemplateNamePattern.MatchString(input) {
+		return "", errors.Errorf("template '%s' must be lowercase letters, digits, and dashes", input)
+	} else if d := MaximumTemplateNameLength; len(input) > d {
+		return "", errors.Errorf("template name is too long, it should be at most %d characters", d)
+	}
+	return input, nil
+}
+
+// TemplateVariables returns the sorted names of the variables used by the template.
+func TemplateVariables(t *TodoTemplate) []string {
+	output := make([]string, 0)
+	texts := []string{t.Title, t.Description}
+	for _, v := range t.Annotations {
+		texts = append(texts, v)
+	}
+	for _, text := range texts {
+		for _, m := range templateVariablePattern.FindAllStringSubmatch(text, -1) {
+			if !slices.Contains(output, m[1]) {
+				output = append(output, m[1])
+			}
+		}
+	}
+	slices.Sort(output)
+	return output
+}
+
+// RenderTodoTemplate returns the params for creating a todo from the template. Every variable used by the template
+// must be provided and every provided variable must be used. The result is validated when the todo is created.
+func RenderTodoTemplate(t *TodoTemplate, vars map[string]string) (CreateTodoParams, error) {
+	used := TemplateVariables(t)
+	for _, name := range used {
+		if _, ok := vars[name]; !ok {
+			return CreateTodoParams{}, errors.Errorf("template variable '%s' is not set", name)
+		}
+	}
+	for name := range vars {
+		if !slices.Contains(used, name) {
+			return CreateTodoParams{}, errors.Errorf("template '%s' has no variable '%s', expected one of [%s]", t.Name, name, strings.Join(used, ", "))
+		}
+	}
+	render := func(text string) string {
+		return templateVariablePattern.ReplaceAllStringFunc(text, func(m string) string {
+			return vars[templateVariablePattern.FindStringSubmatch(m)[1]]
+		})
+	}
+	output := CreateTodoParams{
+		Title:       render(t.Title),
+		Description: render(t.Description),
+		Annotations: make(map[string]string, len(t.Annotations)),
+	}
+	if t.Status != "" {
+		output.Status = &t.Status
+	}
+	for k, v := range t.Annotations {
+		output.Annotations[k] = render(v)
+	}
+	return output, nil
+}
+
+// validateTemplateInner checks the template content with the same validators as todos. Annotation values containing
+// variables can only be checked once they are rendered.
+func validateTemplateInner(doc *automerge.Doc, t *TodoTemplate) error {
+	var err error
+	if t.Title, err = ValidateTodoTitle(t.Title); err != nil {
+		return errors.Wrap(err, "invalid template title")
+	} else if t.Description, err = ValidateTodoDescription(t.Description); err != nil {
+		return errors.Wrap(err, "invalid template description")
+	}
+	if t.Status = strings.TrimSpace(t.Status); t.Status != "" {
+		if _, _, err := resolveStatusInner(doc, nil, t.Status); err != nil {
+			return errors.Wrap(err, "invalid template status")
+		}
+	}
+	rendered := make(map[string]string, len(t.Annotations))
+	for k, v := range t.Annotations {
+		if err := ValidateTodoAnnotationKey(k); err != nil {
+			return errors.Wrapf(err, "invalid annotation key '%s'", k)
+		} else if v == "" {
+			return errors.Errorf("annotation '%s' has an empty value", k)
+		} else if templateVariablePattern.MatchString(v) {
+			continue
+		} else if err := ValidateTodoAnnotationValue(k, v); err != nil {
+			return errors.Wrapf(err, "invalid annotation value for '%s'", k)
+		}
+		rendered[k] = v
+	}
+	return validateDeclaredAnnotationsInner(doc, rendered)
+}
+
+func (p *inMemoryWorkspaceProvider) ListTodoTemplates(ctx context.Context) ([]TodoTemplate, error) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	output := make([]TodoTemplate, 0)
+	templates, err := settingsMapInner(p.Doc, "templates")
+	if err != nil {
+		return output, nil
+	}
+	names, _ := templates.Keys()
+	slices.Sort(names)
+	for _, name := range names {
+		if t, err := getTemplateInner(templates, name); err == nil {
+			output = append(output, *t)
+		}
+	}
+	return output, nil
+}
+
+func (p *inMemoryWorkspaceProvider) GetTodoTemplate(ctx context.Context, name string) (*TodoTemplate, error) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	templates, err := settingsMapInner(p.Doc, "templates")
+	if err != nil {
+		return nil, errors.Errorf("template '%s' does not exist", name)
+	}
+	return getTemplateInner(templates, name)
+}
+
+func (p *inMemoryWorkspaceProvider) AddTodoTemplate(ctx context.Context, params AddTodoTemplateParams) (*TodoTemplate, error) {
+	if err := ValidatedAuthor(params.CreatedBy); err != nil {
+		return nil, err
+	}
+	name, err := ValidateTodoTemplateName(params.Name)
+	if err != nil {
+		return nil, err
+	}
+	t := &TodoTemplate{Name: name, Title: params.Title, Description: params.Description, Status: params.Status, Annotations: params.Annotations}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	if err := validateTemplateInner(p.Doc, t); err != nil {
+		return nil, err
+	}
+	templates, err := settingsMapInner(p.Doc, "templates")
+	if err != nil {
+		return nil, err
+	} else if v, _ := templates.Get(name); v.Kind() != automerge.KindVoid {
+		return nil, errors.Errorf("template '%s' already exists", name)
+	}
+	if err := setTemplateInner(templates, t); err != nil {
+		return nil, err
+	}
+	if _, err := p.commit(params.CreatedBy + " added template " + name); err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
+	return getTemplateInner(templates, name)
+}
+
+func (p *inMemoryWorkspaceProvider) EditTodoTemplate(ctx context.Context, name string, params EditTodoTemplateParams) (*TodoTemplate, error) {
+	if err := ValidatedAuthor(params.UpdatedBy); err != nil {
+		return nil, err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	templates, err := settingsMapInner(p.Doc, "templates")
+	if err != nil {
+		return nil, errors.Errorf("template '%s' does not exist", name)
+	}
+	t, err := getTemplateInner(templates, name)
+	if err != nil {
+		return nil, err
+	}
+	if params.Title != nil {
+		t.Title = *params.Title
+	}
+	if params.Description != nil {
+		t.Description = *params.Description
+	}
+	if params.Status != nil {
+		t.Status = *params.Status
+	}
+	for k, v := range params.Annotations {
+		if v == "" {
+			delete(t.Annotations, k)
+		} else {
+			t.Annotations[k] = v
+		}
+	}
+	if err := validateTemplateInner(p.Doc, t); err != nil {
+		return nil, err
+	} else if err := setTemplateInner(templates, t); err != nil {
+		return nil, err
+	}
+	if _, err := p.commit(params.UpdatedBy + " edited template " + name); err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
+	return getTemplateInner(templates, name)
+}
+
+func (p *inMemoryWorkspaceProvider) DeleteTodoTemplate(ctx context.Context, name string, params DeleteTodoTemplateParams) error {
+	if err := ValidatedAuthor(params.DeletedBy); err != nil {
+		return err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	templates, err := settingsMapInner(p.Doc, "templates")
+	if err != nil {
+		return errors.Errorf("template '%s' does not exist", name)
+	} else if v, _ := templates.Get(name); v.Kind() != automerge.KindMap {
+		return errors.Errorf("template '%s' does not exist", name)
+	} else if err := templates.Delete(name); err != nil {
+		return errors.Wrap(err, "failed to delete template")
+	}
+	if _, err := p.commit(params.DeletedBy + " deleted template " + name); err != nil {
+		return errors.Wrap(err, "failed to commit")
+	}
+	return nil
+}
+
+// setTemplateInner replaces the whole template entry so that removed annotations do not linger.
+func setTemplateInner(templates *automerge.Map, t *TodoTemplate) error {
+	newTemplate, annotations := automerge.NewMap(), automerge.NewMap()
+	if err := templates.Set(t.Name, newTemplate); err != nil {
+		return errors.Wrap(err, "failed to set template entry")
+	} else if err := newTemplate.Set("title", t.Title); err != nil {
+		return errors.Wrap(err, "failed to set title")
+	} else if err := newTemplate.Set("description", t.Description); err != nil {
+		return errors.Wrap(err, "failed to set description")
+	} else if err := newTemplate.Set("annotations", annotations); err != nil {
+		return errors.Wrap(err, "failed to set annotations")
+	}
+	if t.Status != "" {
+		if err := newTemplate.Set("status", t.Status); err != nil {
+			return errors.Wrap(err, "failed to set status")
+		}
+	}
+	for k, v := range t.Annotations {
+		if err := annotations.Set(k, v); err != nil {
+			return errors.Wrap(err, "failed to set annotation")
+		}
+	}
+	return nil
+}
+
+func getTemplateInner(templates *automerge.Map, name string) (*TodoTemplate, error) {
+	item, _ := templates.Get(name)
+	if item.Kind() != automerge.KindMap {
+		return nil, errors.Errorf("template '%s' does not exist", name)
+	}
+	output := &TodoTemplate{Name: name, Annotations: make(map[string]string)}
+	if v, _ := item.Map().Get("title"); v.Kind() == automerge.KindStr {
+		output.Title = v.Str()
+	}
+	if v, _ := item.Map().Get("description"); v.Kind() == automerge.KindStr {
+		output.Description = v.Str()
+	}
+	if v, _ := item.Map().Get("status"); v.Kind() == automerge.KindStr {
+		output.Status = v.Str()
+	}
+	if v, _ := item.Map().Get("annotations"); v.Kind() == automerge.KindMap {
+		keys, _ := v.Map().Keys()
+		for _, k := range keys {
+			if av, _ := v.Map().Get(k); av.Kind() == automerge.KindStr {
+				output.Annotations[k] = av.Str()
+			}
+		}
+	}
+	return output, nil
+}
@@ -0,0 +1,83 @@
+package au
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aurelian-one/au/internal"
+)
+
+func TestRenderTodoTemplate(t *testing.T) {
+	tmpl := &TodoTemplate{
+		Name:        "release",
+		Title:       "Release {{ version }}",
+		Description: "Ship {{version}} to {{env}}",
+		Status:      "closed",
+		Annotations: map[string]string{LabelAnnotationKey("release"): "true", "https://example.com/env": "{{env}}"},
+	}
+	assert.Equal(t, []string{"env", "version"}, TemplateVariables(tmpl))
+
+	params, err := RenderTodoTemplate(tmpl, map[string]string{"version": "1.2", "env": "prod"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Release 1.2", params.Title)
+	assert.Equal(t, "Ship 1.2 to prod", params.Description)
+	assert.Equal(t, internal.Ref("closed"), params.Status)
+	assert.Equal(t, map[string]string{LabelAnnotationKey("release"): "true", "https://example.com/env": "prod"}, params.Annotations)
+
+	_, err = RenderTodoTemplate(tmpl, map[string]string{"version": "1.2"})
+	assert.EqualError(t, err, "template variable 'env' is not set")
+	_, err = RenderTodoTemplate(tmpl, map[string]string{"version": "1.2", "env": "prod", "other": "x"})
+	assert.EqualError(t, err, "template 'release' has no variable 'other', expected one of [env, version]")
+}
+
+func TestTodoTemplates(t *testing.T) {
+	wsp := newTestWorkspace(t)
+	author := "Example <email@me.com>"
+	ctx := context.Background()
+
+	templates, err := wsp.ListTodoTemplates(ctx)
+	assert.NoError(t, err)
+	assert.Empty(t, templates)
+
+	_, err = wsp.AddTodoTemplate(ctx, AddTodoTemplateParams{Name: "Bad Name", Title: "x", CreatedBy: author})
+	assert.EqualError(t, err, "template 'Bad Name' must be lowercase letters, digits, and dashes")
+	_, err = wsp.AddTodoTemplate(ctx, AddTodoTemplateParams{Name: "bug", Title: "  ", CreatedBy: author})
+	assert.EqualError(t, err, "invalid template title: title is too short, it should be at least 3 characters")
+	_, err = wsp.AddTodoTemplate(ctx, AddTodoTemplateParams{Name: "bug", Title: "Bug", Status: "nope", CreatedBy: author})
+	assert.ErrorContains(t, err, "invalid template status")
+	_, err = wsp.AddTodoTemplate(ctx, AddTodoTemplateParams{Name: "bug", Title: "Bug", Annotations: map[string]string{"bad key": "x"}, CreatedBy: author})
+	assert.ErrorContains(t, err, "invalid annotation key 'bad key'")
+
+	tmpl, err := wsp.AddTodoTemplate(ctx, AddTodoTemplateParams{
+		Name: "bug", Title: "Bug: {{summary}}", Annotations: map[string]string{LabelAnnotationKey("bug"): "true"}, CreatedBy: author,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, &TodoTemplate{Name: "bug", Title: "Bug: {{summary}}", Annotations: map[string]string{LabelAnnotationKey("bug"): "true"}}, tmpl)
+	_, err = wsp.AddTodoTemplate(ctx, AddTodoTemplateParams{Name: "bug", Title: "Bug", CreatedBy: author})
+	assert.EqualError(t, err, "template 'bug' already exists")
+
+	tmpl, err = wsp.EditTodoTemplate(ctx, "bug", EditTodoTemplateParams{
+		Description: internal.Ref("Found in {{version}}"), Status: internal.Ref("closed"),
+		Annotations: map[string]string{LabelAnnotationKey("bug"): "", LabelAnnotationKey("triage"): "true"}, UpdatedBy: author,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "Found in {{version}}", tmpl.Description)
+	assert.Equal(t, "closed", tmpl.Status)
+	assert.Equal(t, map[string]string{LabelAnnotationKey("triage"): "true"}, tmpl.Annotations)
+	if h := wsp.GetDoc().Heads(); assert.Len(t, h, 1) {
+		change, _ := wsp.GetDoc().Change(h[0])
+		assert.Equal(t, author+" edited template bug", change.Message())
+	}
+
+	_, err = wsp.EditTodoTemplate(ctx, "nope", EditTodoTemplateParams{UpdatedBy: author})
+	assert.EqualError(t, err, "template 'nope' does not exist")
+
+	templates, err = wsp.ListTodoTemplates(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []TodoTemplate{*tmpl}, templates)
+
+	assert.NoError(t, wsp.DeleteTodoTemplate(ctx, "bug", DeleteTodoTemplateParams{DeletedBy: author}))
+	assert.EqualError(t, wsp.DeleteTodoTemplate(ctx, "bug", DeleteTodoTemplateParams{DeletedBy: author}), "template 'bug' does not exist")
+}
@@ -0,0 +1,312 @@
+package au
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/automerge/automerge-go"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+)
+
+// MaximumTimeEntryDuration bounds a single time entry so that a forgotten timer does not log days of work.
+const MaximumTimeEntryDuration = 24 * time.Hour
+
+// TimeEntry is an amount of time that an author spent on a todo. Entries are kept in a map keyed by id so that entries
+// logged concurrently by different peers all survive a merge.
+type TimeEntry struct {
+	Id        string
+	Author    string
+	StartedAt time.Time
+	Duration  time.Duration
+}
+
+type StartTimerParams struct {
+	StartedBy string
+}
+
+type StopTimerParams struct {
+	StoppedBy string
+}
+
+type LogTimeParams struct {
+	Duration time.Duration
+	// StartedAt is when the work started, it defaults to the duration before now.
+	StartedAt time.Time
+	LoggedBy  string
+}
+
+// TimeTotals sums the duration of the entries by author.
+func TimeTotals(entries []TimeEntry) map[string]time.Duration {
+	output := make(map[string]time.Duration)
+	for _, e := range entries {
+		output[e.Author] += e.Duration
+	}
+	return output
+}
+
+func (p *inMemoryWorkspaceProvider) ListTimeEntries(ctx context.Context, todoId string) ([]TimeEntry, error) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	todoValue, err := p.Doc.Path("todos").Map().Get(todoId)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get todo")
+	} else if todoValue.Kind() != automerge.KindMap {
+		return nil, errors.Errorf("todo with id '%s' does not exist", todoId)
+	}
+	return timeEntriesInner(todoValue.Map()), nil
+}
+
+// StartTimer records that the author started working on the todo. Each author may have one running timer per todo.
+func (p *inMemoryWorkspaceProvider) StartTimer(ctx context.Context, todoId string, params StartTimerParams) error {
+	if err := ValidatedAuthor(params.StartedBy); err != nil {
+		return err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	todoValue, err := p.Doc.Path("todos").Map().Get(todoId)
+	if err != nil {
+		return errors.Wrap(err, "failed to get todo")
+	} else if todoValue.Kind() != automerge.KindMap {
+		return errors.Errorf("todo with id '%s' does not exist", todoId)
+	}
+	if _, ok := timersInner(todoValue.Map())[params.StartedBy]; ok {
+		return errors.Errorf("a timer is already running on todo '%s'", todoId)
+	}
+	timers, err := timeTrackingMapInner(todoValue.Map(), todoId, "timers")
+	if err != nil {
+		return err
+	}
+	if err := timers.Set(params.StartedBy, time.Now().UTC().Truncate(time.Second)); err != nil {
+		return errors.Wrap(err, "failed to set timer")
+	}
+
+	if _, err := p.commit(params.StartedBy + " tracked time on todo " + todoId); err != nil {
+		return errors.Wrap(err, "failed to commit")
+	}
+	return nil
+}
+
+// StopTimer stops the running timer of the author and logs the elapsed time as an entry.
+func (p *inMemoryWorkspaceProvider) StopTimer(ctx context.Context, todoId string, params StopTimerParams) (*TimeEntry, error) {
+	if err := ValidatedAuthor(params.StoppedBy); err != nil {
+		return nil, err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	todoValue, err := p.Doc.Path("todos").Map().Get(todoId)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to get todo")
+	} else if todoValue.Kind() != automerge.KindMap {
+		return nil, errors.Errorf("todo with id '%s' does not exist", todoId)
+	}
+	startedAt, ok := timersInner(todoValue.Map())[params.StoppedBy]
+	if !ok {
+		return nil, errors.Errorf("no timer is running on todo '%s'", todoId)
+	}
+	timers, err := timeTrackingMapInner(todoValue.Map(), todoId, "timers")
+	if err != nil {
+		return nil, err
+	} else if err := timers.Delete(params.StoppedBy); err != nil {
+		return nil, errors.Wrap(err, "failed to delete timer")
+	}
+	duration := min(time.Now().UTC().Truncate(time.Second).Sub(startedAt), MaximumTimeEntryDuration)
+	entry, err := addTimeEntryInner(p.Doc, todoId, params.StoppedBy, startedAt, duration)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.commit(params.StoppedBy + " tracked time on todo " + todoId); err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
+	return entry, nil
+}
+
+func (p *inMemoryWorkspaceProvider) LogTime(ctx context.Context, todoId string, params LogTimeParams) (*TimeEntry, error) {
+	if err := ValidatedAuthor(params.LoggedBy); err != nil {
+		return nil, err
+	}
+	duration := params.Duration.Truncate(time.Second)
+	if duration <= 0 {
+		return nil, errors.New("duration must be at least 1 second")
+	} else if d := MaximumTimeEntryDuration; duration > d {
+		return nil, errors.Errorf("duration must be at most %s", d)
+	}
+	startedAt := params.StartedAt
+	if startedAt.IsZero() {
+		startedAt = time.Now().Add(-duration)
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	if v, err := p.Doc.Path("todos").Map().Get(todoId); err != nil {
+		return nil, errors.Wrap(err, "failed to get todo")
+	} else if v.Kind() != automerge.KindMap {
+		return nil, errors.Errorf("todo with id '%s' does not exist", todoId)
+	}
+	entry, err := addTimeEntryInner(p.Doc, todoId, params.LoggedBy, startedAt.UTC().Truncate(time.Second), duration)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := p.commit(params.LoggedBy + " tracked time on todo " + todoId); err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
+	return entry, nil
+}
+
+// initTimeTrackingInner creates the time_spent counter and the time_entries and timers maps of a todo if they do not
+// exist yet, and returns whether anything was created. They are created along with the todo, or when an older workspace
+// is next opened for writing, because when two peers each create the same container concurrently, only one of them
+// survives the merge along with its contents.
+func initTimeTrackingInner(todo *automerge.Map) (bool, error) {
+	changed := false
+	if v, _ := todo.Get("time_spent"); v.Kind() != automerge.KindCounter {
+		if err := todo.Set("time_spent", automerge.NewCounter(0)); err != nil {
+			return false, errors.Wrap(err, "failed to set time_spent")
+		}
+		changed = true
+	}
+	for _, name := range []string{"time_entries", "timers"} {
+		if v, _ := todo.Get(name); v.Kind() != automerge.KindMap {
+			if err := todo.Set(name, automerge.NewMap()); err != nil {
+				return false, errors.Wrapf(err, "failed to set %s", name)
+			}
+			changed = true
+		}
+	}
+	return changed, nil
+}
+
+// migrateTimeTrackingInner creates the time tracking containers of the todos created by older versions without
+// committing them. It returns whether anything was created.
+func migrateTimeTrackingInner(doc *automerge.Doc) (bool, error) {
+	todos, _ := doc.Path("todos").Get()
+	if todos.Kind() != automerge.KindMap {
+		return false, nil
+	}
+	keys, _ := todos.Map().Keys()
+	changed := false
+	for _, key := range keys {
+		if v, _ := todos.Map().Get(key); v.Kind() == automerge.KindMap {
+			c, err := initTimeTrackingInner(v.Map())
+			if err != nil {
+				return false, errors.Wrapf(err, "failed to migrate todo '%s'", key)
+			}
+			changed = changed || c
+		}
+	}
+	return changed, nil
+}
+
+// timeTrackingMapInner returns the named time tracking map of the todo.
+func timeTrackingMapInner(todo *automerge.Map, todoId, name string) (*automerge.Map, error) {
+	if v, _ := todo.Get(name); v.Kind() == automerge.KindMap {
+		return v.Map(), nil
+	}
+	return nil, errors.Errorf("todo '%s' has no %s, the workspace must be opened for writing to migrate it", todoId, name)
+}
+
+// addTimeEntryInner stores the entry and adds its duration to the time_spent counter of the todo. The counter is
+// incremented rather than overwritten so that time logged concurrently on different peers adds up after a merge.
+func addTimeEntryInner(doc *automerge.Doc, todoId, author string, startedAt time.Time, duration time.Duration) (*TimeEntry, error) {
+	entry := &TimeEntry{Id: ulid.Make().String(), Author: author, StartedAt: startedAt, Duration: duration}
+	todo := doc.Path("todos", todoId).Map()
+	entries, err := timeTrackingMapInner(todo, todoId, "time_entries")
+	if err != nil {
+		return nil, err
+	}
+	timeSpent, _ := todo.Get("time_spent")
+	if timeSpent.Kind() != automerge.KindCounter {
+		return nil, errors.Errorf("todo '%s' has no time_spent, the workspace must be opened for writing to migrate it", todoId)
+	}
+	if err := setTimeEntryInner(entries, *entry); err != nil {
+		return nil, err
+	}
+	if err := timeSpent.Counter().Inc(int64(duration / time.Second)); err != nil {
+		return nil, errors.Wrap(err, "failed to increment time spent")
+	}
+	return entry, nil
+}
+
+func setTimeEntryInner(entries *automerge.Map, entry TimeEntry) error {
+	newEntry := automerge.NewMap()
+	if err := entries.Set(entry.Id, newEntry); err != nil {
+		return errors.Wrap(err, "failed to set time entry")
+	} else if err := newEntry.Set("author", entry.Author); err != nil {
+		return errors.Wrap(err, "failed to set author")
+	} else if err := newEntry.Set("started_at", entry.StartedAt); err != nil {
+		return errors.Wrap(err, "failed to set started_at")
+	} else if err := newEntry.Set("seconds", int64(entry.Duration/time.Second)); err != nil {
+		return errors.Wrap(err, "failed to set seconds")
+	}
+	return nil
+}
+
+// timeEntriesInner returns the time entries of the todo ordered by start time.
+func timeEntriesInner(todo *automerge.Map) []TimeEntry {
+	output := make([]TimeEntry, 0)
+	entriesValue, _ := todo.Get("time_entries")
+	if entriesValue.Kind() != automerge.KindMap {
+		return output
+	}
+	keys, _ := entriesValue.Map().Keys()
+	for _, key := range keys {
+		v, _ := entriesValue.Map().Get(key)
+		if v.Kind() != automerge.KindMap {
+			continue
+		}
+		entry := TimeEntry{Id: key}
+		if a, _ := v.Map().Get("author"); a.Kind() == automerge.KindStr {
+			entry.Author = a.Str()
+		}
+		if s, _ := v.Map().Get("started_at"); s.Kind() == automerge.KindTime {
+			entry.StartedAt = s.Time().In(time.UTC)
+		}
+		if s, _ := v.Map().Get("seconds"); s.Kind() == automerge.KindInt64 {
+			entry.Duration = time.Duration(s.Int64()) * time.Second
+		}
+		output = append(output, entry)
+	}
+	slices.SortFunc(output, func(a, b TimeEntry) int {
+		if c := a.StartedAt.Compare(b.StartedAt); c != 0 {
+			return c
+		}
+		return strings.Compare(a.Id, b.Id)
+	})
+	return output
+}
+
+// timersInner returns the start time of the running timers of the todo by author.
+func timersInner(todo *automerge.Map) map[string]time.Time {
+	output := make(map[string]time.Time)
+	timersValue, _ := todo.Get("timers")
+	if timersValue.Kind() != automerge.KindMap {
+		return output
+	}
+	keys, _ := timersValue.Map().Keys()
+	for _, key := range keys {
+		if v, _ := timersValue.Map().Get(key); v.Kind() == automerge.KindTime {
+			output[key] = v.Time().In(time.UTC)
+		}
+	}
+	return output
+}
+
+// timeSpentInner returns the value of the time_spent counter of the todo.
+func timeSpentInner(todo *automerge.Map) time.Duration {
+	if v, _ := todo.Get("time_spent"); v.Kind() == automerge.KindCounter {
+		if seconds, err := v.Counter().Get(); err == nil {
+			return time.Duration(seconds) * time.Second
+		}
+	}
+	return 0
+}
@@ -0,0 +1,63 @@
+package au
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimeTracking(t *testing.T) {
+	wsA := newTestWorkspace(t)
+	doc := wsA.Doc
+	alice, bob := "Alice <alice@me.com>", "Bob <bob@me.com>"
+	ctx := context.Background()
+
+	td, err := wsA.CreateTodo(ctx, CreateTodoParams{Title: "Write report", CreatedBy: alice})
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), td.TimeSpent)
+
+	_, err = wsA.LogTime(ctx, td.Id, LogTimeParams{Duration: 0, LoggedBy: alice})
+	assert.EqualError(t, err, "duration must be at least 1 second")
+	_, err = wsA.LogTime(ctx, td.Id, LogTimeParams{Duration: 25 * time.Hour, LoggedBy: alice})
+	assert.EqualError(t, err, "duration must be at most 24h0m0s")
+
+	// time logged concurrently on two peers adds up after merging
+	forked, err := doc.Fork()
+	assert.NoError(t, err)
+	wsB := NewInMemoryWorkspaceProvider(forked)
+	at := time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC)
+	a, err := wsA.LogTime(ctx, td.Id, LogTimeParams{Duration: 30 * time.Minute, StartedAt: at, LoggedBy: alice})
+	assert.NoError(t, err)
+	b, err := wsB.LogTime(ctx, td.Id, LogTimeParams{Duration: 45 * time.Minute, StartedAt: at.Add(time.Hour), LoggedBy: bob})
+	assert.NoError(t, err)
+	_, err = doc.Merge(forked)
+	assert.NoError(t, err)
+
+	td, _ = wsA.GetTodo(ctx, td.Id)
+	assert.Equal(t, 75*time.Minute, td.TimeSpent)
+	entries, err := wsA.ListTimeEntries(ctx, td.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, []TimeEntry{*a, *b}, entries)
+	assert.Equal(t, map[string]time.Duration{alice: 30 * time.Minute, bob: 45 * time.Minute}, TimeTotals(entries))
+
+	assert.NoError(t, wsA.StartTimer(ctx, td.Id, StartTimerParams{StartedBy: alice}))
+	assert.EqualError(t, wsA.StartTimer(ctx, td.Id, StartTimerParams{StartedBy: alice}), "a timer is already running on todo '"+td.Id+"'")
+	td, _ = wsA.GetTodo(ctx, td.Id)
+	assert.Contains(t, td.Timers, alice)
+	_, err = wsA.StopTimer(ctx, td.Id, StopTimerParams{StoppedBy: bob})
+	assert.EqualError(t, err, "no timer is running on todo '"+td.Id+"'")
+	entry, err := wsA.StopTimer(ctx, td.Id, StopTimerParams{StoppedBy: alice})
+	assert.NoError(t, err)
+	assert.Equal(t, alice, entry.Author)
+	td, _ = wsA.GetTodo(ctx, td.Id)
+	assert.Empty(t, td.Timers)
+	entries, _ = wsA.ListTimeEntries(ctx, td.Id)
+	assert.Len(t, entries, 3)
+
+	if h := wsA.GetDoc().Heads(); assert.Len(t, h, 1) {
+		change, _ := wsA.GetDoc().Change(h[0])
+		assert.Equal(t, alice+" tracked time on todo "+td.Id, change.Message())
+	}
+}
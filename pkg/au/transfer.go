@@ -0,0 +1,233 @@
+package au
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/automerge/automerge-go"
+	"github.com/oklog/ulid/v2"
+	"github.com/pkg/errors"
+)
+
+// ImportTodoParams describes a todo read from another workspace to recreate in this one.
+type ImportTodoParams struct {
+	// Todo is the source todo as returned by GetTodo, including its checklist.
+	Todo Todo
+	// Comments are the comments of the source todo as returned by ListComments.
+	Comments []Comment
+	// TimeEntries are the time entries of the source todo as returned by ListTimeEntries.
+	TimeEntries       []TimeEntry
+	SourceWorkspaceId string
+	// KeepId imports the todo with the same id as the source todo rather than a new one. This is used when moving a
+	// todo so that it keeps its identity.
+	KeepId     bool
+	ImportedBy string
+}
+
+// FormatTodoOrigin returns the value of the origin annotation for a todo copied from the given workspace.
+func FormatTodoOrigin(workspaceId, todoId string) string {
+	return workspaceId + "/" + todoId
+}
+
+// ParseTodoOrigin returns the workspace and todo id from the value of an origin annotation.
+func ParseTodoOrigin(value string) (string, string, error) {
+	workspaceId, todoId, ok := strings.Cut(value, "/")
+	if !ok {
+		return "", "", errors.Errorf("origin '%s' must be <workspace-id>/<todo-id>", value)
+	} else if _, err := ulid.ParseStrict(workspaceId); err != nil {
+		return "", "", errors.Errorf("origin '%s' has an invalid workspace id", value)
+	} else if _, err := ulid.ParseStrict(todoId); err != nil {
+		return "", "", errors.Errorf("origin '%s' has an invalid todo id", value)
+	}
+	return workspaceId, todoId, nil
+}
+
+// ImportTodo recreates a todo from another workspace, keeping its title, description, annotations,
+// checklist, and comments along with their original created and updated metadata. An origin annotation links back to
+// the source todo. Annotations that reference other todos or the workflow configuration of the source workspace are
+// dropped, and the status is mapped onto a status with the same category if the workflow status is not known here. The
+// annotations must be valid for the annotation schemas of this workspace.
+func (p *inMemoryWorkspaceProvider) ImportTodo(ctx context.Context, params ImportTodoParams) (*Todo, error) {
+	src := params.Todo
+	if err := ValidatedAuthor(params.ImportedBy); err != nil {
+		return nil, err
+	} else if err := ValidatedAuthor(src.CreatedBy); err != nil {
+		return nil, errors.Wrap(err, "invalid created by")
+	}
+	title, err := ValidateTodoTitle(src.Title)
+	if err != nil {
+		return nil, err
+	}
+	description, err := ValidateTodoDescription(src.Description)
+	if err != nil {
+		return nil, err
+	}
+	annotations := make(map[string]string, len(src.Annotations)+1)
+	for k, v := range src.Annotations {
+		if k == AurelianParentAnnotation || k == AurelianPreviousAnnotation || k == AurelianNextAnnotation ||
+			k == AurelianWorkflowStatusAnnotation || strings.HasPrefix(k, AurelianBlockedByAnnotation+"#") {
+			continue
+		} else if err := ValidateTodoAnnotationKey(k); err != nil {
+			return nil, errors.Wrapf(err, "invalid annotation key '%s'", k)
+		} else if v == "" {
+			continue
+		} else if err := ValidateTodoAnnotationValue(k, v); err != nil {
+			return nil, errors.Wrapf(err, "invalid annotation value for '%s'", k)
+		}
+		annotations[k] = v
+	}
+	annotations[AurelianOriginAnnotation] = FormatTodoOrigin(params.SourceWorkspaceId, src.Id)
+	if err := ValidateTodoAnnotationValue(AurelianOriginAnnotation, annotations[AurelianOriginAnnotation]); err != nil {
+		return nil, err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	todos := p.Doc.Path("todos").Map()
+	status, workflowStatus := importStatusInner(p.Doc, &src)
+	if workflowStatus != "" {
+		annotations[AurelianWorkflowStatusAnnotation] = workflowStatus
+	}
+	// the annotations were only checked against the schemas of the source workspace
+	if err := validateDeclaredAnnotationsInner(p.Doc, annotations); err != nil {
+		return nil, err
+	}
+	todoId := ulid.Make().String()
+	if params.KeepId {
+		todoId = src.Id
+		if v, _ := todos.Get(todoId); v.Kind() != automerge.KindVoid {
+			return nil, errors.Errorf("todo with id '%s' already exists", todoId)
+		}
+	}
+
+	newTodo := automerge.NewMap()
+	if err := todos.Set(todoId, newTodo); err != nil {
+		return nil, errors.Wrap(err, "failed to set todo entry")
+	}
+	if err := newTodo.Set("status", status); err != nil {
+		return nil, errors.Wrap(err, "failed to set status")
+	} else if err := newTodo.Set("created_at", src.CreatedAt); err != nil {
+		return nil, errors.Wrap(err, "failed to set created_at")
+	} else if err := newTodo.Set("created_by", src.CreatedBy); err != nil {
+		return nil, errors.Wrap(err, "failed to set created_by")
+	} else if err := newTodo.Set("title", automerge.NewText(title)); err != nil {
+		return nil, errors.Wrap(err, "failed to set title")
+	} else if err := newTodo.Set("description", automerge.NewText(description)); err != nil {
+		return nil, errors.Wrap(err, "failed to set description")
+	} else if _, err := initTimeTrackingInner(newTodo); err != nil {
+		return nil, err
+	}
+	if src.UpdatedAt != nil && src.UpdatedBy != nil {
+		if err := newTodo.Set("updated_at", *src.UpdatedAt); err != nil {
+			return nil, errors.Wrap(err, "failed to set updated_at")
+		} else if err := newTodo.Set("updated_by", *src.UpdatedBy); err != nil {
+			return nil, errors.Wrap(err, "failed to set updated_by")
+		}
+	}
+
+	newAnnotations := automerge.NewMap()
+	_ = newTodo.Set("annotations", newAnnotations)
+	for k, v := range annotations {
+		_ = newAnnotations.Set(k, v)
+	}
+
+	if len(src.Checklist) > 0 {
+		newChecklist := automerge.NewMap()
+		_ = newTodo.Set("checklist", newChecklist)
+		for _, item := range src.Checklist {
+			newItem := automerge.NewMap()
+			if err := newChecklist.Set(item.Id, newItem); err != nil {
+				return nil, errors.Wrap(err, "failed to set checklist item")
+			} else if err := newItem.Set("text", item.Text); err != nil {
+				return nil, errors.Wrap(err, "failed to set checklist item text")
+			} else if err := newItem.Set("done", item.Done); err != nil {
+				return nil, errors.Wrap(err, "failed to set checklist item done")
+			} else if err := newItem.Set("position", item.Position); err != nil {
+				return nil, errors.Wrap(err, "failed to set checklist item position")
+			}
+		}
+	}
+
+	newComments := automerge.NewMap()
+	_ = newTodo.Set("comments", newComments)
+	for _, c := range params.Comments {
+		if err := importCommentInner(newComments, c); err != nil {
+			return nil, errors.Wrapf(err, "failed to import comment '%s'", c.Id)
+		}
+	}
+
+	if len(params.TimeEntries) > 0 {
+		newEntries := p.Doc.Path("todos", todoId, "time_entries").Map()
+		var total time.Duration
+		for _, e := range params.TimeEntries {
+			if _, err := ulid.ParseStrict(e.Id); err != nil {
+				return nil, errors.Errorf("failed to import time entry '%s': invalid id", e.Id)
+			} else if err := setTimeEntryInner(newEntries, e); err != nil {
+				return nil, errors.Wrapf(err, "failed to import time entry '%s'", e.Id)
+			}
+			total += e.Duration
+		}
+		if err := p.Doc.Path("todos", todoId, "time_spent").Counter().Inc(int64(total / time.Second)); err != nil {
+			return nil, errors.Wrap(err, "failed to increment time spent")
+		}
+	}
+
+	if _, err := p.commit(params.ImportedBy + " imported todo " + todoId); err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
+	return getTodoInner(todos, todoId)
+}
+
+// importStatusInner keeps the workflow status of the source todo if it exists here, otherwise it falls back to the
+// first workflow status with the same category, or just the category if there are no workflow statuses.
+func importStatusInner(doc *automerge.Doc, src *Todo) (string, string) {
+	if category, workflowStatus, err := resolveStatusInner(doc, nil, TodoWorkflowStatus(src, listWorkflowStatusesInner(doc))); err == nil {
+		return category, workflowStatus
+	}
+	for _, s := range listWorkflowStatusesInner(doc) {
+		if s.Category == src.Status {
+			return s.Category, s.Name
+		}
+	}
+	return src.Status, ""
+}
+
+func importCommentInner(comments *automerge.Map, c Comment) error {
+	if _, err := ulid.ParseStrict(c.Id); err != nil {
+		return errors.New("invalid id")
+	} else if err := ValidatedAuthor(c.CreatedBy); err != nil {
+		return err
+	}
+	newComment := automerge.NewMap()
+	if err := comments.Set(c.Id, newComment); err != nil {
+		return errors.Wrap(err, "failed to set comment")
+	} else if err := newComment.Set("created_at", c.CreatedAt); err != nil {
+		return errors.Wrap(err, "failed to set created_at")
+	} else if err := newComment.Set("created_by", c.CreatedBy); err != nil {
+		return errors.Wrap(err, "failed to set created_by")
+	} else if err := newComment.Set("media_type", c.MediaType); err != nil {
+		return errors.Wrap(err, "failed to set media type")
+	} else if err := newComment.Set("content", c.Content); err != nil {
+		return errors.Wrap(err, "failed to set content")
+	}
+	if c.UpdatedAt != nil && c.UpdatedBy != nil {
+		if err := newComment.Set("updated_at", *c.UpdatedAt); err != nil {
+			return errors.Wrap(err, "failed to set updated_at")
+		} else if err := newComment.Set("updated_by", *c.UpdatedBy); err != nil {
+			return errors.Wrap(err, "failed to set updated_by")
+		}
+	}
+	if c.ReplyTo != nil {
+		if err := newComment.Set("reply_to", *c.ReplyTo); err != nil {
+			return errors.Wrap(err, "failed to set reply_to")
+		}
+	}
+	if c.Attachment != nil {
+		if err := setAttachmentInner(newComment, *c.Attachment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
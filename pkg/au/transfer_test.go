@@ -0,0 +1,102 @@
+package au
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/oklog/ulid/v2"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aurelian-one/au/internal"
+)
+
+func TestParseTodoOrigin(t *testing.T) {
+	w, td := ulid.Make().String(), ulid.Make().String()
+	a, b, err := ParseTodoOrigin(FormatTodoOrigin(w, td))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{w, td}, []string{a, b})
+
+	_, _, err = ParseTodoOrigin("nope")
+	assert.EqualError(t, err, "origin 'nope' must be <workspace-id>/<todo-id>")
+	_, _, err = ParseTodoOrigin(w + "/nope")
+	assert.EqualError(t, err, "origin '"+w+"/nope' has an invalid todo id")
+}
+
+func TestImportTodo(t *testing.T) {
+	src, dest := newTestWorkspace(t), newTestWorkspace(t)
+	alice, bob := "Alice <alice@me.com>", "Bob <bob@me.com>"
+	ctx := context.Background()
+	srcId := ulid.Make().String()
+
+	parent, err := src.CreateTodo(ctx, CreateTodoParams{Title: "Parent", CreatedBy: alice})
+	assert.NoError(t, err)
+	td, err := src.CreateTodo(ctx, CreateTodoParams{Title: "Child", Description: "Details", CreatedBy: alice, Annotations: map[string]string{
+		AurelianParentAnnotation:     parent.Id,
+		LabelAnnotationKey("urgent"): "true",
+		"https://example.com/thing":  "value",
+	}})
+	assert.NoError(t, err)
+	td, err = src.EditTodo(ctx, td.Id, EditTodoParams{Status: internal.Ref("closed"), UpdatedBy: bob})
+	assert.NoError(t, err)
+	_, err = src.AddChecklistItem(ctx, td.Id, AddChecklistItemParams{Text: "Step", CreatedBy: alice})
+	assert.NoError(t, err)
+	root, err := src.CreateComment(ctx, td.Id, CreateCommentParams{MediaType: DefaultCommentMediaType, Content: []byte("root"), CreatedBy: bob})
+	assert.NoError(t, err)
+	reply, err := src.CreateComment(ctx, td.Id, CreateCommentParams{MediaType: DefaultCommentMediaType, Content: []byte("reply"), ReplyTo: root.Id, CreatedBy: alice})
+	assert.NoError(t, err)
+
+	_, err = src.LogTime(ctx, td.Id, LogTimeParams{Duration: time.Hour, LoggedBy: alice})
+	assert.NoError(t, err)
+
+	td, _ = src.GetTodo(ctx, td.Id)
+	// the links to other todos of the source workspace, such as those of recurring todos, are dropped
+	td.Annotations[AurelianPreviousAnnotation] = ulid.Make().String()
+	td.Annotations[AurelianNextAnnotation] = ulid.Make().String()
+	td.Annotations[AurelianBlockedByAnnotation+"#"+parent.Id] = "true"
+	comments, _ := src.ListComments(ctx, td.Id)
+	entries, _ := src.ListTimeEntries(ctx, td.Id)
+	imported, err := dest.ImportTodo(ctx, ImportTodoParams{Todo: *td, Comments: comments, TimeEntries: entries, SourceWorkspaceId: srcId, ImportedBy: bob})
+	assert.NoError(t, err)
+	assert.NotEqual(t, td.Id, imported.Id)
+	assert.Equal(t, td.CreatedAt, imported.CreatedAt)
+	assert.Equal(t, alice, imported.CreatedBy)
+	assert.Equal(t, td.UpdatedBy, imported.UpdatedBy)
+	assert.Equal(t, "closed", imported.Status)
+	assert.Equal(t, "Details", imported.Description)
+	assert.Equal(t, td.Checklist, imported.Checklist)
+	assert.Equal(t, time.Hour, imported.TimeSpent)
+	assert.Equal(t, map[string]string{
+		LabelAnnotationKey("urgent"): "true",
+		"https://example.com/thing":  "value",
+		AurelianOriginAnnotation:     srcId + "/" + td.Id,
+	}, imported.Annotations)
+
+	importedComments, err := dest.ListComments(ctx, imported.Id)
+	assert.NoError(t, err)
+	assert.ElementsMatch(t, comments, importedComments)
+	importedEntries, err := dest.ListTimeEntries(ctx, imported.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, entries, importedEntries)
+	c, err := dest.GetComment(ctx, imported.Id, reply.Id)
+	assert.NoError(t, err)
+	assert.Equal(t, &root.Id, c.ReplyTo)
+
+	// the annotations must also be valid for the schemas of the destination
+	_, err = dest.SetAnnotationSchema(ctx, SetAnnotationSchemaParams{Key: "https://example.com/thing", Type: AnnotationTypeInteger, UpdatedBy: bob})
+	assert.NoError(t, err)
+	_, err = dest.ImportTodo(ctx, ImportTodoParams{Todo: *td, SourceWorkspaceId: srcId, ImportedBy: bob})
+	assert.EqualError(t, err, "invalid annotation value for 'https://example.com/thing': 'value' is not an integer")
+	assert.NoError(t, dest.DeleteAnnotationSchema(ctx, "https://example.com/thing", DeleteAnnotationSchemaParams{DeletedBy: bob}))
+
+	moved, err := dest.ImportTodo(ctx, ImportTodoParams{Todo: *td, SourceWorkspaceId: srcId, KeepId: true, ImportedBy: bob})
+	assert.NoError(t, err)
+	assert.Equal(t, td.Id, moved.Id)
+	_, err = dest.ImportTodo(ctx, ImportTodoParams{Todo: *td, SourceWorkspaceId: srcId, KeepId: true, ImportedBy: bob})
+	assert.EqualError(t, err, "todo with id '"+td.Id+"' already exists")
+
+	if h := dest.GetDoc().Heads(); assert.Len(t, h, 1) {
+		change, _ := dest.GetDoc().Change(h[0])
+		assert.Equal(t, bob+" imported todo "+moved.Id, change.Message())
+	}
+}
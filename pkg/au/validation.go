@@ -5,6 +5,7 @@ import (
 	"regexp"
 	"strings"
 
+	"github.com/oklog/ulid/v2"
 	"github.com/pkg/errors"
 )
 
@@ -12,6 +13,7 @@ const MinimumTodoTitleLength = 3
 const MaximumTodoTitleLength = 200
 const MaximumDescriptionLength = 5000
 const DefaultCommentMediaType = "text/markdown"
+const MaximumStatusReasonLength = 200
 
 func ValidateWorkspaceAlias(input string) (string, error) {
 	if pa, err := ValidateAndCleanUnicode(input, false); err != nil {
@@ -86,11 +88,17 @@ func ValidateTodoAnnotationKey(key string) error {
 
 		// extra validation for known keys
 		switch parts[2] {
-		case "label":
+		case "label", "blocked-by":
 			if u.Fragment == "" {
 				return errors.Errorf("'%s' '%s' annotation requires a valid fragment", u.Hostname(), parts[2])
 			}
-		case "rank":
+		case "assignee":
+			if u.Fragment == "" {
+				return errors.Errorf("'%s' '%s' annotation requires a valid fragment", u.Hostname(), parts[2])
+			} else if err := ValidatedAuthor(u.Fragment); err != nil {
+				return errors.Wrapf(err, "'%s' '%s' annotation fragment is not valid", u.Hostname(), parts[2])
+			}
+		case "rank", "parent", "due", "start", "hide-until", "workflow-status", "status-reason", "origin", "recurrence", "previous", "next":
 			if u.RawFragment != "" || u.Fragment != "" {
 				return errors.Errorf("'%s '%s' annotation cannot have a fragment", u.Hostname(), parts[2])
 			}
@@ -104,6 +112,50 @@ func ValidateTodoAnnotationKey(key string) error {
 	return nil
 }
 
+// ValidateTodoAnnotationValue checks the value of annotations with a reserved key or a registered validator. The key
+// must already be valid. Any other annotations are not understood by this client and are left as they are.
+func ValidateTodoAnnotationValue(key, value string) error {
+	switch key {
+	case AurelianRankAnnotation:
+		if _, err := ParseRank(value); err != nil {
+			return err
+		}
+	case AurelianDueAnnotation, AurelianStartAnnotation, AurelianHideUntilAnnotation:
+		if _, err := ParseTodoTime(value); err != nil {
+			return err
+		}
+	case AurelianWorkflowStatusAnnotation:
+		return errors.New("the workflow status is set through the todo status")
+	case AurelianOriginAnnotation:
+		if _, _, err := ParseTodoOrigin(value); err != nil {
+			return err
+		}
+	case AurelianRecurrenceAnnotation:
+		if _, err := ParseRecurrence(value); err != nil {
+			return err
+		}
+	case AurelianPreviousAnnotation:
+		if _, err := ulid.ParseStrict(value); err != nil {
+			return errors.Errorf("previous todo '%s' must be a todo id", value)
+		}
+	case AurelianNextAnnotation:
+		if _, err := ulid.ParseStrict(value); err != nil {
+			return errors.Errorf("next todo '%s' must be a todo id", value)
+		}
+	case AurelianStatusReasonAnnotation:
+		if _, err := ValidateAndCleanUnicode(value, false); err != nil {
+			return errors.Wrap(err, "invalid status reason")
+		} else if d := MaximumStatusReasonLength; len(value) > d {
+			return errors.Errorf("status reason is too long, it should be at most %d characters", d)
+		}
+	default:
+		if v := registeredAnnotationValidator(key); v != nil {
+			return v(value)
+		}
+	}
+	return nil
+}
+
 var validAuthorPattern = regexp.MustCompile(`^\S+( \S+)* <\S+@\S+>$`)
 
 func ValidatedAuthor(input string) error {
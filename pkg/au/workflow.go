@@ -0,0 +1,260 @@
+package au
+
+import (
+	"context"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/automerge/automerge-go"
+	"github.com/pkg/errors"
+)
+
+const MaximumWorkflowStatusLength = 50
+
+// WorkflowStatus is a workspace-defined status. Todos in this status store its Category ("open" or "closed") in the
+// spec-defined status field so that clients that do not understand workflows can still interpret them.
+type WorkflowStatus struct {
+	Name     string
+	Category string
+	// Transitions lists the statuses that a todo in this status may move to. An empty list allows any transition.
+	Transitions []string
+	Default     bool
+}
+
+type SetWorkflowStatusParams struct {
+	Name        string
+	Category    string
+	Transitions []string
+	// Default makes this the status of new todos that are created without an explicit status.
+	Default   bool
+	UpdatedBy string
+}
+
+type DeleteWorkflowStatusParams struct {
+	DeletedBy string
+}
+
+var validWorkflowStatusPattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+func ValidateWorkflowStatusName(input string) (string, error) {
+	input = strings.TrimSpace(input)
+	if !validWorkflowStatusPattern.MatchString(input) {
+		return "", errors.Errorf("status '%s' must be lowercase letters, digits, and dashes", input)
+	} else if d := MaximumWorkflowStatusLength; len(input) > d {
+		return "", errors.Errorf("status is too long, it should be at most %d characters", d)
+	}
+	return input, nil
+}
+
+// TodoWorkflowStatus returns the workflow status of the todo given the workflow statuses of the workspace, falling back
+// to the open or closed status when the todo has no workflow status. The workflow status is also ignored when it is no
+// longer defined or when its category does not match the status of the todo, which happens when a client that does
+// not understand workflows changes the status.
+func TodoWorkflowStatus(todo *Todo, statuses []WorkflowStatus) string {
+	if v := todo.Annotations[AurelianWorkflowStatusAnnotation]; v != "" {
+		if slices.ContainsFunc(statuses, func(s WorkflowStatus) bool { return s.Name == v && s.Category == todo.Status }) {
+			return v
+		}
+	}
+	return todo.Status
+}
+
+func (p *inMemoryWorkspaceProvider) ListWorkflowStatuses(ctx context.Context) ([]WorkflowStatus, error) {
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+	return listWorkflowStatusesInner(p.Doc), nil
+}
+
+// SetWorkflowStatus creates or replaces a workflow status. Transitions must refer to statuses that already exist or to
+// the status itself.
+func (p *inMemoryWorkspaceProvider) SetWorkflowStatus(ctx context.Context, params SetWorkflowStatusParams) (*WorkflowStatus, error) {
+	if err := ValidatedAuthor(params.UpdatedBy); err != nil {
+		return nil, err
+	}
+	name, err := ValidateWorkflowStatusName(params.Name)
+	if err != nil {
+		return nil, err
+	}
+	category, err := ValidateTodoStatus(params.Category)
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid category")
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	existing := listWorkflowStatusesInner(p.Doc)
+	targets := make([]string, len(params.Transitions))
+	for i, t := range params.Transitions {
+		if targets[i], err = ValidateWorkflowStatusName(t); err != nil {
+			return nil, errors.Wrap(err, "invalid transition")
+		} else if targets[i] != name && !slices.ContainsFunc(existing, func(s WorkflowStatus) bool { return s.Name == targets[i] }) {
+			return nil, errors.Errorf("transition target status '%s' does not exist", targets[i])
+		}
+	}
+
+	statuses, err := settingsMapInner(p.Doc, "statuses")
+	if err != nil {
+		return nil, err
+	}
+	newStatus, transitions := automerge.NewMap(), automerge.NewMap()
+	if err := statuses.Set(name, newStatus); err != nil {
+		return nil, errors.Wrap(err, "failed to set status entry")
+	} else if err := newStatus.Set("category", category); err != nil {
+		return nil, errors.Wrap(err, "failed to set category")
+	} else if err := newStatus.Set("transitions", transitions); err != nil {
+		return nil, errors.Wrap(err, "failed to set transitions")
+	}
+	for _, t := range targets {
+		if err := transitions.Set(t, true); err != nil {
+			return nil, errors.Wrap(err, "failed to set transition")
+		}
+	}
+	if params.Default {
+		if err := p.Doc.Path("settings", "default_status").Set(name); err != nil {
+			return nil, errors.Wrap(err, "failed to set default status")
+		}
+	}
+
+	if _, err := p.commit(params.UpdatedBy + " set status " + name); err != nil {
+		return nil, errors.Wrap(err, "failed to commit")
+	}
+	for _, s := range listWorkflowStatusesInner(p.Doc) {
+		if s.Name == name {
+			return &s, nil
+		}
+	}
+	return nil, errors.Errorf("status '%s' does not exist", name)
+}
+
+// DeleteWorkflowStatus removes the status along with any transitions to it. Todos in the deleted status keep their
+// category but are no longer restricted by the workflow until their status is next changed. A status cannot be deleted
+// while it is the only transition of another status, since an empty list of transitions would allow any transition.
+func (p *inMemoryWorkspaceProvider) DeleteWorkflowStatus(ctx context.Context, name string, params DeleteWorkflowStatusParams) error {
+	if err := ValidatedAuthor(params.DeletedBy); err != nil {
+		return err
+	}
+
+	p.Lock.Lock()
+	defer p.Lock.Unlock()
+
+	statuses, err := settingsMapInner(p.Doc, "statuses")
+	if err != nil {
+		return errors.Errorf("status '%s' does not exist", name)
+	} else if v, _ := statuses.Get(name); v.Kind() != automerge.KindMap {
+		return errors.Errorf("status '%s' does not exist", name)
+	}
+	for _, other := range listWorkflowStatusesInner(p.Doc) {
+		if other.Name != name && len(other.Transitions) > 0 && !slices.ContainsFunc(other.Transitions, func(t string) bool { return t != name }) {
+			return errors.Errorf("cannot delete status '%s' since it is the only transition of status '%s'", name, other.Name)
+		}
+	}
+	if err := statuses.Delete(name); err != nil {
+		return errors.Wrap(err, "failed to delete status")
+	}
+	names, _ := statuses.Keys()
+	for _, other := range names {
+		v, _ := statuses.Get(other)
+		if v.Kind() != automerge.KindMap {
+			continue
+		}
+		if t, _ := v.Map().Get("transitions"); t.Kind() == automerge.KindMap {
+			if x, _ := t.Map().Get(name); x.Kind() != automerge.KindVoid {
+				if err := t.Map().Delete(name); err != nil {
+					return errors.Wrap(err, "failed to delete transition")
+				}
+			}
+		}
+	}
+	if v, _ := p.Doc.Path("settings", "default_status").Get(); v.Kind() == automerge.KindStr && v.Str() == name {
+		if err := p.Doc.Path("settings").Map().Delete("default_status"); err != nil {
+			return errors.Wrap(err, "failed to delete default status")
+		}
+	}
+
+	if _, err := p.commit(params.DeletedBy + " deleted status " + name); err != nil {
+		return errors.Wrap(err, "failed to commit")
+	}
+	return nil
+}
+
+// listWorkflowStatusesInner returns the valid workflow statuses sorted by name. Entries with an unknown category are
+// ignored.
+func listWorkflowStatusesInner(doc *automerge.Doc) []WorkflowStatus {
+	output := make([]WorkflowStatus, 0)
+	statuses, err := settingsMapInner(doc, "statuses")
+	if err != nil {
+		return output
+	}
+	defaultStatus := ""
+	if v, _ := doc.Path("settings", "default_status").Get(); v.Kind() == automerge.KindStr {
+		defaultStatus = v.Str()
+	}
+	names, _ := statuses.Keys()
+	for _, name := range names {
+		v, _ := statuses.Get(name)
+		if v.Kind() != automerge.KindMap {
+			continue
+		}
+		s := WorkflowStatus{Name: name, Transitions: make([]string, 0), Default: name == defaultStatus}
+		if c, _ := v.Map().Get("category"); c.Kind() == automerge.KindStr {
+			s.Category = c.Str()
+		}
+		if _, err := ValidateTodoStatus(s.Category); err != nil {
+			continue
+		}
+		if t, _ := v.Map().Get("transitions"); t.Kind() == automerge.KindMap {
+			s.Transitions, _ = t.Map().Keys()
+		}
+		slices.Sort(s.Transitions)
+		output = append(output, s)
+	}
+	slices.SortFunc(output, func(a, b WorkflowStatus) int {
+		return strings.Compare(a.Name, b.Name)
+	})
+	return output
+}
+
+// resolveStatusInner maps the requested status onto its open or closed category and workflow status name. Without any
+// workflow statuses, only open and closed are accepted and the workflow name is empty. When current is not nil, the
+// transition from the current workflow status of the todo must be allowed.
+func resolveStatusInner(doc *automerge.Doc, current *Todo, input string) (string, string, error) {
+	statuses := listWorkflowStatusesInner(doc)
+	if len(statuses) == 0 {
+		category, err := ValidateTodoStatus(input)
+		return category, "", err
+	}
+	i := slices.IndexFunc(statuses, func(s WorkflowStatus) bool { return s.Name == input })
+	if i < 0 {
+		names := make([]string, len(statuses))
+		for j, s := range statuses {
+			names[j] = s.Name
+		}
+		return "", "", errors.Errorf("status must be one of %s", strings.Join(names, ", "))
+	}
+	target := statuses[i]
+	if current != nil {
+		from := TodoWorkflowStatus(current, statuses)
+		if j := slices.IndexFunc(statuses, func(s WorkflowStatus) bool { return s.Name == from }); j >= 0 && from != target.Name {
+			if t := statuses[j].Transitions; len(t) > 0 && !slices.Contains(t, target.Name) {
+				return "", "", errors.Errorf("cannot change status of todo '%s' from '%s' to '%s', allowed transitions are %s", current.Id, from, target.Name, strings.Join(t, ", "))
+			}
+		}
+	}
+	return target.Category, target.Name, nil
+}
+
+// defaultStatusInner returns the status for new todos that are created without one. This is the default workflow
+// status if there is one, otherwise the "open" status, or the first status in the open category.
+func defaultStatusInner(doc *automerge.Doc) string {
+	statuses := listWorkflowStatusesInner(doc)
+	if i := slices.IndexFunc(statuses, func(s WorkflowStatus) bool { return s.Default }); i >= 0 {
+		return statuses[i].Name
+	} else if slices.ContainsFunc(statuses, func(s WorkflowStatus) bool { return s.Name == "open" }) {
+		return "open"
+	} else if i := slices.IndexFunc(statuses, func(s WorkflowStatus) bool { return s.Category == "open" }); i >= 0 {
+		return statuses[i].Name
+	}
+	return "open"
+}
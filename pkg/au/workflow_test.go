@@ -0,0 +1,112 @@
+package au
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/aurelian-one/au/internal"
+)
+
+func TestWorkflowStatuses(t *testing.T) {
+	s := newDirectoryStorage(t)
+	ws, _ := s.CreateWorkspace(context.Background(), CreateWorkspaceParams{Alias: "testing"})
+	wsp, _ := s.OpenWorkspace(context.Background(), ws.Id, true)
+	author := "Example <email@me.com>"
+	ctx := context.Background()
+
+	legacy, err := wsp.CreateTodo(ctx, CreateTodoParams{Title: "Before the workflow", CreatedBy: author})
+	assert.NoError(t, err)
+
+	_, err = wsp.SetWorkflowStatus(ctx, SetWorkflowStatusParams{Name: "In Progress", Category: "open", UpdatedBy: author})
+	assert.EqualError(t, err, "status 'In Progress' must be lowercase letters, digits, and dashes")
+	_, err = wsp.SetWorkflowStatus(ctx, SetWorkflowStatusParams{Name: "todo", Category: "pending", UpdatedBy: author})
+	assert.EqualError(t, err, "invalid category: status must be open or closed")
+	_, err = wsp.SetWorkflowStatus(ctx, SetWorkflowStatusParams{Name: "todo", Category: "open", Transitions: []string{"done"}, UpdatedBy: author})
+	assert.EqualError(t, err, "transition target status 'done' does not exist")
+
+	for _, p := range []SetWorkflowStatusParams{
+		{Name: "done", Category: "closed"},
+		{Name: "review", Category: "open", Transitions: []string{"done"}},
+		{Name: "in-progress", Category: "open", Transitions: []string{"review"}},
+		{Name: "todo", Category: "open", Transitions: []string{"in-progress"}, Default: true},
+	} {
+		p.UpdatedBy = author
+		_, err := wsp.SetWorkflowStatus(ctx, p)
+		assert.NoError(t, err)
+	}
+	statuses, err := wsp.ListWorkflowStatuses(ctx)
+	assert.NoError(t, err)
+	assert.Equal(t, []WorkflowStatus{
+		{Name: "done", Category: "closed", Transitions: []string{}},
+		{Name: "in-progress", Category: "open", Transitions: []string{"review"}},
+		{Name: "review", Category: "open", Transitions: []string{"done"}},
+		{Name: "todo", Category: "open", Transitions: []string{"in-progress"}, Default: true},
+	}, statuses)
+
+	_, err = wsp.CreateTodo(ctx, CreateTodoParams{Title: "Bad", Status: internal.Ref("closed"), CreatedBy: author})
+	assert.EqualError(t, err, "status must be one of done, in-progress, review, todo")
+	_, err = wsp.CreateTodo(ctx, CreateTodoParams{
+		Title: "Bad", Annotations: map[string]string{AurelianWorkflowStatusAnnotation: "done"}, CreatedBy: author,
+	})
+	assert.EqualError(t, err, "invalid annotation value for 'https://aurelian.one/annotations/workflow-status': the workflow status is set through the todo status")
+
+	td, err := wsp.CreateTodo(ctx, CreateTodoParams{Title: "Do the thing", CreatedBy: author})
+	assert.NoError(t, err)
+	assert.Equal(t, "open", td.Status)
+	assert.Equal(t, "todo", TodoWorkflowStatus(td, statuses))
+
+	// the params can be reused without carrying the workflow status of one todo into the next
+	reused := CreateTodoParams{Title: "Reused", Status: internal.Ref("done"), Annotations: map[string]string{}, CreatedBy: author}
+	first, err := wsp.CreateTodo(ctx, reused)
+	assert.NoError(t, err)
+	assert.Equal(t, "done", TodoWorkflowStatus(first, statuses))
+	assert.Empty(t, reused.Annotations)
+	reused.Status = nil
+	second, err := wsp.CreateTodo(ctx, reused)
+	assert.NoError(t, err)
+	assert.Equal(t, "todo", TodoWorkflowStatus(second, statuses))
+
+	_, err = wsp.EditTodo(ctx, td.Id, EditTodoParams{Status: internal.Ref("done"), UpdatedBy: author})
+	assert.EqualError(t, err, "cannot change status of todo '"+td.Id+"' from 'todo' to 'done', allowed transitions are in-progress")
+
+	td, err = wsp.EditTodo(ctx, td.Id, EditTodoParams{
+		Status: internal.Ref("in-progress"), Annotations: map[string]string{AurelianStatusReasonAnnotation: "Picked up by bob"}, UpdatedBy: author,
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, "in-progress", TodoWorkflowStatus(td, statuses))
+	assert.Equal(t, "Picked up by bob", td.Annotations[AurelianStatusReasonAnnotation])
+
+	td, err = wsp.EditTodo(ctx, td.Id, EditTodoParams{Status: internal.Ref("review"), UpdatedBy: author})
+	assert.NoError(t, err)
+	assert.NotContains(t, td.Annotations, AurelianStatusReasonAnnotation)
+	td, err = wsp.EditTodo(ctx, td.Id, EditTodoParams{Status: internal.Ref("done"), UpdatedBy: author})
+	assert.NoError(t, err)
+	assert.Equal(t, "closed", td.Status)
+	assert.Equal(t, "done", TodoWorkflowStatus(td, statuses))
+
+	// a client that does not understand workflows may reopen the todo without clearing the workflow status
+	reopened := *td
+	reopened.Status = "open"
+	assert.Equal(t, "open", TodoWorkflowStatus(&reopened, statuses))
+
+	// todos from before the workflow may move to any status
+	legacy, err = wsp.EditTodo(ctx, legacy.Id, EditTodoParams{Status: internal.Ref("review"), UpdatedBy: author})
+	assert.NoError(t, err)
+	assert.Equal(t, "review", TodoWorkflowStatus(legacy, statuses))
+
+	// removing the only transition of in-progress would allow it to move to any status
+	assert.EqualError(t, wsp.DeleteWorkflowStatus(ctx, "review", DeleteWorkflowStatusParams{DeletedBy: author}), "cannot delete status 'review' since it is the only transition of status 'in-progress'")
+	_, err = wsp.SetWorkflowStatus(ctx, SetWorkflowStatusParams{Name: "in-progress", Category: "open", Transitions: []string{"review", "done"}, UpdatedBy: author})
+	assert.NoError(t, err)
+	assert.NoError(t, wsp.DeleteWorkflowStatus(ctx, "review", DeleteWorkflowStatusParams{DeletedBy: author}))
+	assert.EqualError(t, wsp.DeleteWorkflowStatus(ctx, "review", DeleteWorkflowStatusParams{DeletedBy: author}), "status 'review' does not exist")
+	statuses, _ = wsp.ListWorkflowStatuses(ctx)
+	assert.Len(t, statuses, 3)
+	assert.Equal(t, []string{"done"}, statuses[1].Transitions)
+
+	legacy, err = wsp.EditTodo(ctx, legacy.Id, EditTodoParams{Status: internal.Ref("done"), UpdatedBy: author})
+	assert.NoError(t, err)
+	assert.Equal(t, "closed", legacy.Status)
+}
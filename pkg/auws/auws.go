@@ -3,6 +3,7 @@ package auws
 import (
 	"context"
 	"errors"
+	"fmt"
 	"log/slog"
 	"math/rand"
 	"net"
@@ -28,7 +29,24 @@ var DefaultPingTimeout = time.Second * 30
 // DefaultPongTimeout is the timeout to wait for a pong reply for the pings we send
 var DefaultPongTimeout = time.Second * 10
 
-func writePump(ctx context.Context, logger *slog.Logger, conn *websocket.Conn, messages chan []byte, writeWait time.Duration, pingPeriod time.Duration) error {
+// maxCloseReasonLength is the longest reason that fits in a websocket close frame.
+const maxCloseReasonLength = 123
+
+// ChangeFilter inspects changes received from the remote side before they are applied to the document. The staged
+// document already contains the changes so that their effect can be inspected. Returning an error rejects the changes
+// and ends the sync with the error as the reason reported to the remote side.
+type ChangeFilter func(staged *automerge.Doc, changes []*automerge.Change) error
+
+// RejectedError is returned when the remote side rejected the changes we sent it.
+type RejectedError struct {
+	Reason string
+}
+
+func (e *RejectedError) Error() string {
+	return fmt.Sprintf("changes rejected by remote: %s", e.Reason)
+}
+
+func writePump(ctx context.Context, logger *slog.Logger, conn *websocket.Conn, messages chan []byte, closeMessage *[]byte, writeWait time.Duration, pingPeriod time.Duration) error {
 	logger.DebugContext(ctx, "sending pings on an interval", "interval", pingPeriod)
 	ticker := time.NewTicker(pingPeriod)
 	defer func() {
@@ -43,7 +61,7 @@ func writePump(ctx context.Context, logger *slog.Logger, conn *websocket.Conn, m
 		case message, ok := <-messages:
 			if !ok {
 				logger.DebugContext(ctx, "sending close message")
-				return conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(writeWait))
+				return conn.WriteControl(websocket.CloseMessage, *closeMessage, time.Now().Add(writeWait))
 			}
 			logger.DebugContext(ctx, "sending binary message", "size_bytes", len(message))
 			_ = conn.SetWriteDeadline(time.Now().Add(writeWait))
@@ -104,11 +122,16 @@ func headsEqual(a []automerge.ChangeHash, b []automerge.ChangeHash) bool {
 	return true
 }
 
-func Sync(ctx context.Context, logger *slog.Logger, conn *websocket.Conn, doc *automerge.Doc, untilCaughtUp bool) error {
+// Sync synchronises the document with the remote side of the connection. When filters are given, incoming changes are
+// staged on a fork of the document and only applied to it once every filter accepts them.
+func Sync(ctx context.Context, logger *slog.Logger, conn *websocket.Conn, doc *automerge.Doc, untilCaughtUp bool, filters ...ChangeFilter) error {
 	wg := new(sync.WaitGroup)
 
 	incomingMessages := make(chan []byte)
 	outGoingMessages := make(chan []byte)
+	// the close message is only read by the write-pump after the outgoing messages are closed
+	closeMessage := websocket.FormatCloseMessage(websocket.CloseNormalClosure, "")
+	var readError error
 
 	// set timeouts for this sync session
 	writeWait := DefaultWriteTimeout
@@ -132,15 +155,23 @@ func Sync(ctx context.Context, logger *slog.Logger, conn *websocket.Conn, doc *a
 	go func() {
 		defer wg.Done()
 		logger := logger.WithGroup("read-pump")
-		logger.DebugContext(ctx, "read-pump finished", "err", readPump(ctx, logger, conn, incomingMessages, pingWait))
+		readError = readPump(ctx, logger, conn, incomingMessages, pingWait)
+		logger.DebugContext(ctx, "read-pump finished", "err", readError)
 	}()
 	go func() {
 		defer wg.Done()
 		logger := logger.WithGroup("write-pump")
-		logger.DebugContext(ctx, "write-pump finished", "err", writePump(ctx, logger, conn, outGoingMessages, writeWait, pingPeriod))
+		logger.DebugContext(ctx, "write-pump finished", "err", writePump(ctx, logger, conn, outGoingMessages, &closeMessage, writeWait, pingPeriod))
 	}()
 
-	ss := automerge.NewSyncState(doc)
+	staged := doc
+	if len(filters) > 0 {
+		var err error
+		if staged, err = doc.Fork(); err != nil {
+			return errors.Join(errors.New("failed to stage document"), err)
+		}
+	}
+	ss := automerge.NewSyncState(staged)
 	flush := func() {
 		for {
 			if msg, ok := ss.GenerateMessage(); ok {
@@ -156,10 +187,24 @@ func Sync(ctx context.Context, logger *slog.Logger, conn *websocket.Conn, doc *a
 
 	var lastError error
 	for msg := range incomingMessages {
-		if sm, err := ss.ReceiveMessage(msg); err != nil {
+		sm, err := ss.ReceiveMessage(msg)
+		if err != nil {
 			lastError = err
 			break
-		} else if untilCaughtUp && headsEqual(sm.Heads(), doc.Heads()) {
+		}
+		if len(filters) > 0 {
+			if err := applyFiltered(doc, staged, filters); err != nil {
+				logger.DebugContext(ctx, "rejecting changes", "err", err)
+				lastError = err
+				reason := err.Error()
+				if len(reason) > maxCloseReasonLength {
+					reason = reason[:maxCloseReasonLength]
+				}
+				closeMessage = websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason)
+				break
+			}
+		}
+		if untilCaughtUp && headsEqual(sm.Heads(), doc.Heads()) {
 			break
 		}
 
@@ -168,6 +213,11 @@ func Sync(ctx context.Context, logger *slog.Logger, conn *websocket.Conn, doc *a
 	}
 	logger.DebugContext(ctx, "closing outgoing")
 	close(outGoingMessages)
+	// drain anything else the remote side sends so that the read-pump is not blocked when we stopped early
+	go func() {
+		for range incomingMessages {
+		}
+	}()
 
 	waitComplete := make(chan struct{})
 	go func() {
@@ -177,8 +227,32 @@ func Sync(ctx context.Context, logger *slog.Logger, conn *websocket.Conn, doc *a
 
 	select {
 	case <-waitComplete:
+		var closeError *websocket.CloseError
+		if lastError == nil && errors.As(readError, &closeError) && closeError.Code == websocket.ClosePolicyViolation {
+			return &RejectedError{Reason: closeError.Text}
+		}
 		return lastError
 	case <-ctx.Done():
 		return errors.Join(lastError, ctx.Err())
 	}
 }
+
+// applyFiltered runs the filters over the changes that are staged but not yet in the document and applies them to the
+// document when they are all accepted.
+func applyFiltered(doc *automerge.Doc, staged *automerge.Doc, filters []ChangeFilter) error {
+	changes, err := staged.Changes(doc.Heads()...)
+	if err != nil {
+		return errors.Join(errors.New("failed to get staged changes"), err)
+	} else if len(changes) == 0 {
+		return nil
+	}
+	for _, filter := range filters {
+		if err := filter(staged, changes); err != nil {
+			return err
+		}
+	}
+	if err := doc.Apply(changes...); err != nil {
+		return errors.Join(errors.New("failed to apply staged changes"), err)
+	}
+	return nil
+}